@@ -2,22 +2,26 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
 	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
 	"sync"
 	"syscall"
 	"time"
 
 	"github.com/child6yo/wbtech-l3-delayed-notifyer/internal/controller/consumer"
 	httpctrl "github.com/child6yo/wbtech-l3-delayed-notifyer/internal/controller/http"
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/internal/infrastructure/fetcher"
 	"github.com/child6yo/wbtech-l3-delayed-notifyer/internal/infrastructure/logger"
 	"github.com/child6yo/wbtech-l3-delayed-notifyer/internal/infrastructure/messaging"
 	"github.com/child6yo/wbtech-l3-delayed-notifyer/internal/infrastructure/poller"
 	"github.com/child6yo/wbtech-l3-delayed-notifyer/internal/infrastructure/repository"
 	"github.com/child6yo/wbtech-l3-delayed-notifyer/internal/infrastructure/sender"
 	"github.com/child6yo/wbtech-l3-delayed-notifyer/internal/usecase"
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
 	"github.com/wb-go/wbf/config"
 	"github.com/wb-go/wbf/ginext"
 	"github.com/wb-go/wbf/zlog"
@@ -27,8 +31,34 @@ const (
 	createNotificationRoute    = "/notify"
 	getNotificationStatusRoute = "/notify/:id"
 	deleteNotificationRoute    = "/notify/:id"
+	snoozeNotificationRoute    = "/notify/:id/snooze"
+
+	templatesRoute = "/templates"
+	templateRoute  = "/templates/:id"
+
+	escalationPoliciesRoute = "/escalation-policies"
+	escalationPolicyRoute   = "/escalation-policies/:id"
+
+	recipientsRoute  = "/recipients"
+	recipientRoute   = "/recipients/:id"
+	preferencesRoute = "/recipients/:id/preferences"
+
+	ackRoute    = "/ack/:token"
+	snoozeRoute = "/snooze/:token"
+
+	preferencesPageRoute = "/preferences/:token"
+	unsubscribeRoute     = "/preferences/:token/unsubscribe"
+
+	telegramWebhookRoute = "/telegram/webhook"
+
+	readyRoute   = "/ready"
+	metricsRoute = "/metrics"
 )
 
+// interruptTimeout сколько ждать прерванных воркеров: им осталось только вернуть уведомления в очередь,
+// а зависший на хранилище или отправщике воркер не должен задерживать остановку бесконечно.
+const interruptTimeout = 10 * time.Second
+
 type appConfig struct {
 	address string
 
@@ -42,17 +72,59 @@ type appConfig struct {
 
 	pollerTick int
 
+	enabledChannels []models.ChannelName
+
+	readyRequiredChannels []models.ChannelName
+	readyCheckInterval    time.Duration
+
 	consumerNumWorkers int
 
+	shutdownGracePeriod time.Duration
+
 	sendRetryAttemps int
 	sendRetryDelay   time.Duration
 	sendRetryBackoff float64
 
-	emailFrom string
-	emailHost string
-	emailPort string
-
-	tgBotToken string
+	rateLimits      map[models.ChannelName]models.RateLimit
+	rateLimitAction models.RateLimitAction
+
+	breakerFailures int
+	breakerCooldown time.Duration
+
+	emailFrom        string
+	emailFromName    string
+	emailSubject     string
+	emailHost        string
+	emailPort        string
+	emailUsername    string
+	emailPassword    string
+	emailAuth        string
+	emailTLS         string
+	emailPoolSize    int
+	emailIdleTimeout time.Duration
+	emailTimeout     time.Duration
+	emailPerSecond   int
+
+	attachmentMaxSize      int64
+	attachmentsMaxTotal    int64
+	attachmentFetchTimeout time.Duration
+	allowPrivateURLs       bool
+
+	defaultLocale   string
+	localeFallbacks []string
+
+	ackBaseURL        string
+	ackSecret         string
+	ackRepeatInterval time.Duration
+	ackMaxRepeats     int
+
+	webhookTimeout time.Duration
+
+	tgBotToken      string
+	tgWebhookURL    string
+	tgWebhookSecret string
+	tgPerSecond     int
+	tgChatPerSecond int
 }
 
 func initConfig(configFilePath, envFilePath, envPrefix string) (*appConfig, error) {
@@ -77,21 +149,169 @@ func initConfig(configFilePath, envFilePath, envPrefix string) (*appConfig, erro
 
 	appConfig.pollerTick = cfg.GetInt("poller_tick_milliseconds")
 
+	appConfig.enabledChannels, err = parseChannels(cfg.GetStringSlice("enabled_channels"), "enabled_channels")
+	if err != nil {
+		return appConfig, err
+	}
+	appConfig.readyRequiredChannels, err = parseChannels(cfg.GetStringSlice("ready_required_channels"), "ready_required_channels")
+	if err != nil {
+		return appConfig, err
+	}
+	appConfig.readyCheckInterval = time.Duration(cfg.GetInt("ready_check_interval_seconds")) * time.Second
+
 	appConfig.consumerNumWorkers = cfg.GetInt("consumer_num_workers")
 
+	appConfig.shutdownGracePeriod = time.Duration(cfg.GetInt("shutdown_grace_period_seconds")) * time.Second
+
 	appConfig.sendRetryAttemps = cfg.GetInt("send_retry_attemps")
 	appConfig.sendRetryDelay = time.Duration(cfg.GetInt("send_retry_delay_seconds")) * time.Second
 	appConfig.sendRetryBackoff = cfg.GetFloat64("send_retry_backoff")
 
+	appConfig.rateLimits = make(map[models.ChannelName]models.RateLimit)
+	for _, channel := range []models.ChannelName{models.ChannelEmail, models.ChannelTelegram, models.ChannelWebhook} {
+		key := "rate_limit_" + string(channel)
+		if limit := cfg.GetInt(key); limit > 0 {
+			appConfig.rateLimits[channel] = models.RateLimit{
+				Limit:  limit,
+				Window: time.Duration(cfg.GetInt(key+"_window_seconds")) * time.Second,
+			}
+		}
+	}
+	appConfig.rateLimitAction = models.RateLimitAction(cfg.GetString("rate_limit_action"))
+	switch appConfig.rateLimitAction {
+	case models.RateLimitDefer, models.RateLimitReject:
+	case "":
+		appConfig.rateLimitAction = models.RateLimitDefer
+	default:
+		return appConfig, fmt.Errorf("unknown rate_limit_action %q", appConfig.rateLimitAction)
+	}
+
+	appConfig.breakerFailures = cfg.GetInt("circuit_breaker_failures")
+	appConfig.breakerCooldown = time.Duration(cfg.GetInt("circuit_breaker_cooldown_seconds")) * time.Second
+
 	appConfig.emailFrom = cfg.GetString("smtp_from")
+	appConfig.emailFromName = cfg.GetString("smtp_from_name")
+	appConfig.emailSubject = cfg.GetString("smtp_default_subject")
 	appConfig.emailHost = cfg.GetString("smtp_host")
 	appConfig.emailPort = cfg.GetString("smtp_port")
+	appConfig.emailUsername = cfg.GetString("smtp_username")
+	appConfig.emailPassword = cfg.GetString("SMTP_PASSWORD")
+	appConfig.emailAuth = cfg.GetString("smtp_auth")
+	appConfig.emailTLS = cfg.GetString("smtp_tls")
+	appConfig.emailPoolSize = cfg.GetInt("smtp_pool_size")
+	appConfig.emailIdleTimeout = time.Duration(cfg.GetInt("smtp_idle_timeout_seconds")) * time.Second
+	appConfig.emailTimeout = time.Duration(cfg.GetInt("smtp_timeout_seconds")) * time.Second
+	appConfig.emailPerSecond = cfg.GetInt("smtp_messages_per_second")
+
+	appConfig.attachmentMaxSize = int64(cfg.GetInt("attachment_max_size_bytes"))
+	appConfig.attachmentsMaxTotal = int64(cfg.GetInt("attachments_max_total_bytes"))
+	appConfig.attachmentFetchTimeout = time.Duration(cfg.GetInt("attachment_fetch_timeout_seconds")) * time.Second
+	appConfig.allowPrivateURLs = cfg.GetBool("allow_private_urls")
+
+	appConfig.defaultLocale = cfg.GetString("default_locale")
+	appConfig.localeFallbacks = cfg.GetStringSlice("locale_fallbacks")
+
+	appConfig.ackBaseURL = cfg.GetString("ack_base_url")
+	appConfig.ackSecret = cfg.GetString("ACK_SECRET")
+	appConfig.ackRepeatInterval = time.Duration(cfg.GetInt("ack_repeat_interval_seconds")) * time.Second
+	appConfig.ackMaxRepeats = cfg.GetInt("ack_max_repeats")
+
+	appConfig.webhookTimeout = time.Duration(cfg.GetInt("webhook_timeout_seconds")) * time.Second
 
 	appConfig.tgBotToken = cfg.GetString("TG_BOT_TOKEN")
+	appConfig.tgWebhookURL = cfg.GetString("tg_webhook_url")
+	appConfig.tgWebhookSecret = cfg.GetString("TG_WEBHOOK_SECRET")
+	appConfig.tgPerSecond = cfg.GetInt("tg_messages_per_second")
+	appConfig.tgChatPerSecond = cfg.GetInt("tg_chat_messages_per_second")
 
 	return appConfig, nil
 }
 
+// parseChannels проверяет названия каналов из параметра конфига key.
+func parseChannels(names []string, key string) ([]models.ChannelName, error) {
+	channels := make([]models.ChannelName, 0, len(names))
+	for _, name := range names {
+		channel := models.ChannelName(name)
+		switch channel {
+		case models.ChannelEmail, models.ChannelTelegram, models.ChannelWebhook:
+			channels = append(channels, channel)
+		default:
+			return nil, fmt.Errorf("unknown channel %q in %s", name, key)
+		}
+	}
+	return channels, nil
+}
+
+// shutdownSteps содержит компоненты, останавливаемые при завершении работы.
+type shutdownSteps struct {
+	httpServer   *http.Server
+	stopBot      context.CancelFunc // nil, если телеграм бот не запущен
+	botDone      <-chan struct{}
+	pollerDone   <-chan struct{}
+	broker       *messaging.RabbitMQBroker
+	consumerDone <-chan struct{}
+	cancelWork   context.CancelFunc
+	redis        *repository.Redis
+	emailSender  *sender.Email    // nil, если канал выключен
+	tgSender     *sender.Telegram // nil, если канал выключен или недоступен
+}
+
+// shutdown останавливает сервис по порядку: прием запросов, телеграм бот, поллер, консьюмер брокера,
+// затем ждет воркеров не дольше gracePeriod. По истечении периода воркеры прерываются
+// и возвращают необработанные уведомления в отложенную очередь.
+// Соединения закрываются последними, когда они уже никому не нужны.
+func shutdown(lgr zlog.Zerolog, gracePeriod time.Duration, s shutdownSteps) {
+	if err := s.httpServer.Shutdown(context.Background()); err != nil {
+		lgr.Err(err).Send()
+	}
+
+	// обработчики команд бота работают с редисом, поэтому бот останавливается до его закрытия
+	if s.stopBot != nil {
+		s.stopBot()
+		<-s.botDone
+	}
+
+	// поллер завершается по отмене сигнального контекста
+	<-s.pollerDone
+
+	if err := s.broker.StopConsuming(); err != nil {
+		lgr.Err(err).Send()
+	}
+
+	select {
+	case <-s.consumerDone:
+	case <-time.After(gracePeriod):
+		lgr.Warn().Dur("grace_period", gracePeriod).Msg("grace period expired, interrupting workers")
+		s.cancelWork()
+
+		select {
+		case <-s.consumerDone:
+		case <-time.After(interruptTimeout):
+			lgr.Error().Dur("timeout", interruptTimeout).Msg("interrupted workers did not stop, their notifications stay in sending status")
+		}
+	}
+
+	if err := s.redis.Close(); err != nil {
+		lgr.Err(err).Send()
+	}
+
+	if err := s.broker.Close(); err != nil {
+		lgr.Err(err).Send()
+	}
+
+	if s.emailSender != nil {
+		if err := s.emailSender.Close(); err != nil {
+			lgr.Err(err).Send()
+		}
+	}
+
+	if s.tgSender != nil {
+		if err := s.tgSender.Stop(context.Background()); err != nil {
+			lgr.Err(err).Send()
+		}
+	}
+}
+
 func main() {
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
@@ -123,37 +343,175 @@ func main() {
 		}
 	}()
 
-	pl := poller.NewRedisPoller(rds, pbl, cfg.redisDelayedQueueName, logger.NewLoggerAdapter(lgr))
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		pl.Run(ctx, time.NewTicker(time.Duration(cfg.pollerTick)*time.Millisecond))
-	}()
+	rcm := usecase.NewRecipientManager(rds)
+	rdc := usecase.NewReadinessChecker(cfg.readyCheckInterval)
 
-	emailSender := sender.NewEmail(cfg.emailFrom, cfg.emailHost, cfg.emailPort)
+	// у вебхуков адрес свой у каждого уведомления, поэтому отказы одного не говорят о неисправности отправщика
+	brk := usecase.NewCircuitBreakers(cfg.breakerFailures, cfg.breakerCooldown)
 
-	tgSender, err := sender.NewTelegram(cfg.tgBotToken)
-	if err != nil {
-		lgr.Fatal().Err(err).Send()
+	// отправщики выключенных и недоступных каналов передаются в NotificationSender как nil,
+	// поэтому хранятся в интерфейсах, чтобы не получить интерфейс с nil указателем
+	var (
+		emailSender *sender.Email
+		emailCh     interface {
+			Send(ctx context.Context, email models.EmailMessage) error
+		}
+		tgCh interface {
+			Send(ctx context.Context, message models.TelegramMessage) error
+		}
+		whCh interface {
+			Send(ctx context.Context, message models.WebhookMessage) error
+		}
+	)
+
+	if slices.Contains(cfg.enabledChannels, models.ChannelEmail) {
+		emailSender, err = sender.NewEmail(sender.EmailConfig{
+			From:           cfg.emailFrom,
+			FromName:       cfg.emailFromName,
+			DefaultSubject: cfg.emailSubject,
+			Host:           cfg.emailHost,
+			Port:           cfg.emailPort,
+			Username:       cfg.emailUsername,
+			Password:       cfg.emailPassword,
+			Auth:           cfg.emailAuth,
+			TLS:            cfg.emailTLS,
+			PoolSize:       cfg.emailPoolSize,
+			IdleTimeout:    cfg.emailIdleTimeout,
+			Timeout:        cfg.emailTimeout,
+			Throttle:       sender.NewThrottle(rds, "email", cfg.emailPerSecond),
+		})
+		if err != nil {
+			lgr.Fatal().Err(err).Send()
+		}
+		emailCh = emailSender
+		rdc.AddChannel(models.ChannelEmail, emailSender)
+		if cfg.breakerFailures > 0 {
+			brk.AddChannel(models.ChannelEmail)
+		}
 	}
-	wg.Add(1)
+
+	if slices.Contains(cfg.enabledChannels, models.ChannelWebhook) {
+		whSender := sender.NewWebhook(cfg.webhookTimeout, cfg.allowPrivateURLs)
+		whCh = whSender
+		rdc.AddChannel(models.ChannelWebhook, whSender)
+	}
+
+	ackSecret := []byte(cfg.ackSecret)
+	if len(ackSecret) == 0 {
+		// без постоянного ключа ссылки подтверждения перестанут работать после перезапуска
+		lgr.Warn().Msg("ACK_SECRET is not set, using a random key")
+		ackSecret = make([]byte, 32)
+		if _, err := rand.Read(ackSecret); err != nil {
+			lgr.Fatal().Err(err).Send()
+		}
+	}
+	auc := usecase.NewAckManager(rds, ackSecret, cfg.ackBaseURL, cfg.redisDelayedQueueName)
+
+	tuc := usecase.NewTemplateManager(rds, cfg.defaultLocale, cfg.localeFallbacks)
+	euc := usecase.NewEscalationManager(rds)
+	nuc := usecase.NewNotificationCreator(rds, tuc, euc, rcm, cfg.redisDelayedQueueName, logger.NewLoggerAdapter(lgr))
+
+	pl := poller.NewRedisPoller(rds, pbl, usecase.NewQuietHoursChecker(rcm), nuc, cfg.redisDelayedQueueName, logger.NewLoggerAdapter(lgr))
+	pollerDone := make(chan struct{})
 	go func() {
-		defer wg.Done()
-		tgSender.Start(ctx)
+		defer close(pollerDone)
+		pl.Run(ctx, time.NewTicker(time.Duration(cfg.pollerTick)*time.Millisecond))
 	}()
+	snm := usecase.NewSnoozeManager(nuc, ackSecret, cfg.ackBaseURL)
+	pfm := usecase.NewPreferenceManager(rds, rcm, ackSecret, cfg.ackBaseURL)
+
+	var (
+		tgSender *sender.Telegram
+		stopBot  context.CancelFunc
+		botDone  <-chan struct{}
+	)
+	if slices.Contains(cfg.enabledChannels, models.ChannelTelegram) {
+		if cfg.tgWebhookURL != "" && cfg.tgWebhookSecret == "" {
+			// без секрета кто угодно сможет присылать боту поддельные обновления
+			lgr.Fatal().Msg("TG_WEBHOOK_SECRET is required when tg_webhook_url is set")
+		}
+		tgSender, err = sender.NewTelegram(sender.TelegramConfig{
+			Token:         cfg.tgBotToken,
+			WebhookURL:    cfg.tgWebhookURL,
+			WebhookSecret: cfg.tgWebhookSecret,
+			Throttle:      sender.NewThrottle(rds, "telegram", cfg.tgPerSecond),
+			ChatThrottle:  sender.NewThrottle(rds, "telegram.chat", cfg.tgChatPerSecond),
+		}, auc, snm, nuc)
+		if err != nil {
+			// остальные каналы продолжают работать, а телеграм отображается в проверке готовности
+			// и считается выключенным, чтобы уведомления по нему отклонялись при создании
+			lgr.Err(err).Msg("telegram channel is unavailable")
+			rdc.AddUnavailableChannel(models.ChannelTelegram, err)
+			cfg.enabledChannels = slices.DeleteFunc(cfg.enabledChannels, func(name models.ChannelName) bool {
+				return name == models.ChannelTelegram
+			})
+		} else {
+			tgCh = tgSender
+			rdc.AddChannel(models.ChannelTelegram, tgSender)
+			if cfg.breakerFailures > 0 {
+				brk.AddChannel(models.ChannelTelegram)
+			}
+
+			if err := tgSender.RegisterWebhook(ctx); err != nil {
+				lgr.Err(err).Msg("failed to register telegram webhook")
+			}
+			// бот останавливается при завершении отдельно, после того как http сервер перестанет принимать вебхуки
+			var botCtx context.Context
+			botCtx, stopBot = context.WithCancel(context.Background())
+			done := make(chan struct{})
+			botDone = done
+			go func() {
+				defer close(done)
+				tgSender.Start(botCtx)
+			}()
+		}
+	}
 
-	ns := usecase.NewNotificationSender(
-		emailSender, tgSender, rds,
-		cfg.sendRetryAttemps, cfg.sendRetryDelay, cfg.sendRetryBackoff)
+	attachmentLoader := usecase.NewAttachmentLoader(
+		rds, fetcher.NewHTTP(cfg.attachmentFetchTimeout, cfg.attachmentMaxSize, cfg.allowPrivateURLs), cfg.attachmentsMaxTotal)
+
+	ns := usecase.NewNotificationSender(usecase.NotificationSenderConfig{
+		EmailSender:    emailCh,
+		TelegramSender: tgCh,
+		WebhookSender:  whCh,
+		Storage:        rds,
+		Rescheduler:    nuc,
+		Attachments:    attachmentLoader,
+		Templates:      tuc,
+		Acks:           auc,
+		Mutes:          nuc,
+		Snoozes:        snm,
+		Recipients:     rcm,
+		Preferences:    pfm,
+		Limits:         usecase.NewRateLimiter(rds, cfg.rateLimits, cfg.rateLimitAction),
+		Breakers:       brk,
+		Digests:        nuc,
+		RetryAttempts:  cfg.sendRetryAttemps,
+		RetryDelay:     cfg.sendRetryDelay,
+		RetryBackoff:   cfg.sendRetryBackoff,
+	})
 	cnsHandler := consumer.NewNotificationConsumer(msgChan, logger.NewLoggerAdapter(lgr), ns)
-	wg.Add(1)
+
+	// воркеры работают в собственном контексте, который отменяется только
+	// по истечении grace-периода, чтобы сигнал остановки не обрывал отправку
+	workCtx, cancelWork := context.WithCancel(context.Background())
+	defer cancelWork()
+	consumerDone := make(chan struct{})
 	go func() {
-		defer wg.Done()
-		cnsHandler.Consume(ctx, cfg.consumerNumWorkers)
+		defer close(consumerDone)
+		cnsHandler.Consume(workCtx, cfg.consumerNumWorkers)
 	}()
 
-	nuc := usecase.NewNotificationCreator(rds, cfg.redisDelayedQueueName)
-	nc := httpctrl.NewNotificationsController(nuc)
+	nc := httpctrl.NewNotificationsController(
+		nuc, auc, cfg.enabledChannels, cfg.attachmentMaxSize, cfg.attachmentsMaxTotal,
+		models.AckPolicy{RepeatInterval: cfg.ackRepeatInterval, MaxRepeats: cfg.ackMaxRepeats})
+	tc := httpctrl.NewTemplatesController(tuc)
+	ec := httpctrl.NewEscalationsController(euc, cfg.enabledChannels)
+	rc := httpctrl.NewRecipientsController(rcm)
+	pc := httpctrl.NewPreferencesController(pfm)
+	ac := httpctrl.NewAckController(auc)
+	sc := httpctrl.NewSnoozeController(snm)
+	hc := httpctrl.NewHealthController(rdc, brk, cfg.readyRequiredChannels)
 	mdlw := httpctrl.NewMiddleware(logger.NewLoggerAdapter(lgr))
 
 	srv := ginext.New("")
@@ -161,6 +519,38 @@ func main() {
 	srv.POST(createNotificationRoute, nc.CreateNotification)
 	srv.GET(getNotificationStatusRoute, nc.GetNotificationStatus)
 	srv.DELETE(deleteNotificationRoute, nc.DeleteNotification)
+	srv.POST(snoozeNotificationRoute, nc.SnoozeNotification)
+	srv.POST(templatesRoute, tc.CreateTemplate)
+	srv.GET(templatesRoute, tc.ListTemplates)
+	srv.GET(templateRoute, tc.GetTemplate)
+	srv.PUT(templateRoute, tc.UpdateTemplate)
+	srv.DELETE(templateRoute, tc.DeleteTemplate)
+	srv.POST(escalationPoliciesRoute, ec.CreateEscalationPolicy)
+	srv.GET(escalationPoliciesRoute, ec.ListEscalationPolicies)
+	srv.GET(escalationPolicyRoute, ec.GetEscalationPolicy)
+	srv.PUT(escalationPolicyRoute, ec.UpdateEscalationPolicy)
+	srv.DELETE(escalationPolicyRoute, ec.DeleteEscalationPolicy)
+	srv.POST(recipientsRoute, rc.CreateRecipient)
+	srv.GET(recipientsRoute, rc.ListRecipients)
+	srv.GET(recipientRoute, rc.GetRecipient)
+	srv.PUT(recipientRoute, rc.UpdateRecipient)
+	srv.DELETE(recipientRoute, rc.DeleteRecipient)
+	srv.GET(preferencesRoute, pc.GetPreferences)
+	srv.PUT(preferencesRoute, pc.UpdatePreferences)
+	srv.GET(ackRoute, ac.AckPage)
+	srv.POST(ackRoute, ac.Acknowledge)
+	srv.GET(snoozeRoute, sc.SnoozePage)
+	srv.POST(snoozeRoute, sc.Snooze)
+	srv.GET(preferencesPageRoute, pc.Page)
+	srv.POST(preferencesPageRoute, pc.SavePage)
+	srv.GET(unsubscribeRoute, pc.UnsubscribePage)
+	srv.POST(unsubscribeRoute, pc.Unsubscribe)
+	srv.GET(readyRoute, hc.Ready)
+	srv.GET(metricsRoute, hc.Metrics)
+	if tgSender != nil && cfg.tgWebhookURL != "" {
+		twc := httpctrl.NewTelegramWebhookController(tgSender.Bot.WebhookHandler(), cfg.tgWebhookSecret)
+		srv.POST(telegramWebhookRoute, twc.Update)
+	}
 
 	httpServer := &http.Server{
 		Addr:    cfg.address,
@@ -178,17 +568,18 @@ func main() {
 	<-ctx.Done()
 	lgr.Info().Msg("shutting down gracefully...")
 
-	if err := pbl.Close(); err != nil {
-		lgr.Err(err).Send()
-	}
-
-	if err := httpServer.Shutdown(context.Background()); err != nil {
-		lgr.Err(err).Send()
-	}
-
-	if err := tgSender.Stop(context.Background()); err != nil {
-		lgr.Err(err).Send()
-	}
+	shutdown(lgr, cfg.shutdownGracePeriod, shutdownSteps{
+		httpServer:   httpServer,
+		stopBot:      stopBot,
+		botDone:      botDone,
+		pollerDone:   pollerDone,
+		broker:       pbl,
+		consumerDone: consumerDone,
+		cancelWork:   cancelWork,
+		redis:        rds,
+		emailSender:  emailSender,
+		tgSender:     tgSender,
+	})
 
 	wg.Wait()
 
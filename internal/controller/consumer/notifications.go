@@ -3,9 +3,11 @@ package consumer
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"sync"
 
 	"github.com/child6yo/wbtech-l3-delayed-notifyer/internal/infrastructure/logger"
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/internal/usecase"
 	"github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
 )
 
@@ -19,6 +21,9 @@ type NotificationConsumer struct {
 
 	msgChan chan []byte
 	logger  logger.Logger
+
+	mu         sync.Mutex
+	handedBack []string // айди уведомлений, возвращенных в отложенную очередь из-за остановки
 }
 
 // NewNotificationConsumer создает новый NotificationConsumer.
@@ -26,42 +31,51 @@ func NewNotificationConsumer(msgChan chan []byte, logger logger.Logger, uc notif
 	return &NotificationConsumer{usecase: uc, msgChan: msgChan, logger: logger}
 }
 
-// Consume обрабатывает канал сообщений.
+// Consume обрабатывает канал сообщений до его закрытия.
 // Работает конкурретно в n-е количество воркеров.
+// После отмены ctx оставшиеся и прерванные уведомления не отправляются,
+// а возвращаются в отложенную очередь; по завершении логируется их сводка.
 func (c *NotificationConsumer) Consume(ctx context.Context, numWorkers int) {
 	var wg sync.WaitGroup
 	for i := 0; i < numWorkers; i++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			for {
-				select {
-				case <-ctx.Done():
-					return
-				case msg, ok := <-c.msgChan:
-					if !ok {
-						return
-					}
-					if len(msg) == 0 {
-						continue
-					}
-
-					c.logger.WithFields("msg", string(msg)).Debug("new msg consumed")
-
-					var notification models.DelayedNotification
-					if err := json.Unmarshal(msg, &notification); err != nil {
-						c.logger.WithFields("data", string(msg)).Error(err)
-						continue
-					}
-
-					if err := c.usecase.Send(ctx, notification); err != nil {
-						c.logger.WithFields("notification", notification).Error(err)
-					}
-				}
+			for msg := range c.msgChan {
+				c.handleMessage(ctx, msg)
 			}
 		}()
 	}
 
-	<-ctx.Done()
 	wg.Wait()
+
+	if len(c.handedBack) > 0 {
+		c.logger.WithFields("count", len(c.handedBack), "notificationIDs", c.handedBack).
+			Info("in-flight notifications requeued to delayed queue")
+	}
+}
+
+// обработка одного сообщения из очереди.
+func (c *NotificationConsumer) handleMessage(ctx context.Context, msg []byte) {
+	if len(msg) == 0 {
+		return
+	}
+
+	c.logger.WithFields("msg", string(msg)).Debug("new msg consumed")
+
+	var notification models.DelayedNotification
+	if err := json.Unmarshal(msg, &notification); err != nil {
+		c.logger.WithFields("data", string(msg)).Error(err)
+		return
+	}
+
+	err := c.usecase.Send(ctx, notification)
+	if errors.Is(err, usecase.ErrSendInterrupted) {
+		c.mu.Lock()
+		c.handedBack = append(c.handedBack, notification.ID)
+		c.mu.Unlock()
+	}
+	if err != nil {
+		c.logger.WithFields("notification", notification).Error(err)
+	}
 }
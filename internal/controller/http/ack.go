@@ -0,0 +1,79 @@
+package httpctrl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/internal/usecase"
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
+	"github.com/wb-go/wbf/ginext"
+)
+
+type ackUsecase interface {
+	Acknowledge(ctx context.Context, token, recipient, by string) (models.Acknowledgement, error)
+}
+
+// AckController http контроллер подтверждения получения уведомлений по ссылке из письма или вебхука.
+type AckController struct {
+	usecase ackUsecase
+}
+
+// NewAckController создает новый AckController.
+func NewAckController(uc ackUsecase) *AckController {
+	return &AckController{usecase: uc}
+}
+
+// ответы открываются в браузере получателя, поэтому отдаются страницей, а не JSON
+const (
+	ackPageConfirm = `<!DOCTYPE html><html><head><meta charset="utf-8"><title>Подтверждение получения</title></head><body><form method="post"><p>Подтвердить получение уведомления?</p><p><button type="submit">Подтвердить</button></p></form></body></html>`
+	ackPageOK      = `<!DOCTYPE html><html><head><meta charset="utf-8"><title>Получение подтверждено</title></head><body><p>Получение уведомления подтверждено.</p></body></html>`
+	ackPageInvalid = `<!DOCTYPE html><html><head><meta charset="utf-8"><title>Ссылка недействительна</title></head><body><p>Ссылка подтверждения недействительна.</p></body></html>`
+	ackPageError   = `<!DOCTYPE html><html><head><meta charset="utf-8"><title>Ошибка</title></head><body><p>Не удалось подтвердить получение, попробуйте позже.</p></body></html>`
+)
+
+// AckPage обрабатывает GET /ack/{token}?to= — страница подтверждения по ссылке из письма.
+// Ссылки открывают и почтовые сканеры, поэтому получение подтверждается только отправкой формы.
+func (ac *AckController) AckPage(c *ginext.Context) {
+	if _, ok := ac.parseRecipient(c); !ok {
+		return
+	}
+
+	c.Data(200, "text/html; charset=utf-8", []byte(ackPageConfirm))
+}
+
+// Acknowledge обрабатывает POST /ack/{token}?to= — подтверждение получения уведомления со страницы подтверждения
+// или запросом получателя вебхука.
+func (ac *AckController) Acknowledge(c *ginext.Context) {
+	recipient, ok := ac.parseRecipient(c)
+	if !ok {
+		return
+	}
+
+	_, err := ac.usecase.Acknowledge(c.Request.Context(), c.Param("token"), recipient, "")
+	switch {
+	case errors.Is(err, usecase.ErrInvalidAckToken):
+		c.Data(400, "text/html; charset=utf-8", []byte(ackPageInvalid))
+		_ = c.Error(fmt.Errorf("validation error: %w", err))
+	case err != nil:
+		c.Data(500, "text/html; charset=utf-8", []byte(ackPageError))
+		_ = c.Error(fmt.Errorf("acknowledge failed: %w", err))
+	default:
+		c.Data(200, "text/html; charset=utf-8", []byte(ackPageOK))
+	}
+}
+
+// parseRecipient читает получателя из ссылки, при его отсутствии отвечает страницей 400.
+// Токен без получателя выдается только кнопке в телеграм, поэтому по ссылке не принимается.
+func (ac *AckController) parseRecipient(c *ginext.Context) (string, bool) {
+	c.Set("request", c.Param("token"))
+
+	recipient := c.Query("to")
+	if recipient == "" {
+		c.Data(400, "text/html; charset=utf-8", []byte(ackPageInvalid))
+		_ = c.Error(errors.New("validation error: recipient is required"))
+		return "", false
+	}
+
+	return recipient, true
+}
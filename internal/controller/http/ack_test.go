@@ -0,0 +1,68 @@
+package httpctrl
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/internal/usecase"
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/wb-go/wbf/ginext"
+)
+
+type fakeAcks struct {
+	calls []string
+	err   error
+}
+
+func (f *fakeAcks) Acknowledge(_ context.Context, token, recipient, _ string) (models.Acknowledgement, error) {
+	f.calls = append(f.calls, token+" "+recipient)
+	return models.Acknowledgement{By: recipient}, f.err
+}
+
+func TestAckController(t *testing.T) {
+	t.Parallel()
+
+	serve := func(acks *fakeAcks, method, target string) *httptest.ResponseRecorder {
+		ac := NewAckController(acks)
+		srv := ginext.New("release")
+		srv.GET("/ack/:token", ac.AckPage)
+		srv.POST("/ack/:token", ac.Acknowledge)
+
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+		return rec
+	}
+
+	t.Run("link_opened", func(t *testing.T) {
+		// ссылку открывают и почтовые сканеры, поэтому GET только показывает форму
+		acks := &fakeAcks{}
+		rec := serve(acks, http.MethodGet, "/ack/token?to=user%40example.com")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `<form method="post">`)
+		assert.Empty(t, acks.calls)
+	})
+
+	t.Run("form_submitted", func(t *testing.T) {
+		acks := &fakeAcks{}
+		rec := serve(acks, http.MethodPost, "/ack/token?to=user%40example.com")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, []string{"token user@example.com"}, acks.calls)
+	})
+
+	t.Run("invalid_token", func(t *testing.T) {
+		rec := serve(&fakeAcks{err: usecase.ErrInvalidAckToken}, http.MethodPost, "/ack/token?to=user%40example.com")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("without_recipient", func(t *testing.T) {
+		for _, method := range []string{http.MethodGet, http.MethodPost} {
+			acks := &fakeAcks{}
+			rec := serve(acks, method, "/ack/token")
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Empty(t, acks.calls)
+		}
+	})
+}
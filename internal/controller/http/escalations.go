@@ -0,0 +1,153 @@
+package httpctrl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/internal/usecase"
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
+	"github.com/wb-go/wbf/ginext"
+)
+
+type escalationUsecase interface {
+	CreateEscalationPolicy(ctx context.Context, policy models.EscalationPolicy) (models.EscalationPolicy, error)
+	UpdateEscalationPolicy(ctx context.Context, id string, policy models.EscalationPolicy) (models.EscalationPolicy, error)
+	GetEscalationPolicy(ctx context.Context, id string) (models.EscalationPolicy, error)
+	ListEscalationPolicies(ctx context.Context) ([]models.EscalationPolicy, error)
+	DeleteEscalationPolicy(ctx context.Context, id string) error
+}
+
+// EscalationsController http контроллер политик эскалации.
+type EscalationsController struct {
+	usecase         escalationUsecase
+	enabledChannels []models.ChannelName // каналы, включенные в конфиге
+}
+
+// NewEscalationsController создает новый EscalationsController.
+func NewEscalationsController(uc escalationUsecase, enabledChannels []models.ChannelName) *EscalationsController {
+	return &EscalationsController{usecase: uc, enabledChannels: enabledChannels}
+}
+
+type escalationPolicyRequest struct {
+	Name  string                  `json:"name" binding:"required,max=255"`
+	Steps []models.EscalationStep `json:"steps" binding:"required,min=1,max=10,dive"`
+}
+
+// policy проверяет каналы шагов и возвращает политику из запроса.
+func (r escalationPolicyRequest) policy(enabledChannels []models.ChannelName) (models.EscalationPolicy, error) {
+	for i, step := range r.Steps {
+		// шаги эскалации всегда требуют подтверждения, поэтому ряд кнопок оставляется под него
+		if err := validateChannels(step.Channels, enabledChannels, "", 1); err != nil {
+			return models.EscalationPolicy{}, fmt.Errorf("step %d: %w", i, err)
+		}
+	}
+
+	return models.EscalationPolicy{Name: r.Name, Steps: r.Steps}, nil
+}
+
+// CreateEscalationPolicy обрабатывает POST /escalation-policies — создание политики эскалации.
+func (ec *EscalationsController) CreateEscalationPolicy(c *ginext.Context) {
+	policy, ok := ec.bind(c)
+	if !ok {
+		return
+	}
+
+	policy, err := ec.usecase.CreateEscalationPolicy(c.Request.Context(), policy)
+	if err != nil {
+		ec.handleError(c, "failed to create escalation policy", err)
+		return
+	}
+
+	c.JSON(201, policy)
+}
+
+// UpdateEscalationPolicy обрабатывает PUT /escalation-policies/{id} — замена шагов политики.
+// Уже запланированные уведомления идут по шагам, действовавшим при их создании.
+func (ec *EscalationsController) UpdateEscalationPolicy(c *ginext.Context) {
+	policy, ok := ec.bind(c)
+	if !ok {
+		return
+	}
+
+	policy, err := ec.usecase.UpdateEscalationPolicy(c.Request.Context(), c.Param("id"), policy)
+	if err != nil {
+		ec.handleError(c, "failed to update escalation policy", err)
+		return
+	}
+
+	c.JSON(200, policy)
+}
+
+// GetEscalationPolicy обрабатывает GET /escalation-policies/{id} — получение политики.
+func (ec *EscalationsController) GetEscalationPolicy(c *ginext.Context) {
+	uid := c.Param("id")
+	c.Set("request", uid)
+
+	policy, err := ec.usecase.GetEscalationPolicy(c.Request.Context(), uid)
+	if err != nil {
+		ec.handleError(c, "failed to get escalation policy", err)
+		return
+	}
+
+	c.JSON(200, policy)
+}
+
+// ListEscalationPolicies обрабатывает GET /escalation-policies — получение всех политик.
+func (ec *EscalationsController) ListEscalationPolicies(c *ginext.Context) {
+	policies, err := ec.usecase.ListEscalationPolicies(c.Request.Context())
+	if err != nil {
+		ec.handleError(c, "failed to list escalation policies", err)
+		return
+	}
+
+	c.JSON(200, ginext.H{"escalation_policies": policies})
+}
+
+// DeleteEscalationPolicy обрабатывает DELETE /escalation-policies/{id} — удаление политики.
+func (ec *EscalationsController) DeleteEscalationPolicy(c *ginext.Context) {
+	uid := c.Param("id")
+	c.Set("request", uid)
+
+	if err := ec.usecase.DeleteEscalationPolicy(c.Request.Context(), uid); err != nil {
+		ec.handleError(c, "failed to delete escalation policy", err)
+		return
+	}
+
+	c.JSON(200, ginext.H{"message": "escalation policy deleted"})
+}
+
+// bind разбирает и проверяет запрос, при ошибке отвечает 400.
+func (ec *EscalationsController) bind(c *ginext.Context) (models.EscalationPolicy, bool) {
+	var req escalationPolicyRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, ginext.H{"error": "invalid request: " + err.Error()})
+		_ = c.Error(fmt.Errorf("validation error: %w", err))
+		return models.EscalationPolicy{}, false
+	}
+
+	c.Set("request", req)
+
+	policy, err := req.policy(ec.enabledChannels)
+	if err != nil {
+		c.JSON(400, ginext.H{"error": "invalid request: " + err.Error()})
+		_ = c.Error(fmt.Errorf("validation error: %w", err))
+		return models.EscalationPolicy{}, false
+	}
+
+	return policy, true
+}
+
+// handleError отвечает 404 на отсутствующую политику, 400 на некорректную и 500 на остальные ошибки.
+func (ec *EscalationsController) handleError(c *ginext.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrEscalationPolicyNotFound):
+		c.JSON(404, ginext.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrInvalidEscalationPolicy):
+		c.JSON(400, ginext.H{"error": err.Error()})
+	default:
+		c.JSON(500, ginext.H{"error": msg})
+	}
+	_ = c.Error(fmt.Errorf("%s: %w", msg, err))
+}
@@ -0,0 +1,98 @@
+package httpctrl
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
+	"github.com/wb-go/wbf/ginext"
+)
+
+type readinessUsecase interface {
+	CheckChannels(ctx context.Context) map[models.ChannelName]error
+}
+
+type breakerStats interface {
+	Stats() map[models.ChannelName]models.BreakerStats
+}
+
+// HealthController http контроллер проверок состояния сервиса.
+type HealthController struct {
+	readiness readinessUsecase
+	breakers  breakerStats
+	required  []models.ChannelName // каналы, без которых сервис не готов
+}
+
+// NewHealthController создает новый HealthController.
+func NewHealthController(readiness readinessUsecase, breakers breakerStats, required []models.ChannelName) *HealthController {
+	return &HealthController{readiness: readiness, breakers: breakers, required: required}
+}
+
+// Ready обрабатывает GET /ready — проверку доступности отправщиков включенных каналов.
+// Отвечает 503, если недоступен один из обязательных каналов; недоступность остальных
+// отображается статусом degraded, потому что уведомления по другим каналам продолжают уходить.
+// Разомкнутые автоматы отключения только отображаются: отправка по их каналам откладывается, а не теряется.
+func (hc *HealthController) Ready(c *ginext.Context) {
+	results := hc.readiness.CheckChannels(c.Request.Context())
+
+	code, status := 200, "ready"
+	channels := make(map[models.ChannelName]string, len(results))
+	for name, err := range results {
+		if err == nil {
+			channels[name] = "ok"
+			continue
+		}
+
+		channels[name] = "unavailable: " + err.Error()
+		if slices.Contains(hc.required, name) {
+			code, status = 503, "not ready"
+		} else if code == 200 {
+			status = "degraded"
+		}
+	}
+
+	resp := ginext.H{"status": status, "channels": channels}
+	if stats := hc.breakers.Stats(); len(stats) > 0 {
+		resp["circuit_breakers"] = stats
+	}
+
+	c.JSON(code, resp)
+}
+
+// breakerStateValues значения метрики состояния автомата отключения.
+var breakerStateValues = map[models.BreakerState]int{
+	models.BreakerClosed:   0,
+	models.BreakerHalfOpen: 1,
+	models.BreakerOpen:     2,
+}
+
+// Metrics обрабатывает GET /metrics — метрики автоматов отключения каналов в текстовом формате Prometheus.
+func (hc *HealthController) Metrics(c *ginext.Context) {
+	stats := hc.breakers.Stats()
+	names := make([]models.ChannelName, 0, len(stats))
+	for name := range stats {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var b strings.Builder
+	b.WriteString("# HELP notifier_circuit_breaker_state Circuit breaker state: 0 - closed, 1 - half_open, 2 - open.\n")
+	b.WriteString("# TYPE notifier_circuit_breaker_state gauge\n")
+	for _, name := range names {
+		fmt.Fprintf(&b, "notifier_circuit_breaker_state{channel=%q} %d\n", name, breakerStateValues[stats[name].State])
+	}
+	b.WriteString("# HELP notifier_circuit_breaker_consecutive_failures Consecutive sender failures.\n")
+	b.WriteString("# TYPE notifier_circuit_breaker_consecutive_failures gauge\n")
+	for _, name := range names {
+		fmt.Fprintf(&b, "notifier_circuit_breaker_consecutive_failures{channel=%q} %d\n", name, stats[name].ConsecutiveFailures)
+	}
+	b.WriteString("# HELP notifier_circuit_breaker_opens_total Times the circuit breaker opened.\n")
+	b.WriteString("# TYPE notifier_circuit_breaker_opens_total counter\n")
+	for _, name := range names {
+		fmt.Fprintf(&b, "notifier_circuit_breaker_opens_total{channel=%q} %d\n", name, stats[name].Opens)
+	}
+
+	c.Data(200, "text/plain; version=0.0.4; charset=utf-8", []byte(b.String()))
+}
@@ -2,9 +2,16 @@ package httpctrl
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/internal/usecase"
 	"github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
 	"github.com/wb-go/wbf/ginext"
 )
@@ -12,23 +19,153 @@ import (
 type notificationUsecase interface {
 	ScheduleNotification(ctx context.Context, notification models.DelayedNotification) (string, error)
 	GetNotificationStatus(ctx context.Context, uid string) (models.NotificationStatus, error)
-	RemoveNotification(ctx context.Context, uid string) error
+	RemoveNotification(ctx context.Context, uid string, cancelEvent bool) error
+	GetDeliveredChannels(ctx context.Context, uid string) ([]models.ChannelName, error)
+	GetSuppressedChannels(ctx context.Context, uid string) ([]models.ChannelName, error)
+	GetRateLimitedChannels(ctx context.Context, uid string) ([]models.ChannelName, error)
+	GetStatusHistory(ctx context.Context, uid string) ([]models.StatusChange, error)
+	GetDigestID(ctx context.Context, uid string) (string, error)
+	SnoozeNotification(ctx context.Context, uid string, sendAt time.Time) (string, error)
+	ScheduleRecipientNotifications(ctx context.Context, notification models.DelayedNotification, recipientIDs []string) (map[string]string, error)
+}
+
+type acknowledgementGetter interface {
+	GetAcknowledgement(ctx context.Context, uid string) (*models.Acknowledgement, error)
 }
 
 // NotificationsController http контроллер сервиса отложенных уведомлений.
 type NotificationsController struct {
 	usecase notificationUsecase
+	acks    acknowledgementGetter
+
+	enabledChannels     []models.ChannelName // каналы, включенные в конфиге
+	maxAttachmentSize   int64                // ограничение на одно вложение, переданное в запросе
+	maxAttachmentsTotal int64                // ограничение на все вложения уведомления
+	ackDefaults         models.AckPolicy     // повторы напоминаний, если они не заданы в запросе
 }
 
 // NewNotificationsController создает новый NotificationsController.
-func NewNotificationsController(uc notificationUsecase) *NotificationsController {
-	return &NotificationsController{usecase: uc}
+func NewNotificationsController(
+	uc notificationUsecase, acks acknowledgementGetter, enabledChannels []models.ChannelName,
+	maxAttachmentSize, maxAttachmentsTotal int64, ackDefaults models.AckPolicy,
+) *NotificationsController {
+	return &NotificationsController{
+		usecase:             uc,
+		acks:                acks,
+		enabledChannels:     enabledChannels,
+		maxAttachmentSize:   maxAttachmentSize,
+		maxAttachmentsTotal: maxAttachmentsTotal,
+		ackDefaults:         ackDefaults,
+	}
 }
 
 type createNotificationRequest struct {
-	Notification string          `json:"notification" binding:"required,min=1,max=1000"`
+	Notification string          `json:"notification" binding:"required_without=TemplateID,max=1000"`
 	DelaySeconds int64           `json:"delay_seconds" binding:"required,min=1,max=2592000"` // 1 сек – 30 дней
-	Channels     models.Channels `json:"channels" binding:"required"`
+	Channels     models.Channels `json:"channels"`
+	Event        *models.Event   `json:"event,omitempty"` // приглашение в календарь, отправляется только по email
+
+	// шаблон, заполняемый переменными при отправке, вместо готового текста notification
+	TemplateID      string         `json:"template_id,omitempty" binding:"omitempty,max=255"`
+	TemplateVersion int            `json:"template_version,omitempty" binding:"min=0"` // по умолчанию последняя версия
+	Variables       map[string]any `json:"variables,omitempty"`
+	Locale          string         `json:"locale,omitempty" binding:"max=35"` // язык получателя, например "kk"
+
+	// повтор уведомления, пока получатель не подтвердит его кнопкой в телеграм или ссылкой в письме
+	AckRequired              bool  `json:"ack_required,omitempty"`
+	AckRepeatIntervalSeconds int64 `json:"ack_repeat_interval_seconds,omitempty" binding:"omitempty,min=60,max=86400"`
+	AckMaxRepeats            *int  `json:"ack_max_repeats,omitempty" binding:"omitempty,min=0,max=100"`
+
+	// политика эскалации, каналы которой используются вместо channels
+	EscalationPolicyID string `json:"escalation_policy_id,omitempty" binding:"omitempty,max=255"`
+
+	// получатели из реестра, адреса которых используются вместо channels; каждому создается свое уведомление
+	RecipientIDs []string `json:"recipient_ids,omitempty" binding:"omitempty,max=100,unique,dive,required,max=255"`
+	Category     string   `json:"category,omitempty" binding:"max=64"` // категория, от которой получатель может отписаться
+
+	// тихие часы, в которые уведомление откладывается или отбрасывается, вместо тихих часов получателя из реестра;
+	// срочные уведомления с bypass_quiet_hours отправляются и в тихие часы
+	QuietHours       *models.QuietHours `json:"quiet_hours,omitempty"`
+	BypassQuietHours bool               `json:"bypass_quiet_hours,omitempty"`
+
+	// сводка: уведомления получателя с одним ключом, срок которых приходится на одно окно, отправляются одним сообщением
+	DigestKey           string `json:"digest_key,omitempty" binding:"omitempty,max=64,required_with=DigestWindowSeconds"`
+	DigestWindowSeconds int64  `json:"digest_window_seconds,omitempty" binding:"omitempty,min=60,max=86400,required_with=DigestKey"`
+
+	// ключ схлопывания: уведомление с ключом, под которым другое еще запланировано, заменяет его (replace, по умолчанию)
+	// или не создается (ignore)
+	CollapseKey    string                `json:"collapse_key,omitempty" binding:"required_with=CollapsePolicy,max=255"`
+	CollapsePolicy models.CollapsePolicy `json:"collapse_policy,omitempty" binding:"omitempty,oneof=replace ignore"`
+
+	// порядок доставки по каналам, по умолчанию all - по всем сразу
+	DeliveryMode models.DeliveryMode  `json:"delivery_mode,omitempty" binding:"omitempty,oneof=all first_success any_n"`
+	ChannelOrder []models.ChannelName `json:"channel_order,omitempty" binding:"omitempty,unique,dive,oneof=telegram email webhook"`
+	DeliveryN    int                  `json:"delivery_n,omitempty" binding:"min=0"` // для any_n
+
+	// варианты "отложить" для получателя: кнопки в телеграм и ссылки в письме, от минуты до недели
+	SnoozeOptionsSeconds []int64 `json:"snooze_options_seconds,omitempty" binding:"omitempty,max=3,unique,dive,min=60,max=604800"`
+}
+
+// snoozeOptions возвращает варианты "отложить" из запроса.
+func (r createNotificationRequest) snoozeOptions() []time.Duration {
+	if len(r.SnoozeOptionsSeconds) == 0 {
+		return nil
+	}
+
+	options := make([]time.Duration, len(r.SnoozeOptionsSeconds))
+	for i, seconds := range r.SnoozeOptionsSeconds {
+		options[i] = time.Duration(seconds) * time.Second
+	}
+	return options
+}
+
+// delivery возвращает порядок доставки из запроса или nil для отправки по всем каналам сразу.
+func (r createNotificationRequest) delivery() *models.Delivery {
+	switch r.DeliveryMode {
+	case models.DeliveryFirstSuccess:
+		return &models.Delivery{Mode: r.DeliveryMode, Order: r.ChannelOrder, N: 1}
+	case models.DeliveryAnyN:
+		return &models.Delivery{Mode: r.DeliveryMode, Order: r.ChannelOrder, N: r.DeliveryN}
+	default:
+		return nil
+	}
+}
+
+// digest возвращает правила сводки из запроса или nil, если уведомление отправляется отдельно.
+func (r createNotificationRequest) digest() *models.DigestPolicy {
+	if r.DigestKey == "" {
+		return nil
+	}
+	return &models.DigestPolicy{Key: r.DigestKey, Window: time.Duration(r.DigestWindowSeconds) * time.Second}
+}
+
+// collapse возвращает ключ схлопывания из запроса или nil, если уведомление не схлопывается.
+func (r createNotificationRequest) collapse() *models.Collapse {
+	if r.CollapseKey == "" {
+		return nil
+	}
+
+	policy := r.CollapsePolicy
+	if policy == "" {
+		policy = models.CollapseReplace
+	}
+	return &models.Collapse{Key: r.CollapseKey, Policy: policy}
+}
+
+// ackPolicy возвращает правила повторов из запроса, дополненные значениями по умолчанию.
+func (r createNotificationRequest) ackPolicy(defaults models.AckPolicy) *models.AckPolicy {
+	if !r.AckRequired {
+		return nil
+	}
+
+	policy := defaults
+	if r.AckRepeatIntervalSeconds != 0 {
+		policy.RepeatInterval = time.Duration(r.AckRepeatIntervalSeconds) * time.Second
+	}
+	if r.AckMaxRepeats != nil {
+		policy.MaxRepeats = *r.AckMaxRepeats
+	}
+	return &policy
 }
 
 // CreateNotification обрабатывает POST /notify — создание уведомлений с датой и временем отправки.
@@ -43,13 +180,57 @@ func (nc *NotificationsController) CreateNotification(c *ginext.Context) {
 
 	c.Set("request", req)
 
+	if err := nc.validateAttachments(req.Channels.EmailChannel.Attachments); err != nil {
+		c.JSON(400, ginext.H{"error": "invalid request: " + err.Error()})
+		_ = c.Error(fmt.Errorf("validation error: %w", err))
+		return
+	}
+
+	if err := validateNotificationRequest(req, nc.enabledChannels); err != nil {
+		c.JSON(400, ginext.H{"error": "invalid request: " + err.Error()})
+		_ = c.Error(fmt.Errorf("validation error: %w", err))
+		return
+	}
+
 	delayedNotif := models.DelayedNotification{
 		Notification: models.Notification(req.Notification),
 		Delay:        time.Duration(req.DelaySeconds) * time.Second,
 		Channels:     req.Channels,
+		Event:        req.Event,
+		Locale:       req.Locale,
+		Category:     req.Category,
+		Ack:          req.ackPolicy(nc.ackDefaults),
+		Delivery:     req.delivery(),
+		Digest:       req.digest(),
+		Collapse:     req.collapse(),
+
+		SnoozeOptions:    req.snoozeOptions(),
+		QuietHours:       req.QuietHours,
+		BypassQuietHours: req.BypassQuietHours,
+	}
+	if req.EscalationPolicyID != "" {
+		delayedNotif.Escalation = &models.Escalation{PolicyID: req.EscalationPolicyID}
+	}
+	if req.TemplateID != "" {
+		delayedNotif.Template = &models.TemplateRef{
+			ID:        req.TemplateID,
+			Version:   req.TemplateVersion,
+			Variables: req.Variables,
+		}
+	}
+
+	if len(req.RecipientIDs) > 0 {
+		nc.createRecipientNotifications(c, delayedNotif, req.RecipientIDs)
+		return
 	}
 
 	uid, err := nc.usecase.ScheduleNotification(c.Request.Context(), delayedNotif)
+	if errors.Is(err, usecase.ErrTemplateNotFound) || errors.Is(err, usecase.ErrInvalidTemplate) ||
+		errors.Is(err, usecase.ErrEscalationPolicyNotFound) {
+		c.JSON(400, ginext.H{"error": "invalid request: " + err.Error()})
+		_ = c.Error(fmt.Errorf("validation error: %w", err))
+		return
+	}
 	if err != nil {
 		c.JSON(500, ginext.H{"error": "failed to schedule notification"})
 		_ = c.Error(fmt.Errorf("scheduling failed: %w", err))
@@ -59,7 +240,258 @@ func (nc *NotificationsController) CreateNotification(c *ginext.Context) {
 	c.JSON(201, ginext.H{"uid": uid})
 }
 
-// GetNotificationStatus обрабатывает GET /notify/{id} — получение статуса уведомления.
+// createRecipientNotifications планирует уведомление для каждого получателя из реестра
+// и отвечает айди уведомлений по айди получателей.
+func (nc *NotificationsController) createRecipientNotifications(c *ginext.Context, notification models.DelayedNotification, recipientIDs []string) {
+	uids, err := nc.usecase.ScheduleRecipientNotifications(c.Request.Context(), notification, recipientIDs)
+	if errors.Is(err, usecase.ErrRecipientNotFound) || errors.Is(err, usecase.ErrTemplateNotFound) ||
+		errors.Is(err, usecase.ErrInvalidTemplate) {
+		c.JSON(400, ginext.H{"error": "invalid request: " + err.Error()})
+		_ = c.Error(fmt.Errorf("validation error: %w", err))
+		return
+	}
+	if err != nil {
+		c.JSON(500, ginext.H{"error": "failed to schedule notification"})
+		_ = c.Error(fmt.Errorf("scheduling failed: %w", err))
+		return
+	}
+
+	c.JSON(201, ginext.H{"uids": uids})
+}
+
+// validateAttachments проверяет размер вложений, переданных в запросе.
+func (nc *NotificationsController) validateAttachments(attachments []models.Attachment) error {
+	var total int64
+	for _, a := range attachments {
+		size := int64(len(a.Data))
+		if size > nc.maxAttachmentSize {
+			return fmt.Errorf("attachment %s is larger than %d bytes", a.Filename, nc.maxAttachmentSize)
+		}
+		total += size
+	}
+
+	if total > nc.maxAttachmentsTotal {
+		return fmt.Errorf("attachments are larger than %d bytes in total", nc.maxAttachmentsTotal)
+	}
+	return nil
+}
+
+// validateNotificationRequest проверяет сочетания полей запроса, которые не выразить тегами binding.
+func validateNotificationRequest(req createNotificationRequest, enabledChannels []models.ChannelName) error {
+	if len(req.RecipientIDs) > 0 {
+		if err := validateRecipientRequest(req); err != nil {
+			return err
+		}
+	}
+
+	if err := validateDelivery(req); err != nil {
+		return err
+	}
+
+	if req.DigestKey != "" {
+		if err := validateDigestRequest(req); err != nil {
+			return err
+		}
+	}
+
+	if req.EscalationPolicyID != "" {
+		if req.Channels.Any() {
+			return errors.New("channels must be empty when escalation_policy_id is set")
+		}
+		if req.Event != nil {
+			return errors.New("event is not supported with escalation policy")
+		}
+		if len(req.SnoozeOptionsSeconds) > 0 {
+			return errors.New("snooze_options_seconds is not supported with escalation policy")
+		}
+		return nil
+	}
+
+	var reservedRows int // ряды кнопок, которые сервис добавляет к кнопкам запроса
+	if req.AckRequired {
+		reservedRows++
+	}
+	if len(req.SnoozeOptionsSeconds) > 0 {
+		reservedRows++
+	}
+
+	if err := validateChannels(req.Channels, enabledChannels, req.Notification, reservedRows); err != nil {
+		return err
+	}
+
+	if req.Event != nil && req.Channels.EmailChannel.Email == "" {
+		return errors.New("event requires email channel")
+	}
+
+	return nil
+}
+
+// validateRecipientRequest проверяет, что уведомление для получателей из реестра не задает своих адресов.
+func validateRecipientRequest(req createNotificationRequest) error {
+	switch {
+	case req.EscalationPolicyID != "":
+		return errors.New("recipient_ids is not supported with escalation policy")
+	case req.Channels.Any():
+		return errors.New("channels must be empty when recipient_ids is set")
+	case req.Event != nil:
+		return errors.New("event is not supported with recipient_ids")
+	case req.DeliveryMode != "" && req.DeliveryMode != models.DeliveryAll:
+		return fmt.Errorf("delivery_mode %s is not supported with recipient_ids", req.DeliveryMode)
+	}
+
+	return nil
+}
+
+// validateDigestRequest проверяет, что уведомление в сводку адресовано получателям из реестра
+// и не требует того, что нельзя сделать для одного уведомления внутри общего сообщения.
+func validateDigestRequest(req createNotificationRequest) error {
+	switch {
+	case len(req.RecipientIDs) == 0:
+		return errors.New("digest_key requires recipient_ids")
+	case req.AckRequired:
+		return errors.New("ack_required is not supported with digest_key")
+	case len(req.SnoozeOptionsSeconds) > 0:
+		return errors.New("snooze_options_seconds is not supported with digest_key")
+	case req.QuietHours != nil || req.BypassQuietHours:
+		return errors.New("quiet_hours and bypass_quiet_hours are not supported with digest_key")
+	case req.Category != "":
+		return errors.New("category is not supported with digest_key")
+	case req.DeliveryMode != "" && req.DeliveryMode != models.DeliveryAll:
+		return fmt.Errorf("delivery_mode %s is not supported with digest_key", req.DeliveryMode)
+	case len(req.Channels.EmailChannel.Attachments) > 0 ||
+		req.Channels.TelegramChannel.PhotoURL != "" || req.Channels.TelegramChannel.DocumentURL != "":
+		// сводка отправляется одним текстом, вложения и медиа участников в нее не попадут
+		return errors.New("attachments, photo_url and document_url are not supported with digest_key")
+	}
+
+	return nil
+}
+
+// validateDelivery проверяет, что для доставки по очереди перечислены ровно указанные каналы.
+func validateDelivery(req createNotificationRequest) error {
+	if req.DeliveryMode == "" || req.DeliveryMode == models.DeliveryAll {
+		if len(req.ChannelOrder) > 0 || req.DeliveryN != 0 {
+			return errors.New("channel_order and delivery_n require first_success or any_n delivery_mode")
+		}
+		return nil
+	}
+
+	if req.EscalationPolicyID != "" {
+		return fmt.Errorf("delivery_mode %s is not supported with escalation policy", req.DeliveryMode)
+	}
+
+	configured := map[models.ChannelName]bool{
+		models.ChannelTelegram: req.Channels.TelegramChannel.ChatID != "",
+		models.ChannelEmail:    req.Channels.EmailChannel.Email != "",
+		models.ChannelWebhook:  req.Channels.WebhookChannel.URL != "",
+	}
+	for name, ok := range configured {
+		if ok != slices.Contains(req.ChannelOrder, name) {
+			return errors.New("channel_order must list exactly the configured channels")
+		}
+	}
+
+	switch req.DeliveryMode {
+	case models.DeliveryFirstSuccess:
+		if req.DeliveryN != 0 {
+			return errors.New("delivery_n is only supported with any_n delivery_mode")
+		}
+	case models.DeliveryAnyN:
+		if req.DeliveryN < 1 || req.DeliveryN > len(req.ChannelOrder) {
+			return fmt.Errorf("delivery_n must be between 1 and %d", len(req.ChannelOrder))
+		}
+	}
+
+	return nil
+}
+
+// validateChannels проверяет, что каналы включены, и их настройки, которые не покрывают теги binding.
+func validateChannels(channels models.Channels, enabledChannels []models.ChannelName, text string, reservedRows int) error {
+	configured := map[models.ChannelName]bool{
+		models.ChannelTelegram: channels.TelegramChannel.ChatID != "",
+		models.ChannelEmail:    channels.EmailChannel.Email != "",
+		models.ChannelWebhook:  channels.WebhookChannel.URL != "",
+	}
+	for name, ok := range configured {
+		if ok && !slices.Contains(enabledChannels, name) {
+			return fmt.Errorf("channel %s is not enabled", name)
+		}
+	}
+
+	if err := validateTelegramChannel(channels.TelegramChannel, text, reservedRows); err != nil {
+		return err
+	}
+
+	if u := channels.WebhookChannel.URL; u != "" && !hasScheme(u, "http", "https") {
+		return errors.New("webhook url must be http or https link")
+	}
+
+	return nil
+}
+
+// ограничения Bot API
+const (
+	telegramCallbackDataMaxBytes = 64
+	telegramCaptionMaxLength     = 1024
+	telegramKeyboardMaxRows      = 10
+)
+
+// validateTelegramChannel проверяет то, что не покрывают теги binding: ограничения Bot API
+// на кнопки и подписи к медиа. Оставляет reservedRows рядов под кнопки подтверждения и "отложить".
+func validateTelegramChannel(tg models.TelegramChannel, text string, reservedRows int) error {
+	hasOptions := tg.ParseMode != "" || len(tg.Buttons) > 0 || tg.Silent ||
+		tg.PhotoURL != "" || tg.DocumentURL != "" || tg.MessageThreadID != 0
+	if tg.ChatID == "" {
+		if hasOptions {
+			return errors.New("telegram options require chat_id")
+		}
+		return nil
+	}
+
+	if len(tg.Buttons)+reservedRows > telegramKeyboardMaxRows {
+		return fmt.Errorf("ack_required and snooze_options_seconds allow at most %d rows of buttons", telegramKeyboardMaxRows-reservedRows)
+	}
+
+	for _, row := range tg.Buttons {
+		for _, b := range row {
+			for _, prefix := range []string{models.AckCallbackPrefix, models.SnoozeCallbackPrefix} {
+				if strings.HasPrefix(b.CallbackData, prefix) {
+					return fmt.Errorf("button %q: callback_data prefix %q is reserved", b.Text, prefix)
+				}
+			}
+			if len(b.CallbackData) > telegramCallbackDataMaxBytes {
+				return fmt.Errorf("button %q: callback_data is longer than %d bytes", b.Text, telegramCallbackDataMaxBytes)
+			}
+			if b.URL != "" && !hasScheme(b.URL, "http", "https", "tg") {
+				return fmt.Errorf("button %q: url must be http, https or tg link", b.Text)
+			}
+		}
+	}
+
+	for _, media := range []string{tg.PhotoURL, tg.DocumentURL} {
+		if media != "" && !hasScheme(media, "http", "https") {
+			return fmt.Errorf("media url must be http or https link")
+		}
+	}
+
+	if (tg.PhotoURL != "" || tg.DocumentURL != "") && utf8.RuneCountInString(text) > telegramCaptionMaxLength {
+		return fmt.Errorf("caption is longer than %d characters", telegramCaptionMaxLength)
+	}
+
+	return nil
+}
+
+// hasScheme сообщает, является ли ссылка ссылкой с одной из схем.
+func hasScheme(link string, schemes ...string) bool {
+	u, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+	return slices.Contains(schemes, strings.ToLower(u.Scheme))
+}
+
+// GetNotificationStatus обрабатывает GET /notify/{id} — получение статуса уведомления,
+// доставленных каналов, сводки и подтверждения получения, если они есть.
 func (nc *NotificationsController) GetNotificationStatus(c *ginext.Context) {
 	uid := c.Param("id")
 	c.Set("request", uid)
@@ -71,15 +503,144 @@ func (nc *NotificationsController) GetNotificationStatus(c *ginext.Context) {
 		return
 	}
 
-	c.JSON(200, ginext.H{"status": status})
+	delivered, err := nc.usecase.GetDeliveredChannels(c.Request.Context(), uid)
+	if err != nil {
+		c.JSON(500, ginext.H{"error": "failed to get notification"})
+		_ = c.Error(fmt.Errorf("get delivered channels failed: %w", err))
+		return
+	}
+
+	suppressed, err := nc.usecase.GetSuppressedChannels(c.Request.Context(), uid)
+	if err != nil {
+		c.JSON(500, ginext.H{"error": "failed to get notification"})
+		_ = c.Error(fmt.Errorf("get suppressed channels failed: %w", err))
+		return
+	}
+
+	rateLimited, err := nc.usecase.GetRateLimitedChannels(c.Request.Context(), uid)
+	if err != nil {
+		c.JSON(500, ginext.H{"error": "failed to get notification"})
+		_ = c.Error(fmt.Errorf("get rate limited channels failed: %w", err))
+		return
+	}
+
+	history, err := nc.usecase.GetStatusHistory(c.Request.Context(), uid)
+	if err != nil {
+		c.JSON(500, ginext.H{"error": "failed to get notification"})
+		_ = c.Error(fmt.Errorf("get status history failed: %w", err))
+		return
+	}
+
+	digestID, err := nc.usecase.GetDigestID(c.Request.Context(), uid)
+	if err != nil {
+		c.JSON(500, ginext.H{"error": "failed to get notification"})
+		_ = c.Error(fmt.Errorf("get digest failed: %w", err))
+		return
+	}
+
+	ack, err := nc.acks.GetAcknowledgement(c.Request.Context(), uid)
+	if err != nil {
+		c.JSON(500, ginext.H{"error": "failed to get notification"})
+		_ = c.Error(fmt.Errorf("get acknowledgement failed: %w", err))
+		return
+	}
+
+	resp := ginext.H{"status": status}
+	if len(delivered) > 0 {
+		resp["delivered_via"] = delivered
+	}
+	if len(delivered) > 0 || len(suppressed) > 0 || len(rateLimited) > 0 {
+		resp["channels"] = channelStatuses(delivered, suppressed, rateLimited)
+	}
+	if len(history) > 0 {
+		resp["history"] = history
+	}
+	if digestID != "" {
+		resp["digest_id"] = digestID
+	}
+	if ack != nil {
+		resp["acknowledged_at"] = ack.At
+		resp["acknowledged_by"] = ack.By
+	}
+
+	c.JSON(200, resp)
+}
+
+// channelStatuses возвращает статусы каналов уведомления: delivered, suppressed или rate_limited.
+func channelStatuses(delivered, suppressed, rateLimited []models.ChannelName) map[models.ChannelName]string {
+	statuses := make(map[models.ChannelName]string, len(delivered)+len(suppressed)+len(rateLimited))
+	for _, name := range rateLimited {
+		statuses[name] = "rate_limited"
+	}
+	for _, name := range suppressed {
+		statuses[name] = "suppressed"
+	}
+	for _, name := range delivered {
+		statuses[name] = "delivered"
+	}
+	return statuses
+}
+
+type snoozeNotificationRequest struct {
+	DelaySeconds int64      `json:"delay_seconds,omitempty" binding:"omitempty,min=1,max=2592000"`
+	SendAt       *time.Time `json:"send_at,omitempty"`
+}
+
+// SnoozeNotification обрабатывает POST /notify/{id}/snooze — откладывание уведомления на delay_seconds
+// или до send_at. Уже отправленное уведомление создается заново с новым айди.
+func (nc *NotificationsController) SnoozeNotification(c *ginext.Context) {
+	uid := c.Param("id")
+
+	var req snoozeNotificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, ginext.H{"error": "invalid request: " + err.Error()})
+		_ = c.Error(fmt.Errorf("validation error: %w", err))
+		return
+	}
+
+	c.Set("request", req)
+
+	if (req.DelaySeconds == 0) == (req.SendAt == nil) {
+		c.JSON(400, ginext.H{"error": "invalid request: exactly one of delay_seconds and send_at is required"})
+		_ = c.Error(errors.New("validation error: no snooze time"))
+		return
+	}
+
+	sendAt := time.Now().Add(time.Duration(req.DelaySeconds) * time.Second)
+	if req.SendAt != nil {
+		sendAt = *req.SendAt
+	}
+
+	snoozedID, err := nc.usecase.SnoozeNotification(c.Request.Context(), uid, sendAt)
+	switch {
+	case errors.Is(err, usecase.ErrInvalidSnoozeTime):
+		c.JSON(400, ginext.H{"error": "invalid request: " + err.Error()})
+		_ = c.Error(fmt.Errorf("validation error: %w", err))
+	case errors.Is(err, usecase.ErrNotificationNotFound):
+		c.JSON(404, ginext.H{"error": "notification not found"})
+		_ = c.Error(fmt.Errorf("snooze notification failed: %w", err))
+	case err != nil:
+		c.JSON(500, ginext.H{"error": "failed to snooze notification"})
+		_ = c.Error(fmt.Errorf("snooze notification failed: %w", err))
+	default:
+		c.JSON(200, ginext.H{"uid": snoozedID, "send_at": sendAt.UTC()})
+	}
 }
 
 // DeleteNotification обрабатывает DELETE /notify/{id} — отмена запланированного уведомления.
+// С параметром cancel_event=true получателю дополнительно рассылается отмена события.
 func (nc *NotificationsController) DeleteNotification(c *ginext.Context) {
 	uid := c.Param("id")
 	c.Set("request", uid)
 
-	err := nc.usecase.RemoveNotification(c.Request.Context(), uid)
+	cancelEvent, err := strconv.ParseBool(c.DefaultQuery("cancel_event", "false"))
+	if err != nil {
+		c.JSON(400, ginext.H{"error": "invalid request: cancel_event must be a boolean"})
+		_ = c.Error(fmt.Errorf("validation error: %w", err))
+		return
+	}
+
+	err = nc.usecase.RemoveNotification(c.Request.Context(), uid, cancelEvent)
 	if err != nil {
 		c.JSON(500, ginext.H{"error": "failed to delete notification: " + err.Error()})
 		_ = c.Error(fmt.Errorf("delete notification failed: %w", err))
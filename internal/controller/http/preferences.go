@@ -0,0 +1,245 @@
+package httpctrl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"slices"
+
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/internal/usecase"
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
+	"github.com/wb-go/wbf/ginext"
+)
+
+type preferenceUsecase interface {
+	GetPreferences(ctx context.Context, userID string) (models.Preferences, error)
+	UpdatePreferences(ctx context.Context, userID string, prefs models.Preferences) (models.Preferences, error)
+	ParsePreferencesToken(token string) (string, error)
+	Unsubscribe(ctx context.Context, token, category string) error
+}
+
+// PreferencesController http контроллер настроек получателей: API и подписанная страница из писем.
+type PreferencesController struct {
+	usecase preferenceUsecase
+}
+
+// NewPreferencesController создает новый PreferencesController.
+func NewPreferencesController(uc preferenceUsecase) *PreferencesController {
+	return &PreferencesController{usecase: uc}
+}
+
+// GetPreferences обрабатывает GET /recipients/{id}/preferences — получение настроек получателя.
+func (pc *PreferencesController) GetPreferences(c *ginext.Context) {
+	userID := c.Param("id")
+	c.Set("request", userID)
+
+	prefs, err := pc.usecase.GetPreferences(c.Request.Context(), userID)
+	if err != nil {
+		pc.handleError(c, "failed to get preferences", err)
+		return
+	}
+
+	c.JSON(200, prefs)
+}
+
+// UpdatePreferences обрабатывает PUT /recipients/{id}/preferences — замена настроек получателя.
+// Настройки применяются и к уже запланированным уведомлениям.
+func (pc *PreferencesController) UpdatePreferences(c *ginext.Context) {
+	var req models.Preferences
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, ginext.H{"error": "invalid request: " + err.Error()})
+		_ = c.Error(fmt.Errorf("validation error: %w", err))
+		return
+	}
+
+	c.Set("request", req)
+
+	prefs, err := pc.usecase.UpdatePreferences(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		pc.handleError(c, "failed to update preferences", err)
+		return
+	}
+
+	c.JSON(200, prefs)
+}
+
+// handleError отвечает 404 на отсутствующего получателя и 500 на остальные ошибки.
+func (pc *PreferencesController) handleError(c *ginext.Context, msg string, err error) {
+	if errors.Is(err, usecase.ErrRecipientNotFound) {
+		c.JSON(404, ginext.H{"error": err.Error()})
+	} else {
+		c.JSON(500, ginext.H{"error": msg})
+	}
+	_ = c.Error(fmt.Errorf("%s: %w", msg, err))
+}
+
+// pageChannels каналы, от которых получатель может отказаться на странице настроек, с подписями.
+var pageChannels = []struct {
+	Name  models.ChannelName
+	Label string
+}{
+	{models.ChannelEmail, "Email"},
+	{models.ChannelTelegram, "Телеграм"},
+}
+
+// ответы открываются в браузере получателя, поэтому отдаются страницей, а не JSON
+const (
+	preferencesPageInvalid     = `<!DOCTYPE html><html><head><meta charset="utf-8"><title>Ссылка недействительна</title></head><body><p>Ссылка на настройки недействительна.</p></body></html>`
+	preferencesPageError       = `<!DOCTYPE html><html><head><meta charset="utf-8"><title>Ошибка</title></head><body><p>Не удалось открыть настройки, попробуйте позже.</p></body></html>`
+	preferencesPageConfirm     = `<!DOCTYPE html><html><head><meta charset="utf-8"><title>Отписка</title></head><body><form method="post"><p>Отписаться от этих уведомлений?</p><p><button type="submit">Отписаться</button></p></form></body></html>`
+	preferencesPageUnsubscribe = `<!DOCTYPE html><html><head><meta charset="utf-8"><title>Вы отписались</title></head><body><p>Вы отписались от этих уведомлений.</p></body></html>`
+)
+
+var preferencesPage = template.Must(template.New("preferences").Parse(`<!DOCTYPE html><html><head><meta charset="utf-8"><title>Настройки уведомлений</title></head><body>
+<form method="post">
+<p>Получать уведомления:</p>
+{{range .Channels}}<p><label><input type="checkbox" name="channel" value="{{.Name}}"{{if .Enabled}} checked{{end}}> {{.Label}}</label></p>
+{{end}}{{if .Categories}}<p>Вы отписаны от категорий (снимите отметку, чтобы подписаться снова):</p>
+{{range .Categories}}<p><label><input type="checkbox" name="category" value="{{.}}" checked> {{.}}</label></p>
+{{end}}{{end}}<p><button type="submit">Сохранить</button></p>
+{{if .Saved}}<p>Настройки сохранены.</p>
+{{end}}</form>
+</body></html>`))
+
+type preferencesPageChannel struct {
+	Name    models.ChannelName
+	Label   string
+	Enabled bool
+}
+
+type preferencesPageData struct {
+	Channels   []preferencesPageChannel
+	Categories []string
+	Saved      bool
+}
+
+// Page обрабатывает GET /preferences/{token} — страница настроек по ссылке из письма.
+func (pc *PreferencesController) Page(c *ginext.Context) {
+	userID, ok := pc.parseToken(c)
+	if !ok {
+		return
+	}
+
+	prefs, err := pc.usecase.GetPreferences(c.Request.Context(), userID)
+	if err != nil {
+		pc.handlePageError(c, "failed to get preferences", err)
+		return
+	}
+
+	pc.renderPage(c, prefs, false)
+}
+
+// SavePage обрабатывает POST /preferences/{token} — сохранение формы страницы настроек.
+// Каналы, не отмеченные в форме, и категории, отметка с которых не снята, остаются отключенными.
+func (pc *PreferencesController) SavePage(c *ginext.Context) {
+	userID, ok := pc.parseToken(c)
+	if !ok {
+		return
+	}
+
+	prefs, err := pc.usecase.GetPreferences(c.Request.Context(), userID)
+	if err != nil {
+		pc.handlePageError(c, "failed to get preferences", err)
+		return
+	}
+
+	accepted := c.PostFormArray("channel")
+	for _, ch := range pageChannels {
+		prefs.OptedOutChannels = slices.DeleteFunc(prefs.OptedOutChannels, func(name models.ChannelName) bool {
+			return name == ch.Name
+		})
+		if !slices.Contains(accepted, string(ch.Name)) {
+			prefs.OptedOutChannels = append(prefs.OptedOutChannels, ch.Name)
+		}
+	}
+
+	// на странице можно только подписаться обратно, поэтому новые категории из формы не принимаются
+	kept := c.PostFormArray("category")
+	prefs.OptedOutCategories = slices.DeleteFunc(prefs.OptedOutCategories, func(category string) bool {
+		return !slices.Contains(kept, category)
+	})
+
+	prefs, err = pc.usecase.UpdatePreferences(c.Request.Context(), userID, prefs)
+	if err != nil {
+		pc.handlePageError(c, "failed to update preferences", err)
+		return
+	}
+
+	pc.renderPage(c, prefs, true)
+}
+
+// UnsubscribePage обрабатывает GET /preferences/{token}/unsubscribe — ссылку отписки, открытую
+// почтовым клиентом без поддержки RFC 8058. Отписка только подтверждается формой: переход по ссылке,
+// например при проверке письма антиспамом, не должен отписывать получателя.
+func (pc *PreferencesController) UnsubscribePage(c *ginext.Context) {
+	if _, ok := pc.parseToken(c); !ok {
+		return
+	}
+
+	c.Data(200, "text/html; charset=utf-8", []byte(preferencesPageConfirm))
+}
+
+// Unsubscribe обрабатывает POST /preferences/{token}/unsubscribe?category= — отписку в один клик
+// из заголовка List-Unsubscribe письма (RFC 8058): от категории, а если она не указана - от писем.
+func (pc *PreferencesController) Unsubscribe(c *ginext.Context) {
+	token := c.Param("token")
+	c.Set("request", token)
+
+	err := pc.usecase.Unsubscribe(c.Request.Context(), token, c.Query("category"))
+	switch {
+	case errors.Is(err, usecase.ErrInvalidPreferencesToken), errors.Is(err, usecase.ErrInvalidCategory):
+		c.Data(400, "text/html; charset=utf-8", []byte(preferencesPageInvalid))
+		_ = c.Error(fmt.Errorf("validation error: %w", err))
+	case err != nil:
+		pc.handlePageError(c, "unsubscribe failed", err)
+	default:
+		c.Data(200, "text/html; charset=utf-8", []byte(preferencesPageUnsubscribe))
+	}
+}
+
+// parseToken проверяет токен из ссылки, при ошибке отвечает страницей 400.
+func (pc *PreferencesController) parseToken(c *ginext.Context) (string, bool) {
+	token := c.Param("token")
+	c.Set("request", token)
+
+	userID, err := pc.usecase.ParsePreferencesToken(token)
+	if err != nil {
+		c.Data(400, "text/html; charset=utf-8", []byte(preferencesPageInvalid))
+		_ = c.Error(fmt.Errorf("validation error: %w", err))
+		return "", false
+	}
+
+	return userID, true
+}
+
+func (pc *PreferencesController) renderPage(c *ginext.Context, prefs models.Preferences, saved bool) {
+	data := preferencesPageData{Categories: prefs.OptedOutCategories, Saved: saved}
+	for _, ch := range pageChannels {
+		data.Channels = append(data.Channels, preferencesPageChannel{
+			Name:    ch.Name,
+			Label:   ch.Label,
+			Enabled: !slices.Contains(prefs.OptedOutChannels, ch.Name),
+		})
+	}
+
+	var page bytes.Buffer
+	if err := preferencesPage.Execute(&page, data); err != nil {
+		pc.handlePageError(c, "failed to render preferences page", err)
+		return
+	}
+
+	c.Data(200, "text/html; charset=utf-8", page.Bytes())
+}
+
+// handlePageError отвечает страницей 404, если получатель удален, и 500 на остальные ошибки.
+func (pc *PreferencesController) handlePageError(c *ginext.Context, msg string, err error) {
+	if errors.Is(err, usecase.ErrRecipientNotFound) {
+		c.Data(404, "text/html; charset=utf-8", []byte(preferencesPageInvalid))
+	} else {
+		c.Data(500, "text/html; charset=utf-8", []byte(preferencesPageError))
+	}
+	_ = c.Error(fmt.Errorf("%s: %w", msg, err))
+}
@@ -0,0 +1,161 @@
+package httpctrl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/internal/usecase"
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
+	"github.com/wb-go/wbf/ginext"
+)
+
+type recipientUsecase interface {
+	CreateRecipient(ctx context.Context, recipient models.Recipient) (models.Recipient, error)
+	UpdateRecipient(ctx context.Context, userID string, recipient models.Recipient) (models.Recipient, error)
+	GetRecipient(ctx context.Context, userID string) (models.Recipient, error)
+	ListRecipients(ctx context.Context) ([]models.Recipient, error)
+	DeleteRecipient(ctx context.Context, userID string) error
+}
+
+// RecipientsController http контроллер реестра получателей.
+type RecipientsController struct {
+	usecase recipientUsecase
+}
+
+// NewRecipientsController создает новый RecipientsController.
+func NewRecipientsController(uc recipientUsecase) *RecipientsController {
+	return &RecipientsController{usecase: uc}
+}
+
+type recipientRequest struct {
+	UserID         string             `json:"user_id" binding:"omitempty,max=255,excludesall=/?#"` // обязателен при создании
+	Email          string             `json:"email,omitempty" binding:"omitempty,email,max=255"`
+	TelegramChatID string             `json:"telegram_chat_id,omitempty" binding:"max=255"`
+	Phone          string             `json:"phone,omitempty" binding:"omitempty,e164"`
+	PushTokens     []string           `json:"push_tokens,omitempty" binding:"max=10,dive,required,max=4096"`
+	QuietHours     *models.QuietHours `json:"quiet_hours,omitempty"`
+}
+
+func (r recipientRequest) recipient() models.Recipient {
+	return models.Recipient{
+		UserID:         r.UserID,
+		Email:          r.Email,
+		TelegramChatID: r.TelegramChatID,
+		Phone:          r.Phone,
+		PushTokens:     r.PushTokens,
+		QuietHours:     r.QuietHours,
+	}
+}
+
+// CreateRecipient обрабатывает POST /recipients — добавление получателя в реестр.
+func (rc *RecipientsController) CreateRecipient(c *ginext.Context) {
+	req, ok := rc.bind(c)
+	if !ok {
+		return
+	}
+
+	if req.UserID == "" {
+		c.JSON(400, ginext.H{"error": "invalid request: user_id is required"})
+		_ = c.Error(errors.New("validation error: user_id is required"))
+		return
+	}
+
+	recipient, err := rc.usecase.CreateRecipient(c.Request.Context(), req.recipient())
+	if err != nil {
+		rc.handleError(c, "failed to create recipient", err)
+		return
+	}
+
+	c.JSON(201, recipient)
+}
+
+// UpdateRecipient обрабатывает PUT /recipients/{id} — замена адресов получателя.
+// Новые адреса применяются и к уже запланированным уведомлениям.
+func (rc *RecipientsController) UpdateRecipient(c *ginext.Context) {
+	req, ok := rc.bind(c)
+	if !ok {
+		return
+	}
+
+	userID := c.Param("id")
+	if req.UserID != "" && req.UserID != userID {
+		c.JSON(400, ginext.H{"error": "invalid request: user_id does not match the path"})
+		_ = c.Error(errors.New("validation error: user_id does not match the path"))
+		return
+	}
+
+	recipient, err := rc.usecase.UpdateRecipient(c.Request.Context(), userID, req.recipient())
+	if err != nil {
+		rc.handleError(c, "failed to update recipient", err)
+		return
+	}
+
+	c.JSON(200, recipient)
+}
+
+// GetRecipient обрабатывает GET /recipients/{id} — получение получателя.
+func (rc *RecipientsController) GetRecipient(c *ginext.Context) {
+	userID := c.Param("id")
+	c.Set("request", userID)
+
+	recipient, err := rc.usecase.GetRecipient(c.Request.Context(), userID)
+	if err != nil {
+		rc.handleError(c, "failed to get recipient", err)
+		return
+	}
+
+	c.JSON(200, recipient)
+}
+
+// ListRecipients обрабатывает GET /recipients — получение всех получателей.
+func (rc *RecipientsController) ListRecipients(c *ginext.Context) {
+	recipients, err := rc.usecase.ListRecipients(c.Request.Context())
+	if err != nil {
+		rc.handleError(c, "failed to list recipients", err)
+		return
+	}
+
+	c.JSON(200, ginext.H{"recipients": recipients})
+}
+
+// DeleteRecipient обрабатывает DELETE /recipients/{id} — удаление получателя.
+func (rc *RecipientsController) DeleteRecipient(c *ginext.Context) {
+	userID := c.Param("id")
+	c.Set("request", userID)
+
+	if err := rc.usecase.DeleteRecipient(c.Request.Context(), userID); err != nil {
+		rc.handleError(c, "failed to delete recipient", err)
+		return
+	}
+
+	c.JSON(200, ginext.H{"message": "recipient deleted"})
+}
+
+// bind разбирает и проверяет запрос, при ошибке отвечает 400.
+func (rc *RecipientsController) bind(c *ginext.Context) (recipientRequest, bool) {
+	var req recipientRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, ginext.H{"error": "invalid request: " + err.Error()})
+		_ = c.Error(fmt.Errorf("validation error: %w", err))
+		return recipientRequest{}, false
+	}
+
+	c.Set("request", req)
+
+	return req, true
+}
+
+// handleError отвечает 404 на отсутствующего получателя, 409 на занятый айди и 500 на остальные ошибки.
+func (rc *RecipientsController) handleError(c *ginext.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrRecipientNotFound):
+		c.JSON(404, ginext.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrRecipientExists):
+		c.JSON(409, ginext.H{"error": err.Error()})
+	default:
+		c.JSON(500, ginext.H{"error": msg})
+	}
+	_ = c.Error(fmt.Errorf("%s: %w", msg, err))
+}
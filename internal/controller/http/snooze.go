@@ -0,0 +1,83 @@
+package httpctrl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"strconv"
+	"time"
+
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/internal/usecase"
+	"github.com/wb-go/wbf/ginext"
+)
+
+type snoozeUsecase interface {
+	Snooze(ctx context.Context, token string, d time.Duration) (string, time.Time, error)
+}
+
+// SnoozeController http контроллер ссылок "отложить" из писем.
+type SnoozeController struct {
+	usecase snoozeUsecase
+}
+
+// NewSnoozeController создает новый SnoozeController.
+func NewSnoozeController(uc snoozeUsecase) *SnoozeController {
+	return &SnoozeController{usecase: uc}
+}
+
+// ответы открываются в браузере получателя, поэтому отдаются страницей, а не JSON
+const (
+	snoozePageConfirm  = `<!DOCTYPE html><html><head><meta charset="utf-8"><title>Отложить уведомление</title></head><body><form method="post"><p>Отложить уведомление?</p><p><button type="submit">Отложить</button></p></form></body></html>`
+	snoozePageOK       = `<!DOCTYPE html><html><head><meta charset="utf-8"><title>Уведомление отложено</title></head><body><p>Напомним %s (UTC).</p></body></html>`
+	snoozePageInvalid  = `<!DOCTYPE html><html><head><meta charset="utf-8"><title>Ссылка недействительна</title></head><body><p>Ссылка недействительна.</p></body></html>`
+	snoozePageNotFound = `<!DOCTYPE html><html><head><meta charset="utf-8"><title>Уведомление не найдено</title></head><body><p>Уведомление больше нельзя отложить.</p></body></html>`
+	snoozePageError    = `<!DOCTYPE html><html><head><meta charset="utf-8"><title>Ошибка</title></head><body><p>Не удалось отложить уведомление, попробуйте позже.</p></body></html>`
+)
+
+// SnoozePage обрабатывает GET /snooze/{token}?for= — страница подтверждения по ссылке из письма.
+// Ссылки открывают и почтовые сканеры, поэтому уведомление откладывается только отправкой формы.
+func (sc *SnoozeController) SnoozePage(c *ginext.Context) {
+	if _, ok := sc.parseDuration(c); !ok {
+		return
+	}
+
+	c.Data(200, "text/html; charset=utf-8", []byte(snoozePageConfirm))
+}
+
+// Snooze обрабатывает POST /snooze/{token}?for= — откладывание уведомления на for секунд со страницы подтверждения.
+func (sc *SnoozeController) Snooze(c *ginext.Context) {
+	d, ok := sc.parseDuration(c)
+	if !ok {
+		return
+	}
+
+	_, sendAt, err := sc.usecase.Snooze(c.Request.Context(), c.Param("token"), d)
+	switch {
+	case errors.Is(err, usecase.ErrInvalidSnoozeToken) || errors.Is(err, usecase.ErrInvalidSnoozeTime):
+		c.Data(400, "text/html; charset=utf-8", []byte(snoozePageInvalid))
+		_ = c.Error(fmt.Errorf("validation error: %w", err))
+	case errors.Is(err, usecase.ErrNotificationNotFound):
+		c.Data(404, "text/html; charset=utf-8", []byte(snoozePageNotFound))
+		_ = c.Error(fmt.Errorf("snooze failed: %w", err))
+	case err != nil:
+		c.Data(500, "text/html; charset=utf-8", []byte(snoozePageError))
+		_ = c.Error(fmt.Errorf("snooze failed: %w", err))
+	default:
+		page := fmt.Sprintf(snoozePageOK, html.EscapeString(sendAt.UTC().Format("02.01.2006 15:04")))
+		c.Data(200, "text/html; charset=utf-8", []byte(page))
+	}
+}
+
+// parseDuration читает длительность из ссылки, при ошибке отвечает страницей 400.
+func (sc *SnoozeController) parseDuration(c *ginext.Context) (time.Duration, bool) {
+	c.Set("request", c.Param("token"))
+
+	seconds, err := strconv.Atoi(c.Query("for"))
+	if err != nil {
+		c.Data(400, "text/html; charset=utf-8", []byte(snoozePageInvalid))
+		_ = c.Error(fmt.Errorf("validation error: %w", err))
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
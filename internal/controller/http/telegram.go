@@ -0,0 +1,40 @@
+package httpctrl
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/wb-go/wbf/ginext"
+)
+
+// telegramSecretHeader заголовок, в котором телеграм передает секрет вебхука.
+const telegramSecretHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+var errInvalidTelegramSecret = errors.New("invalid telegram webhook secret token")
+
+// TelegramWebhookController http контроллер приема обновлений телеграм-бота через вебхук.
+type TelegramWebhookController struct {
+	updates http.Handler
+	secret  string
+}
+
+// NewTelegramWebhookController создает новый TelegramWebhookController.
+// Обновления с верным секретом передаются в updates.
+func NewTelegramWebhookController(updates http.Handler, secret string) *TelegramWebhookController {
+	return &TelegramWebhookController{updates: updates, secret: secret}
+}
+
+// Update обрабатывает POST /telegram/webhook — обновление бота от телеграма.
+func (tc *TelegramWebhookController) Update(c *ginext.Context) {
+	secret := c.GetHeader(telegramSecretHeader)
+	if subtle.ConstantTimeCompare([]byte(secret), []byte(tc.secret)) != 1 {
+		c.JSON(401, ginext.H{"error": "invalid secret token"})
+		_ = c.Error(fmt.Errorf("telegram webhook: %w", errInvalidTelegramSecret))
+		return
+	}
+
+	tc.updates.ServeHTTP(c.Writer, c.Request)
+	c.Status(200)
+}
@@ -0,0 +1,146 @@
+package httpctrl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/internal/usecase"
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
+	"github.com/wb-go/wbf/ginext"
+)
+
+type templateUsecase interface {
+	CreateTemplate(ctx context.Context, tmpl models.Template) (models.Template, error)
+	UpdateTemplate(ctx context.Context, id string, tmpl models.Template) (models.Template, error)
+	GetTemplate(ctx context.Context, id string, version int) (models.Template, error)
+	ListTemplates(ctx context.Context) ([]models.Template, error)
+	DeleteTemplate(ctx context.Context, id string) error
+}
+
+// TemplatesController http контроллер шаблонов уведомлений.
+type TemplatesController struct {
+	usecase templateUsecase
+}
+
+// NewTemplatesController создает новый TemplatesController.
+func NewTemplatesController(uc templateUsecase) *TemplatesController {
+	return &TemplatesController{usecase: uc}
+}
+
+type templateRequest struct {
+	Name     string                             `json:"name" binding:"required,max=255"`
+	Locale   string                             `json:"locale,omitempty" binding:"max=35"` // язык основного варианта
+	Variants models.TemplateVariants            `json:"variants" binding:"required"`
+	Locales  map[string]models.TemplateVariants `json:"locales,omitempty" binding:"max=20,dive,keys,max=35,endkeys"`
+}
+
+func (r templateRequest) template() models.Template {
+	return models.Template{
+		Name:     r.Name,
+		Locale:   r.Locale,
+		Variants: r.Variants,
+		Locales:  r.Locales,
+	}
+}
+
+// CreateTemplate обрабатывает POST /templates — создание шаблона.
+func (tc *TemplatesController) CreateTemplate(c *ginext.Context) {
+	var req templateRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, ginext.H{"error": "invalid request: " + err.Error()})
+		_ = c.Error(fmt.Errorf("validation error: %w", err))
+		return
+	}
+
+	c.Set("request", req)
+
+	tmpl, err := tc.usecase.CreateTemplate(c.Request.Context(), req.template())
+	if err != nil {
+		tc.handleError(c, "failed to create template", err)
+		return
+	}
+
+	c.JSON(201, tmpl)
+}
+
+// UpdateTemplate обрабатывает PUT /templates/{id} — создание новой версии шаблона.
+func (tc *TemplatesController) UpdateTemplate(c *ginext.Context) {
+	var req templateRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, ginext.H{"error": "invalid request: " + err.Error()})
+		_ = c.Error(fmt.Errorf("validation error: %w", err))
+		return
+	}
+
+	c.Set("request", req)
+
+	tmpl, err := tc.usecase.UpdateTemplate(c.Request.Context(), c.Param("id"), req.template())
+	if err != nil {
+		tc.handleError(c, "failed to update template", err)
+		return
+	}
+
+	c.JSON(200, tmpl)
+}
+
+// GetTemplate обрабатывает GET /templates/{id} — получение последней или указанной в ?version= версии шаблона.
+func (tc *TemplatesController) GetTemplate(c *ginext.Context) {
+	uid := c.Param("id")
+	c.Set("request", uid)
+
+	version, err := strconv.Atoi(c.DefaultQuery("version", "0"))
+	if err != nil || version < 0 {
+		c.JSON(400, ginext.H{"error": "invalid request: version must be a positive number"})
+		_ = c.Error(fmt.Errorf("validation error: invalid version %q", c.Query("version")))
+		return
+	}
+
+	tmpl, err := tc.usecase.GetTemplate(c.Request.Context(), uid, version)
+	if err != nil {
+		tc.handleError(c, "failed to get template", err)
+		return
+	}
+
+	c.JSON(200, tmpl)
+}
+
+// ListTemplates обрабатывает GET /templates — получение последних версий всех шаблонов.
+func (tc *TemplatesController) ListTemplates(c *ginext.Context) {
+	templates, err := tc.usecase.ListTemplates(c.Request.Context())
+	if err != nil {
+		tc.handleError(c, "failed to list templates", err)
+		return
+	}
+
+	c.JSON(200, ginext.H{"templates": templates})
+}
+
+// DeleteTemplate обрабатывает DELETE /templates/{id} — удаление шаблона.
+func (tc *TemplatesController) DeleteTemplate(c *ginext.Context) {
+	uid := c.Param("id")
+	c.Set("request", uid)
+
+	if err := tc.usecase.DeleteTemplate(c.Request.Context(), uid); err != nil {
+		tc.handleError(c, "failed to delete template", err)
+		return
+	}
+
+	c.JSON(200, ginext.H{"message": "template deleted"})
+}
+
+// handleError отвечает 404 на отсутствующий шаблон, 400 на некорректный и 500 на остальные ошибки.
+func (tc *TemplatesController) handleError(c *ginext.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrTemplateNotFound):
+		c.JSON(404, ginext.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrInvalidTemplate):
+		c.JSON(400, ginext.H{"error": err.Error()})
+	default:
+		c.JSON(500, ginext.H{"error": msg})
+	}
+	_ = c.Error(fmt.Errorf("%s: %w", msg, err))
+}
@@ -0,0 +1,63 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/internal/infrastructure/netguard"
+)
+
+// HTTP загружает содержимое по URL с ограничением размера.
+type HTTP struct {
+	client  *http.Client
+	maxSize int64
+}
+
+// NewHTTP создает новый HTTP. Без allowPrivate адреса внутренних сетей не загружаются.
+func NewHTTP(timeout time.Duration, maxSize int64, allowPrivate bool) *HTTP {
+	return &HTTP{client: netguard.NewHTTPClient(timeout, allowPrivate), maxSize: maxSize}
+}
+
+// Fetch загружает содержимое по URL и возвращает его вместе с Content-Type ответа.
+// Возвращает ошибку, если содержимое больше допустимого размера или адрес ведет во внутреннюю сеть.
+func (f *HTTP) Fetch(ctx context.Context, rawURL string) ([]byte, string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, "", err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, "", fmt.Errorf("unsupported url scheme %q", u.Scheme)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	if resp.ContentLength > f.maxSize {
+		return nil, "", fmt.Errorf("content is larger than %d bytes", f.maxSize)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, f.maxSize+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if int64(len(data)) > f.maxSize {
+		return nil, "", fmt.Errorf("content is larger than %d bytes", f.maxSize)
+	}
+
+	return data, resp.Header.Get("Content-Type"), nil
+}
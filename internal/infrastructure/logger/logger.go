@@ -4,5 +4,6 @@ package logger
 type Logger interface {
 	WithFields(keyValues ...interface{}) Logger
 	Error(err error)
+	Info(msg string)
 	Debug(msg string)
 }
@@ -16,6 +16,10 @@ func (a loggerAdapter) Error(err error) {
 	a.impl.Err(err).Send()
 }
 
+func (a loggerAdapter) Info(msg string) {
+	a.impl.Info().Msg(msg)
+}
+
 func (a loggerAdapter) Debug(msg string) {
 	a.impl.Debug().Msg(msg)
 }
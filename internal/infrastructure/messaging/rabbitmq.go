@@ -8,12 +8,16 @@ import (
 	"github.com/wb-go/wbf/rabbitmq"
 )
 
+// consumerTag идентифицирует консьюмера сервиса, чтобы его можно было остановить.
+const consumerTag = "delayed-notifyer"
+
 // RabbitMQBroker определяет структуру соединения с RabbitMQ.
 type RabbitMQBroker struct {
 	url   string // аддресс
 	queue string // очередь
 
-	ch *amqp091.Channel
+	conn *amqp091.Connection
+	ch   *amqp091.Channel
 
 	publisher *rabbitmq.Publisher
 	consumer  *rabbitmq.Consumer
@@ -36,6 +40,7 @@ func (b *RabbitMQBroker) ConnectWithRetry(retries int, pause time.Duration) erro
 		return err
 	}
 
+	b.conn = conn
 	b.ch = ch
 
 	qm := rabbitmq.NewQueueManager(ch)
@@ -45,7 +50,9 @@ func (b *RabbitMQBroker) ConnectWithRetry(retries int, pause time.Duration) erro
 	}
 
 	b.publisher = rabbitmq.NewPublisher(ch, "")
-	b.consumer = rabbitmq.NewConsumer(ch, rabbitmq.NewConsumerConfig(b.queue))
+	consumerCfg := rabbitmq.NewConsumerConfig(b.queue)
+	consumerCfg.Consumer = consumerTag
+	b.consumer = rabbitmq.NewConsumer(ch, consumerCfg)
 
 	return nil
 }
@@ -68,7 +75,16 @@ func (b *RabbitMQBroker) Consume(msgChan chan []byte) error {
 	return b.consumer.Consume(msgChan)
 }
 
-// Close закрывает соединение amqp канала.
+// StopConsuming останавливает доставку новых сообщений консьюмеру.
+// После этого Consume завершается, как только будут переданы уже полученные сообщения.
+func (b *RabbitMQBroker) StopConsuming() error {
+	if b.ch == nil {
+		return errors.New("not connected: channel is nil")
+	}
+	return b.ch.Cancel(consumerTag, false)
+}
+
+// Close закрывает amqp канал и соединение.
 func (b *RabbitMQBroker) Close() error {
-	return b.ch.Close()
+	return errors.Join(b.ch.Close(), b.conn.Close())
 }
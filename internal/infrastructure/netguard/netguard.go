@@ -0,0 +1,66 @@
+// Package netguard защищает исходящие запросы по адресам, присланным клиентами, от обращений
+// во внутренние сети сервиса (SSRF).
+package netguard
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// ErrPrivateAddress возвращается при попытке соединиться с адресом внутренней сети.
+var ErrPrivateAddress = errors.New("address is not public")
+
+// NewHTTPClient создает http клиент с таймаутом, который не соединяется с loopback, link-local,
+// частными (RFC 1918, ULA) и прочими непубличными адресами. Адрес проверяется после разрешения имени
+// при каждом соединении, в том числе после редиректа, поэтому имя, указывающее во внутреннюю сеть, не помогает.
+// Прокси из окружения не используется. С allowPrivate проверка выключена.
+func NewHTTPClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = control
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// control проверяет адрес, с которым открывается соединение.
+func control(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+
+	if !IsPublic(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrPrivateAddress, addrPort.Addr())
+	}
+	return nil
+}
+
+// nonPublic сети, которые не считаются частными в net/netip, но тоже недоступны из интернета.
+var nonPublic = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),     // "эта сеть"
+	netip.MustParsePrefix("100.64.0.0/10"), // операторский NAT, RFC 6598
+}
+
+// IsPublic сообщает, является ли адрес публичным адресом unicast.
+func IsPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range nonPublic {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
@@ -2,6 +2,8 @@ package poller
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
 	"time"
@@ -16,25 +18,41 @@ type storage interface {
 	Get(ctx context.Context, key string) (string, error)
 	Remove(ctx context.Context, key string) error
 	SortedSetRemove(ctx context.Context, set string, value interface{}) error
+	SortedSetAdd(ctx context.Context, set string, value interface{}, score float64) error
+	CompareAndSwap(ctx context.Context, key, old, value string, exp time.Duration) (bool, error)
+}
+
+type notificationManager interface {
+	SetNotificationStatus(ctx context.Context, uid string, status models.NotificationStatus, note string) error
+	DropNotification(ctx context.Context, notification models.DelayedNotification, note string) error
 }
 
 type publisher interface {
 	Publish(value string) error
 }
 
+type quietHoursChecker interface {
+	QuietUntil(ctx context.Context, notification models.DelayedNotification, now time.Time) (time.Time, models.QuietHoursAction, error)
+}
+
 // RedisPoller мониторит хранилище уведомлений в поисках тех, которые пора отправить.
 // Отправляет необходимые уведомления в паблишер. Пишет ошибки в отдельный канал.
+// Уведомления, пришедшиеся на тихие часы получателя, откладываются до их конца или отбрасываются.
 type RedisPoller struct {
 	storage        storage
 	publisher      publisher
+	quietHours     quietHoursChecker
+	notifications  notificationManager
 	delayedSetName string
 	logger         logger.Logger
 }
 
 // NewRedisPoller создает новый RedisPoller.
-func NewRedisPoller(storage storage, publisher publisher, delayedSetName string, logger logger.Logger) *RedisPoller {
+func NewRedisPoller(
+	storage storage, publisher publisher, quietHours quietHoursChecker, notifications notificationManager, delayedSetName string, logger logger.Logger,
+) *RedisPoller {
 	return &RedisPoller{
-		storage: storage, publisher: publisher, delayedSetName: delayedSetName, logger: logger}
+		storage: storage, publisher: publisher, quietHours: quietHours, notifications: notifications, delayedSetName: delayedSetName, logger: logger}
 }
 
 // Run запускает поллер. Поллер запускает функцию-воркер с частотой тикера.
@@ -69,27 +87,137 @@ func (rp *RedisPoller) processReadyTasks(ctx context.Context) {
 }
 
 // обработка уведомления.
+// Уведомление снимается с очереди до публикации: консьюмер может вернуть его
+// в очередь для повторной попытки раньше, чем поллер закончит обработку.
 func (rp *RedisPoller) handleNotification(ctx context.Context, notificationID string) {
 	payload, err := rp.storage.Get(ctx, "notification:"+notificationID)
+	if errors.Is(err, models.ErrNotFound) {
+		// уведомление отменено, а айди остался в очереди: иначе он занял бы место среди готовых к отправке
+		if err := rp.storage.SortedSetRemove(ctx, rp.delayedSetName, notificationID); err != nil {
+			rp.logger.WithFields("notificationID", notificationID).Error(err)
+		}
+		return
+	}
 	if err != nil {
+		rp.logger.WithFields("notificationID", notificationID).Error(err)
 		return
 	}
 
-	if err := rp.publisher.Publish(payload); err != nil {
-		_ = rp.storage.Add(ctx, "notification.status:"+notificationID, string(models.StatusFailed), 168*time.Hour)
-		rp.logger.WithFields("notificationID", notificationID).Error(fmt.Errorf("publishing: %v", err))
+	if rp.holdForQuietHours(ctx, notificationID, payload) {
 		return
 	}
 
 	if err := rp.storage.SortedSetRemove(ctx, rp.delayedSetName, notificationID); err != nil {
 		rp.logger.WithFields("notificationID", notificationID).Error(err)
+		return
 	}
 
 	if err := rp.storage.Remove(ctx, "notification:"+notificationID); err != nil {
 		rp.logger.WithFields("notificationID", notificationID).Error(err)
 	}
 
-	if err := rp.storage.Add(ctx, "notification.status:"+notificationID, string(models.StatusSending), 168*time.Hour); err != nil {
+	if err := rp.releaseCollapseKey(ctx, notificationID, payload); err != nil {
+		rp.logger.WithFields("notificationID", notificationID).Error(err)
+	}
+
+	if err := rp.notifications.SetNotificationStatus(ctx, notificationID, models.StatusSending, ""); err != nil {
 		rp.logger.WithFields("notificationID", notificationID).Error(err)
 	}
+
+	if err := rp.publisher.Publish(payload); err != nil {
+		rp.restoreNotification(ctx, notificationID, payload)
+		rp.logger.WithFields("notificationID", notificationID).Error(fmt.Errorf("publishing: %v", err))
+	}
+}
+
+// восстановление уведомления в очереди после неудачной публикации.
+// Уведомление снова ждет отправки, поэтому получает статус повторной попытки, а не ошибки.
+func (rp *RedisPoller) restoreNotification(ctx context.Context, notificationID string, payload string) {
+	if err := rp.storage.Add(ctx, "notification:"+notificationID, payload, 24*time.Hour); err != nil {
+		rp.logger.WithFields("notificationID", notificationID).Error(err)
+	}
+
+	if err := rp.storage.SortedSetAdd(ctx, rp.delayedSetName, notificationID, float64(time.Now().UnixMilli())); err != nil {
+		rp.logger.WithFields("notificationID", notificationID).Error(err)
+	}
+
+	if err := rp.notifications.SetNotificationStatus(ctx, notificationID, models.StatusRetrying, "publishing failed"); err != nil {
+		rp.logger.WithFields("notificationID", notificationID).Error(err)
+	}
+}
+
+// holdForQuietHours откладывает уведомление до конца тихих часов или отбрасывает его.
+// Возвращает false, если уведомление можно отправлять. При ошибке проверки уведомление отправляется:
+// лучше побеспокоить получателя, чем потерять уведомление.
+func (rp *RedisPoller) holdForQuietHours(ctx context.Context, notificationID, payload string) bool {
+	var notification models.DelayedNotification
+	if err := json.Unmarshal([]byte(payload), &notification); err != nil {
+		return false // отправщик сообщит о поврежденном уведомлении
+	}
+
+	until, action, err := rp.quietHours.QuietUntil(ctx, notification, time.Now())
+	if err != nil {
+		rp.logger.WithFields("notificationID", notificationID).Error(fmt.Errorf("checking quiet hours: %v", err))
+		return false
+	}
+	if until.IsZero() {
+		return false
+	}
+
+	if action == models.QuietHoursDrop {
+		rp.dropNotification(ctx, notification, until)
+	} else {
+		rp.deferNotification(ctx, notificationID, payload, until)
+	}
+	return true
+}
+
+// deferNotification переносит уведомление в отложенной очереди на конец тихих часов.
+func (rp *RedisPoller) deferNotification(ctx context.Context, notificationID, payload string, until time.Time) {
+	// тихие часы могут оказаться дольше оставшегося срока хранения уведомления
+	if err := rp.storage.Add(ctx, "notification:"+notificationID, payload, time.Until(until)+24*time.Hour); err != nil {
+		rp.logger.WithFields("notificationID", notificationID).Error(err)
+		return
+	}
+
+	if err := rp.storage.SortedSetAdd(ctx, rp.delayedSetName, notificationID, float64(until.UnixMilli())); err != nil {
+		rp.logger.WithFields("notificationID", notificationID).Error(err)
+		return
+	}
+
+	note := "quiet hours until " + until.UTC().Format(time.RFC3339)
+	if err := rp.notifications.SetNotificationStatus(ctx, notificationID, models.StatusDeferred, note); err != nil {
+		rp.logger.WithFields("notificationID", notificationID).Error(err)
+	}
+}
+
+// dropNotification снимает с очереди и отбрасывает уведомление, пришедшееся на тихие часы.
+func (rp *RedisPoller) dropNotification(ctx context.Context, notification models.DelayedNotification, until time.Time) {
+	if err := rp.storage.SortedSetRemove(ctx, rp.delayedSetName, notification.ID); err != nil {
+		rp.logger.WithFields("notificationID", notification.ID).Error(err)
+		return
+	}
+
+	note := "quiet hours until " + until.UTC().Format(time.RFC3339)
+	if err := rp.notifications.DropNotification(ctx, notification, note); err != nil {
+		rp.logger.WithFields("notificationID", notification.ID).Error(err)
+	}
+}
+
+// releaseCollapseKey удаляет индекс ключа схлопывания отправляемого уведомления,
+// чтобы уведомление с тем же ключом можно было запланировать снова.
+func (rp *RedisPoller) releaseCollapseKey(ctx context.Context, notificationID, payload string) error {
+	var notification models.DelayedNotification
+	if err := json.Unmarshal([]byte(payload), &notification); err != nil {
+		return nil // отправщик сообщит о поврежденном уведомлении
+	}
+
+	key := notification.CollapseIndexKey()
+	if key == "" {
+		return nil
+	}
+
+	// индекс, который уже указывает на заменившее уведомление, не трогается
+	_, err := rp.storage.CompareAndSwap(ctx, key, notificationID, "", 0)
+	return err
 }
@@ -2,8 +2,10 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"time"
 
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
 	z "github.com/go-redis/redis/v8"
 	"github.com/wb-go/wbf/redis"
 )
@@ -24,6 +26,17 @@ func (r *Redis) Add(ctx context.Context, key string, value interface{}, exp time
 	return r.client.SetWithExpiration(ctx, key, value, exp)
 }
 
+// Increment атомарно увеличивает счетчик по ключу и возвращает новое значение.
+func (r *Redis) Increment(ctx context.Context, key string) (int64, error) {
+	return r.client.Incr(ctx, key).Result()
+}
+
+// AddIfAbsent атомарно добавляет значение по ключу, только если ключа еще нет.
+// Возвращает false, если ключ уже есть.
+func (r *Redis) AddIfAbsent(ctx context.Context, key string, value interface{}, exp time.Duration) (bool, error) {
+	return r.client.SetNX(ctx, key, value, exp).Result()
+}
+
 // SortedSetAdd добавляет новое значение в SortedSet.
 func (r *Redis) SortedSetAdd(ctx context.Context, set string, value interface{}, score float64) error {
 	_, err := r.client.ZAdd(ctx, set, &z.Z{
@@ -44,9 +57,24 @@ func (r *Redis) SortedSetRangeByScore(ctx context.Context, key, min, max string,
 	}).Result()
 }
 
+// SortedSetScore возвращает score значения в sorted set.
+// Если значения нет, возвращает models.ErrNotFound.
+func (r *Redis) SortedSetScore(ctx context.Context, set string, value string) (float64, error) {
+	score, err := r.client.ZScore(ctx, set, value).Result()
+	if errors.Is(err, z.Nil) {
+		return 0, models.ErrNotFound
+	}
+	return score, err
+}
+
 // Get возвращает значение по ключу.
+// Если ключа нет, возвращает models.ErrNotFound.
 func (r *Redis) Get(ctx context.Context, key string) (string, error) {
-	return r.client.Get(ctx, key)
+	value, err := r.client.Get(ctx, key)
+	if errors.Is(err, redis.NoMatches) {
+		return "", models.ErrNotFound
+	}
+	return value, err
 }
 
 // Remove удаляет значение по ключу.
@@ -59,3 +87,110 @@ func (r *Redis) SortedSetRemove(ctx context.Context, set string, value interface
 	_, err := r.client.ZRem(ctx, set, value).Result()
 	return err
 }
+
+// SortedSetRemoveRangeByScore удаляет из sorted set значения со score в диапазоне от min до max.
+func (r *Redis) SortedSetRemoveRangeByScore(ctx context.Context, set, min, max string) error {
+	return r.client.ZRemRangeByScore(ctx, set, min, max).Err()
+}
+
+// ListPush добавляет значение в конец списка и продлевает срок его хранения.
+func (r *Redis) ListPush(ctx context.Context, key string, value interface{}, exp time.Duration) error {
+	pipe := r.client.TxPipeline()
+	pipe.RPush(ctx, key, value)
+	pipe.Expire(ctx, key, exp)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// ListPushCapped добавляет значение в конец списка, оставляя в нем только последние size значений,
+// и продлевает срок его хранения.
+func (r *Redis) ListPushCapped(ctx context.Context, key string, value interface{}, size int64, exp time.Duration) error {
+	pipe := r.client.TxPipeline()
+	pipe.RPush(ctx, key, value)
+	pipe.LTrim(ctx, key, -size, -1)
+	pipe.Expire(ctx, key, exp)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// ListRange возвращает все значения списка. Если ключа нет, возвращает пустой список.
+func (r *Redis) ListRange(ctx context.Context, key string) ([]string, error) {
+	return r.client.LRange(ctx, key, 0, -1).Result()
+}
+
+// compareAndSwapScript атомарно заменяет значение KEYS[1] на ARGV[2] со сроком ARGV[3] мс,
+// если оно равно ARGV[1]. Пустое ARGV[2] удаляет ключ. Возвращает 1, если значение заменено.
+var compareAndSwapScript = z.NewScript(`
+if redis.call('GET', KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+if ARGV[2] == '' then
+	redis.call('DEL', KEYS[1])
+else
+	redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+end
+return 1
+`)
+
+// CompareAndSwap заменяет значение по ключу на value со сроком exp, если оно равно old.
+// Пустое value удаляет ключ. Возвращает false, если значение по ключу другое или ключа нет.
+func (r *Redis) CompareAndSwap(ctx context.Context, key, old, value string, exp time.Duration) (bool, error) {
+	swapped, err := compareAndSwapScript.Run(ctx, r.client.Client, []string{key}, old, value, exp.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return swapped == 1, nil
+}
+
+// takeTokenScript атомарно пополняет корзину токенов по прошедшему времени и забирает из нее токен.
+// Корзина вмещает ARGV[1] токенов, один токен пополняется за ARGV[2] мс, ARGV[3] - текущее время в мс.
+// Возвращает 0, если токен забран, иначе сколько мс ждать следующего токена.
+var takeTokenScript = z.NewScript(`
+local capacity = tonumber(ARGV[1])
+local interval = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(bucket[1])
+local ts = tonumber(bucket[2])
+if tokens == nil or ts == nil then
+	tokens = capacity
+	ts = now
+end
+
+local refill = math.floor((now - ts) / interval)
+if refill > 0 then
+	tokens = math.min(capacity, tokens + refill)
+	ts = ts + refill * interval
+end
+if tokens >= capacity then
+	ts = now
+end
+
+local wait = 0
+if tokens > 0 then
+	tokens = tokens - 1
+else
+	wait = math.max(1, math.ceil(interval - (now - ts)))
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tokens, 'ts', ts)
+redis.call('PEXPIRE', KEYS[1], math.ceil(capacity * interval))
+return wait
+`)
+
+// TakeToken забирает токен из корзины по ключу, вмещающей capacity токенов и полностью пополняемой за window.
+// Возвращает 0, если токен забран, иначе время до появления следующего токена.
+func (r *Redis) TakeToken(ctx context.Context, key string, capacity int, window time.Duration) (time.Duration, error) {
+	interval := float64(window.Milliseconds()) / float64(capacity)
+	wait, err := takeTokenScript.Run(ctx, r.client.Client, []string{key}, capacity, interval, time.Now().UnixMilli()).Int64()
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(wait) * time.Millisecond, nil
+}
+
+// Close закрывает соединение с Redis.
+func (r *Redis) Close() error {
+	return r.client.Close()
+}
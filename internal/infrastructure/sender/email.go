@@ -1,36 +1,248 @@
 package sender
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"net"
+	"net/mail"
 	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
+	"github.com/google/uuid"
+)
+
+const (
+	smtpTLSNone     = "none"     // соединение без шифрования, например MailHog
+	smtpTLSStartTLS = "starttls" // обязательный переход на TLS командой STARTTLS
+	smtpTLSImplicit = "tls"      // TLS с момента подключения, обычно порт 465
 )
 
+// EmailConfig описывает подключение к SMTP серверу.
+type EmailConfig struct {
+	From           string
+	FromName       string // отображаемое имя отправителя
+	DefaultSubject string // тема письма, если у уведомления она не задана
+	Host           string
+	Port           string
+
+	Username string
+	Password string
+	Auth     string // none, plain, login или cram-md5
+	TLS      string // none, starttls или tls
+
+	PoolSize    int           // максимальное число одновременно открытых соединений
+	IdleTimeout time.Duration // простаивающие дольше соединения закрываются
+	Timeout     time.Duration // ограничение на отправку, если у контекста нет своего дедлайна
+
+	Throttle *Throttle // лимит отправки через релей, nil - без лимита
+}
+
 // Email определяет отправщик электронных писем через SMTP сервер.
+// Соединения с сервером переиспользуются между письмами.
 type Email struct {
-	from string
-	host string
-	port string
+	from           mail.Address
+	defaultSubject string
+
+	host    string
+	addr    string
+	tlsMode string
+	auth    smtp.Auth
+	timeout time.Duration
+
+	throttle *Throttle
+	pool     *smtpPool
 }
 
 // NewEmail создает новый Email.
-func NewEmail(from, host, port string) *Email {
-	return &Email{
-		from: from,
-		host: host,
-		port: port,
-	}
-}
-
-// Send отправляет сообщение на указанный адрес.
-func (e *Email) Send(emailAddr string, data string) error {
-	to := []string{emailAddr}
-	msg := fmt.Sprintf("From: %s\r\n"+
-		"To: %s\r\n"+
-		"Subject: Уведомление\r\n"+
-		"\r\n"+
-		"%s\r\n",
-		e.from, to, data)
-
-	addr := e.host + ":" + e.port
-	return smtp.SendMail(addr, nil, e.from, to, []byte(msg))
+func NewEmail(cfg EmailConfig) (*Email, error) {
+	tlsMode := strings.ToLower(cfg.TLS)
+	switch tlsMode {
+	case "":
+		tlsMode = smtpTLSNone
+	case smtpTLSNone, smtpTLSStartTLS, smtpTLSImplicit:
+	default:
+		return nil, fmt.Errorf("unknown smtp tls mode %q", cfg.TLS)
+	}
+
+	auth, err := newSMTPAuth(cfg.Auth, cfg.Username, cfg.Password, cfg.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &Email{
+		from:           mail.Address{Name: cfg.FromName, Address: cfg.From},
+		defaultSubject: cfg.DefaultSubject,
+		host:           cfg.Host,
+		addr:           net.JoinHostPort(cfg.Host, cfg.Port),
+		tlsMode:        tlsMode,
+		auth:           auth,
+		timeout:        cfg.Timeout,
+		throttle:       cfg.Throttle,
+	}
+	e.pool = newSMTPPool(cfg.PoolSize, cfg.IdleTimeout, e.dial)
+
+	return e, nil
+}
+
+// Send отправляет письмо на указанный в нем адрес, дождавшись очереди в лимите релея.
+// Соединение с SMTP сервером закрывается при отмене контекста или по истечении дедлайна.
+// Если письмо не собралось или сервер окончательно отказал в адресе или письме, возвращается *models.RecipientError.
+func (e *Email) Send(ctx context.Context, email models.EmailMessage) error {
+	if err := e.throttle.Wait(ctx, ""); err != nil {
+		return err
+	}
+
+	if _, ok := ctx.Deadline(); !ok && e.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.timeout)
+		defer cancel()
+	}
+
+	msg, err := e.buildMessage(email)
+	if err != nil {
+		return &models.RecipientError{Err: fmt.Errorf("failed to build email: %w", err)}
+	}
+
+	err = e.sendMail(ctx, []string{email.To}, msg)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
+
+// buildMessage собирает MIME письмо с текущей датой и уникальным Message-ID.
+func (e *Email) buildMessage(email models.EmailMessage) ([]byte, error) {
+	subject := email.Subject
+	if subject == "" {
+		subject = e.defaultSubject
+	}
+
+	m := mimeMessage{
+		from:        e.from,
+		to:          mail.Address{Address: email.To},
+		subject:     subject,
+		text:        email.Text,
+		html:        email.HTML,
+		attachments: email.Attachments,
+		event:       email.Event,
+		unsubscribe: email.Unsubscribe,
+		date:        time.Now(),
+		messageID:   e.newMessageID(),
+	}
+	return m.bytes()
+}
+
+// newMessageID генерирует Message-ID в домене адреса отправителя.
+func (e *Email) newMessageID() string {
+	domain := e.host
+	if at := strings.LastIndex(e.from.Address, "@"); at >= 0 {
+		domain = e.from.Address[at+1:]
+	}
+	return "<" + uuid.NewString() + "@" + domain + ">"
+}
+
+// Close закрывает свободные соединения с SMTP сервером.
+func (e *Email) Close() error {
+	e.pool.close()
+	return nil
+}
+
+// Ping проверяет, что SMTP сервер доступен и отвечает на команды.
+// Проверенное соединение возвращается в пул и используется для следующих писем.
+func (e *Email) Ping(ctx context.Context) error {
+	return e.withConn(ctx, func(sc *smtpConn) error {
+		return sc.client.Noop()
+	})
+}
+
+// sendMail передает письмо через соединение из пула.
+func (e *Email) sendMail(ctx context.Context, to []string, msg []byte) error {
+	return e.withConn(ctx, func(sc *smtpConn) error {
+		return sc.transmit(e.from.Address, to, msg)
+	})
+}
+
+// withConn выполняет fn на соединении из пула, привязывая его к контексту.
+func (e *Email) withConn(ctx context.Context, fn func(sc *smtpConn) error) error {
+	sc, err := e.pool.get(ctx)
+	if err != nil {
+		return err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = sc.conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() { _ = sc.conn.Close() })
+
+	err = fn(sc)
+
+	// если AfterFunc уже сработал, соединение закрыто и в пул не возвращается
+	e.pool.put(sc, stop() && err == nil)
+	return err
+}
+
+// dial открывает новое соединение, при необходимости включает TLS и проходит аутентификацию.
+func (e *Email) dial(ctx context.Context) (*smtpConn, error) {
+	tlsConfig := &tls.Config{ServerName: e.host}
+
+	var (
+		conn net.Conn
+		err  error
+	)
+	if e.tlsMode == smtpTLSImplicit {
+		d := tls.Dialer{Config: tlsConfig}
+		conn, err = d.DialContext(ctx, "tcp", e.addr)
+	} else {
+		var d net.Dialer
+		conn, err = d.DialContext(ctx, "tcp", e.addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	c, err := smtp.NewClient(conn, e.host)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	if err := e.handshake(c, tlsConfig); err != nil {
+		_ = c.Close()
+		return nil, err
+	}
+
+	return &smtpConn{conn: conn, client: c}, nil
+}
+
+// handshake выполняет STARTTLS и аутентификацию согласно конфигу.
+func (e *Email) handshake(c *smtp.Client, tlsConfig *tls.Config) error {
+	if e.tlsMode == smtpTLSStartTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return errors.New("smtp server does not support STARTTLS")
+		}
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+
+	if e.auth != nil {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return errors.New("smtp server does not support AUTH")
+		}
+		if err := c.Auth(e.auth); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
@@ -0,0 +1,237 @@
+package sender
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSMTPServer минимальный SMTP сервер, принимающий любые письма, кроме писем на адреса unknown@.
+type fakeSMTPServer struct {
+	ln          net.Listener
+	connections atomic.Int32
+
+	mu       sync.Mutex
+	messages []string
+	logins   []string // пары логин:пароль, полученные через AUTH LOGIN
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := &fakeSMTPServer{ln: ln}
+	go s.serve()
+	t.Cleanup(func() { _ = ln.Close() })
+
+	return s
+}
+
+func listenerPort(ln net.Listener) string {
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+	return port
+}
+
+func (s *fakeSMTPServer) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		s.connections.Add(1)
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeSMTPServer) handle(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+	readLine := func() (string, bool) {
+		line, err := r.ReadString('\n')
+		return strings.TrimRight(line, "\r\n"), err == nil
+	}
+	decode := func(line string) string {
+		b, _ := base64.StdEncoding.DecodeString(line)
+		return string(b)
+	}
+
+	reply("220 fake ESMTP")
+	for {
+		line, ok := readLine()
+		if !ok {
+			return
+		}
+
+		cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch cmd {
+		case "EHLO":
+			reply("250-fake")
+			reply("250 AUTH LOGIN")
+		case "AUTH":
+			reply("334 " + base64.StdEncoding.EncodeToString([]byte("Username:")))
+			user, _ := readLine()
+			reply("334 " + base64.StdEncoding.EncodeToString([]byte("Password:")))
+			pass, _ := readLine()
+			s.mu.Lock()
+			s.logins = append(s.logins, decode(user)+":"+decode(pass))
+			s.mu.Unlock()
+			reply("235 authenticated")
+		case "RCPT":
+			if strings.Contains(line, "unknown@") {
+				reply("550 no such user")
+				continue
+			}
+			reply("250 ok")
+		case "DATA":
+			reply("354 go ahead")
+			var msg strings.Builder
+			for {
+				l, ok := readLine()
+				if !ok || l == "." {
+					break
+				}
+				msg.WriteString(l + "\n")
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, msg.String())
+			s.mu.Unlock()
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func TestEmail_Send_ReusesConnections(t *testing.T) {
+	t.Parallel()
+
+	srv := newFakeSMTPServer(t)
+
+	e, err := NewEmail(EmailConfig{
+		From:     "noreply@example.com",
+		Host:     "127.0.0.1",
+		Port:     listenerPort(srv.ln),
+		Username: "user",
+		Password: "secret",
+		Auth:     "login",
+		PoolSize: 1,
+		Timeout:  5 * time.Second,
+	})
+	require.NoError(t, err)
+	defer e.Close()
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, e.Send(context.Background(), models.EmailMessage{To: "user@example.com", Text: "hello"}))
+	}
+
+	assert.Equal(t, int32(1), srv.connections.Load())
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	assert.Len(t, srv.messages, 3)
+	assert.Equal(t, []string{"user:secret"}, srv.logins)
+}
+
+func TestEmail_Send_RecipientRejected(t *testing.T) {
+	t.Parallel()
+
+	srv := newFakeSMTPServer(t)
+
+	e, err := NewEmail(EmailConfig{
+		From:     "noreply@example.com",
+		Host:     "127.0.0.1",
+		Port:     listenerPort(srv.ln),
+		PoolSize: 1,
+		Timeout:  5 * time.Second,
+	})
+	require.NoError(t, err)
+	defer e.Close()
+
+	err = e.Send(context.Background(), models.EmailMessage{To: "unknown@example.com", Text: "hello"})
+	var recipientErr *models.RecipientError
+	assert.ErrorAs(t, err, &recipientErr)
+	assert.ErrorContains(t, err, "550")
+}
+
+func TestEmail_Ping(t *testing.T) {
+	t.Parallel()
+
+	srv := newFakeSMTPServer(t)
+
+	e, err := NewEmail(EmailConfig{
+		From:     "noreply@example.com",
+		Host:     "127.0.0.1",
+		Port:     listenerPort(srv.ln),
+		PoolSize: 1,
+		Timeout:  5 * time.Second,
+	})
+	require.NoError(t, err)
+	defer e.Close()
+
+	require.NoError(t, e.Ping(context.Background()))
+	require.NoError(t, e.Send(context.Background(), models.EmailMessage{To: "user@example.com", Text: "hello"}))
+
+	// письмо уходит через соединение, открытое проверкой
+	assert.Equal(t, int32(1), srv.connections.Load())
+
+	_ = srv.ln.Close()
+	down, err := NewEmail(EmailConfig{Host: "127.0.0.1", Port: listenerPort(srv.ln)})
+	require.NoError(t, err)
+	assert.Error(t, down.Ping(context.Background()))
+}
+
+func TestEmail_Send_ContextCanceled(t *testing.T) {
+	t.Parallel()
+
+	// сервер принимает соединение, но ничего не отвечает
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err == nil {
+			defer conn.Close()
+			time.Sleep(5 * time.Second)
+		}
+	}()
+
+	e, err := NewEmail(EmailConfig{
+		Host: "127.0.0.1",
+		Port: listenerPort(ln),
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err = e.Send(ctx, models.EmailMessage{To: "user@example.com", Text: "hello"})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestNewEmail_InvalidConfig(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewEmail(EmailConfig{TLS: "ssl"})
+	assert.Error(t, err)
+
+	_, err = NewEmail(EmailConfig{Auth: "xoauth2"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,110 @@
+package sender
+
+import (
+	"bytes"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
+)
+
+const (
+	icsProductID  = "-//delayed-notifyer//EN"
+	icsTimeLayout = "20060102T150405Z"
+	icsLineOctets = 75 // максимальная длина строки без переноса по RFC 5545
+)
+
+// calendarInvite описывает приглашение в календарь (RFC 5545) с методом iTIP (RFC 5546).
+type calendarInvite struct {
+	event     models.Event
+	summary   string // используется, если у события нет своего названия
+	organizer mail.Address
+	attendee  mail.Address
+	stamp     time.Time
+}
+
+// bytes собирает VCALENDAR с единственным VEVENT.
+func (ci *calendarInvite) bytes() []byte {
+	summary := ci.event.Summary
+	if summary == "" {
+		summary = ci.summary
+	}
+
+	status := "CONFIRMED"
+	if ci.event.Method == models.EventCancel {
+		status = "CANCELLED"
+	}
+
+	var buf bytes.Buffer
+	writeICSLine(&buf, "BEGIN:VCALENDAR")
+	writeICSLine(&buf, "PRODID:"+icsProductID)
+	writeICSLine(&buf, "VERSION:2.0")
+	writeICSLine(&buf, "CALSCALE:GREGORIAN")
+	writeICSLine(&buf, "METHOD:"+string(ci.method()))
+	writeICSLine(&buf, "BEGIN:VEVENT")
+	writeICSLine(&buf, "UID:"+escapeICSText(ci.event.UID))
+	writeICSLine(&buf, "DTSTAMP:"+ci.stamp.UTC().Format(icsTimeLayout))
+	writeICSLine(&buf, "DTSTART:"+ci.event.Start.UTC().Format(icsTimeLayout))
+	writeICSLine(&buf, "DTEND:"+ci.event.End.UTC().Format(icsTimeLayout))
+	writeICSLine(&buf, "SEQUENCE:"+strconv.Itoa(ci.event.Sequence))
+	writeICSLine(&buf, "STATUS:"+status)
+	writeICSLine(&buf, "SUMMARY:"+escapeICSText(summary))
+	if ci.event.Location != "" {
+		writeICSLine(&buf, "LOCATION:"+escapeICSText(ci.event.Location))
+	}
+	writeICSLine(&buf, "ORGANIZER"+icsCommonName(ci.organizer)+":mailto:"+ci.organizer.Address)
+	writeICSLine(&buf, "ATTENDEE;ROLE=REQ-PARTICIPANT;PARTSTAT=NEEDS-ACTION;RSVP=FALSE"+
+		icsCommonName(ci.attendee)+":mailto:"+ci.attendee.Address)
+	writeICSLine(&buf, "END:VEVENT")
+	writeICSLine(&buf, "END:VCALENDAR")
+
+	return buf.Bytes()
+}
+
+// method возвращает метод приглашения, по умолчанию REQUEST.
+func (ci *calendarInvite) method() models.EventMethod {
+	if ci.event.Method == "" {
+		return models.EventRequest
+	}
+	return ci.event.Method
+}
+
+// icsCommonName возвращает параметр CN с отображаемым именем, если оно есть.
+func icsCommonName(addr mail.Address) string {
+	if addr.Name == "" {
+		return ""
+	}
+	return `;CN="` + strings.NewReplacer(`"`, "'", "\r", "", "\n", " ").Replace(addr.Name) + `"`
+}
+
+// escapeICSText экранирует значение типа TEXT.
+func escapeICSText(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		"\r", "",
+	).Replace(s)
+}
+
+// writeICSLine пишет строку контента, перенося ее по 75 октетов без разрыва UTF-8 символов.
+func writeICSLine(buf *bytes.Buffer, line string) {
+	limit := icsLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		buf.WriteString(line[:cut])
+		buf.WriteString("\r\n ")
+		line = line[cut:]
+		limit = icsLineOctets - 1 // пробел в начале продолжения входит в длину строки
+	}
+	buf.WriteString(line)
+	buf.WriteString("\r\n")
+}
@@ -0,0 +1,41 @@
+package sender
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteICSLine_Folding(t *testing.T) {
+	t.Parallel()
+
+	line := "SUMMARY:" + strings.Repeat("Напоминание ", 20)
+
+	var buf bytes.Buffer
+	writeICSLine(&buf, line)
+
+	out := strings.TrimSuffix(buf.String(), "\r\n")
+	parts := strings.Split(out, "\r\n")
+	assert.Greater(t, len(parts), 1)
+
+	var unfolded strings.Builder
+	for i, part := range parts {
+		assert.LessOrEqual(t, len(part), icsLineOctets)
+		assert.True(t, utf8.ValidString(part))
+		if i > 0 {
+			assert.True(t, strings.HasPrefix(part, " "))
+			part = part[1:]
+		}
+		unfolded.WriteString(part)
+	}
+	assert.Equal(t, line, unfolded.String())
+}
+
+func TestEscapeICSText(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, `a\, b\; c\\d\nline`, escapeICSText("a, b; c\\d\r\nline"))
+}
@@ -0,0 +1,311 @@
+package sender
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
+)
+
+// mimeMessage описывает письмо, собираемое в формате MIME.
+// Дата, Message-ID и разделитель частей задаются снаружи, чтобы сборка была детерминированной.
+type mimeMessage struct {
+	from        mail.Address
+	to          mail.Address
+	subject     string
+	text        string
+	html        string // если пусто, HTML версия строится из текста
+	attachments []models.Attachment
+	event       *models.Event // если задано, письмо содержит приглашение в календарь
+	unsubscribe string        // ссылка отписки в один клик, если задана
+	date        time.Time
+	messageID   string
+	boundary    string // префикс разделителей частей, если пусто - случайный
+}
+
+// bytes собирает письмо: заголовки и тело.
+// Тело - multipart/alternative с текстовой и HTML частями, которое при наличии
+// inline изображений вкладывается в multipart/related, а при наличии вложений - в multipart/mixed.
+// Приглашение в календарь добавляется и в multipart/alternative, и вложением invite.ics:
+// почтовые клиенты по-разному находят его в письме.
+func (m *mimeMessage) bytes() ([]byte, error) {
+	var body bytes.Buffer
+	contentType, err := m.writeBody(&body)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	writeHeader(&buf, "From", m.from.String())
+	writeHeader(&buf, "To", m.to.String())
+	writeHeader(&buf, "Subject", mime.BEncoding.Encode("UTF-8", m.subject))
+	writeHeader(&buf, "Date", m.date.Format(time.RFC1123Z))
+	writeHeader(&buf, "Message-ID", m.messageID)
+	if m.unsubscribe != "" {
+		// RFC 8058: почтовый клиент отписывает получателя POST запросом без перехода по ссылке
+		writeHeader(&buf, "List-Unsubscribe", "<"+m.unsubscribe+">")
+		writeHeader(&buf, "List-Unsubscribe-Post", "List-Unsubscribe=One-Click")
+	}
+	writeHeader(&buf, "MIME-Version", "1.0")
+	writeHeader(&buf, "Content-Type", contentType)
+	buf.WriteString("\r\n")
+	buf.Write(body.Bytes())
+
+	return buf.Bytes(), nil
+}
+
+// writeBody выбирает структуру тела письма и возвращает его Content-Type.
+func (m *mimeMessage) writeBody(w io.Writer) (string, error) {
+	attachments := m.attachments
+	if invite := m.calendarInvite(); invite != nil {
+		attachments = append(attachments[:len(attachments):len(attachments)], models.Attachment{
+			Filename:    "invite.ics",
+			ContentType: "application/ics",
+			Data:        invite.bytes(),
+		})
+	}
+
+	var inline, attached []models.Attachment
+	for _, a := range attachments {
+		if a.ContentID != "" {
+			inline = append(inline, a)
+		} else {
+			attached = append(attached, a)
+		}
+	}
+
+	content := m.writeAlternative
+	if len(inline) > 0 {
+		content = func(w io.Writer) (string, error) { return m.writeRelated(w, inline) }
+	}
+	if len(attached) > 0 {
+		return m.writeMixed(w, content, attached)
+	}
+	return content(w)
+}
+
+// writeAlternative пишет текстовую и HTML версии письма.
+func (m *mimeMessage) writeAlternative(w io.Writer) (string, error) {
+	mw, err := m.multipartWriter(w, "alt")
+	if err != nil {
+		return "", err
+	}
+
+	htmlBody := m.html
+	if htmlBody == "" {
+		htmlBody = textToHTML(m.text)
+	}
+
+	if err := writeQuotedPrintablePart(mw, "text/plain", nil, m.text); err != nil {
+		return "", err
+	}
+	if err := writeQuotedPrintablePart(mw, "text/html", nil, htmlBody); err != nil {
+		return "", err
+	}
+	if invite := m.calendarInvite(); invite != nil {
+		params := map[string]string{"method": string(invite.method())}
+		if err := writeQuotedPrintablePart(mw, "text/calendar", params, string(invite.bytes())); err != nil {
+			return "", err
+		}
+	}
+
+	contentType := mime.FormatMediaType("multipart/alternative", map[string]string{"boundary": mw.Boundary()})
+	return contentType, mw.Close()
+}
+
+// writeRelated пишет письмо вместе с inline изображениями, на которые HTML ссылается через cid:.
+func (m *mimeMessage) writeRelated(w io.Writer, inline []models.Attachment) (string, error) {
+	mw, err := m.multipartWriter(w, "related")
+	if err != nil {
+		return "", err
+	}
+
+	if err := writeNestedPart(mw, m.writeAlternative); err != nil {
+		return "", err
+	}
+	for _, a := range inline {
+		if err := writeAttachmentPart(mw, a); err != nil {
+			return "", err
+		}
+	}
+
+	contentType := mime.FormatMediaType("multipart/related", map[string]string{
+		"boundary": mw.Boundary(),
+		"type":     "multipart/alternative",
+	})
+	return contentType, mw.Close()
+}
+
+// writeMixed пишет содержимое письма и вложения к нему.
+func (m *mimeMessage) writeMixed(w io.Writer, content func(io.Writer) (string, error), attached []models.Attachment) (string, error) {
+	mw, err := m.multipartWriter(w, "mixed")
+	if err != nil {
+		return "", err
+	}
+
+	if err := writeNestedPart(mw, content); err != nil {
+		return "", err
+	}
+	for _, a := range attached {
+		if err := writeAttachmentPart(mw, a); err != nil {
+			return "", err
+		}
+	}
+
+	contentType := mime.FormatMediaType("multipart/mixed", map[string]string{"boundary": mw.Boundary()})
+	return contentType, mw.Close()
+}
+
+// calendarInvite собирает приглашение в календарь, если к письму привязано событие.
+// Организатором по умолчанию считается отправитель письма.
+func (m *mimeMessage) calendarInvite() *calendarInvite {
+	if m.event == nil {
+		return nil
+	}
+
+	organizer := m.from
+	if m.event.Organizer != "" {
+		organizer = mail.Address{Address: m.event.Organizer}
+	}
+
+	return &calendarInvite{
+		event:     *m.event,
+		summary:   m.subject,
+		organizer: organizer,
+		attendee:  m.to,
+		stamp:     m.date,
+	}
+}
+
+// multipartWriter создает multipart.Writer с детерминированным разделителем, если задан префикс.
+func (m *mimeMessage) multipartWriter(w io.Writer, kind string) (*multipart.Writer, error) {
+	mw := multipart.NewWriter(w)
+	if m.boundary != "" {
+		if err := mw.SetBoundary(m.boundary + "-" + kind); err != nil {
+			return nil, err
+		}
+	}
+	return mw, nil
+}
+
+func writeHeader(w io.Writer, key, value string) {
+	fmt.Fprintf(w, "%s: %s\r\n", key, value)
+}
+
+// writeNestedPart добавляет в multipart вложенную multipart часть.
+func writeNestedPart(mw *multipart.Writer, content func(io.Writer) (string, error)) error {
+	var buf bytes.Buffer
+	contentType, err := content(&buf)
+	if err != nil {
+		return err
+	}
+
+	part, err := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {contentType}})
+	if err != nil {
+		return err
+	}
+	_, err = part.Write(buf.Bytes())
+	return err
+}
+
+// writeQuotedPrintablePart добавляет в multipart текстовую часть в кодировке UTF-8.
+func writeQuotedPrintablePart(mw *multipart.Writer, mediaType string, params map[string]string, content string) error {
+	contentParams := map[string]string{"charset": "UTF-8"}
+	for k, v := range params {
+		contentParams[k] = v
+	}
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", mime.FormatMediaType(mediaType, contentParams))
+	header.Set("Content-Transfer-Encoding", "quoted-printable")
+
+	part, err := mw.CreatePart(header)
+	if err != nil {
+		return err
+	}
+
+	qp := quotedprintable.NewWriter(part)
+	if _, err := io.WriteString(qp, content); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// writeAttachmentPart добавляет в multipart вложение в base64.
+// Вложения с ContentID помечаются как inline и доступны в HTML по cid:.
+func writeAttachmentPart(mw *multipart.Writer, a models.Attachment) error {
+	// ContentID проверяется в запросе, но попадает в заголовок как есть, поэтому проверяется и здесь
+	if strings.ContainsAny(a.ContentID, "<>\r\n") {
+		return fmt.Errorf("invalid content id of attachment %s", a.Filename)
+	}
+
+	mediaType, params := attachmentMediaType(a)
+	params["name"] = a.Filename
+
+	disposition := "attachment"
+	header := textproto.MIMEHeader{}
+	if a.ContentID != "" {
+		disposition = "inline"
+		header.Set("Content-ID", "<"+a.ContentID+">")
+	}
+	header.Set("Content-Type", mime.FormatMediaType(mediaType, params))
+	header.Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": a.Filename}))
+	header.Set("Content-Transfer-Encoding", "base64")
+
+	part, err := mw.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	return writeBase64Lines(part, a.Data)
+}
+
+// attachmentMediaType определяет тип вложения: из переданного Content-Type или по расширению файла.
+func attachmentMediaType(a models.Attachment) (string, map[string]string) {
+	contentType := a.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(a.Filename))
+	}
+
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "application/octet-stream", map[string]string{}
+	}
+	return mediaType, params
+}
+
+// writeBase64Lines пишет данные в base64 строками по 76 символов, как требует RFC 2045.
+func writeBase64Lines(w io.Writer, data []byte) error {
+	const lineBytes = 57 // 57 байт кодируются ровно в 76 символов
+
+	line := make([]byte, base64.StdEncoding.EncodedLen(lineBytes)+2)
+	for len(data) > 0 {
+		n := min(lineBytes, len(data))
+		encoded := base64.StdEncoding.EncodedLen(n)
+		base64.StdEncoding.Encode(line, data[:n])
+		copy(line[encoded:], "\r\n")
+		if _, err := w.Write(line[:encoded+2]); err != nil {
+			return err
+		}
+		data = data[n:]
+	}
+	return nil
+}
+
+// textToHTML экранирует текст и сохраняет переносы строк.
+func textToHTML(text string) string {
+	escaped := html.EscapeString(text)
+	escaped = strings.ReplaceAll(escaped, "\r\n", "\n")
+	escaped = strings.ReplaceAll(escaped, "\n", "<br>\n")
+	return "<!DOCTYPE html>\n<html><body>" + escaped + "</body></html>"
+}
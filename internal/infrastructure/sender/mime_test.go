@@ -0,0 +1,169 @@
+package sender
+
+import (
+	"bytes"
+	"flag"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// assertGolden сравнивает результат с testdata/<name>.golden.
+// Запуск с флагом -update перезаписывает эталон.
+func assertGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		require.NoError(t, os.MkdirAll("testdata", 0o755))
+		require.NoError(t, os.WriteFile(path, got, 0o644))
+	}
+
+	want, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(got))
+}
+
+func TestMimeMessage_Bytes(t *testing.T) {
+	t.Parallel()
+
+	date := time.Date(2025, time.March, 14, 9, 30, 0, 0, time.FixedZone("MSK", 3*60*60))
+	event := models.Event{
+		UID:      "event-1@delayed-notifyer",
+		Summary:  "Планирование спринта, команда; уведомлений",
+		Start:    time.Date(2025, time.March, 14, 10, 0, 0, 0, time.FixedZone("MSK", 3*60*60)),
+		End:      time.Date(2025, time.March, 14, 11, 0, 0, 0, time.FixedZone("MSK", 3*60*60)),
+		Location: "Переговорная 3, Москва",
+		Method:   models.EventRequest,
+	}
+	cancelled := event
+	cancelled.Method = models.EventCancel
+	cancelled.Sequence = 1
+
+	tests := []struct {
+		name string
+		msg  mimeMessage
+	}{
+		{
+			name: "plain_ascii",
+			msg: mimeMessage{
+				from:    mail.Address{Address: "noreply@example.com"},
+				to:      mail.Address{Address: "user@example.com"},
+				subject: "Reminder",
+				text:    "Don't forget the meeting at 10:00.",
+			},
+		},
+		{
+			name: "cyrillic_with_display_name",
+			msg: mimeMessage{
+				from:    mail.Address{Name: "Сервис уведомлений", Address: "noreply@example.com"},
+				to:      mail.Address{Address: "user@example.com"},
+				subject: "Напоминание о встрече",
+				text:    "Встреча начнется через 15 минут.\nСсылка: https://example.com/meet?id=1&lang=ru",
+			},
+		},
+		{
+			name: "custom_html",
+			msg: mimeMessage{
+				from:    mail.Address{Name: "Delayed Notifier", Address: "noreply@example.com"},
+				to:      mail.Address{Address: "user@example.com"},
+				subject: "Invoice is ready",
+				text:    "Your invoice is ready.",
+				html:    `<p>Your <b>invoice</b> is ready. Total: 100 &euro;</p>`,
+			},
+		},
+		{
+			name: "attachments_and_inline_image",
+			msg: mimeMessage{
+				from:    mail.Address{Address: "noreply@example.com"},
+				to:      mail.Address{Address: "user@example.com"},
+				subject: "Счет за март",
+				text:    "Счет во вложении.",
+				html:    `<p>Счет во вложении.</p><img src="cid:logo">`,
+				attachments: []models.Attachment{
+					{Filename: "счет.pdf", ContentType: "application/pdf", Data: bytes.Repeat([]byte("%PDF-1.4 "), 10)},
+					{Filename: "logo.png", ContentID: "logo", Data: []byte{0x89, 'P', 'N', 'G'}},
+					{Filename: "notes.txt", Data: []byte("plain notes")},
+				},
+			},
+		},
+		{
+			name: "attachment_only",
+			msg: mimeMessage{
+				from:        mail.Address{Address: "noreply@example.com"},
+				to:          mail.Address{Address: "user@example.com"},
+				subject:     "Report",
+				text:        "See attached.",
+				attachments: []models.Attachment{{Filename: "report.csv", ContentType: "text/csv; charset=utf-8", Data: []byte("a,b\n1,2\n")}},
+			},
+		},
+		{
+			name: "event_request",
+			msg: mimeMessage{
+				from:    mail.Address{Name: "Сервис уведомлений", Address: "noreply@example.com"},
+				to:      mail.Address{Address: "user@example.com"},
+				subject: "Напоминание о встрече",
+				text:    "Встреча начнется через 30 минут.",
+				event:   &event,
+			},
+		},
+		{
+			name: "event_cancel",
+			msg: mimeMessage{
+				from:    mail.Address{Address: "noreply@example.com"},
+				to:      mail.Address{Address: "user@example.com"},
+				subject: "Встреча отменена",
+				text:    "Встреча отменена.",
+				event:   &cancelled,
+			},
+		},
+		{
+			name: "list_unsubscribe",
+			msg: mimeMessage{
+				from:        mail.Address{Address: "noreply@example.com"},
+				to:          mail.Address{Address: "user@example.com"},
+				subject:     "Weekly digest",
+				text:        "Nothing new this week.",
+				unsubscribe: "https://notify.example.com/preferences/user-1.c2lnbmF0dXJl/unsubscribe?category=digest",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tt.msg.date = date
+			tt.msg.messageID = "<" + tt.name + "@example.com>"
+			tt.msg.boundary = "boundary-" + tt.name
+
+			got, err := tt.msg.bytes()
+			require.NoError(t, err)
+			assertGolden(t, tt.name, got)
+		})
+	}
+}
+
+func TestMimeMessage_Bytes_InvalidContentID(t *testing.T) {
+	t.Parallel()
+
+	msg := mimeMessage{
+		from:        mail.Address{Address: "noreply@example.com"},
+		to:          mail.Address{Address: "user@example.com"},
+		text:        "hello",
+		html:        `<img src="cid:logo">`,
+		attachments: []models.Attachment{{Filename: "logo.png", ContentID: "logo>\r\nBcc: victim@example.com", Data: []byte("x")}},
+	}
+
+	_, err := msg.bytes()
+	assert.ErrorContains(t, err, "invalid content id")
+}
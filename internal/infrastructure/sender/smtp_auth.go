@@ -0,0 +1,67 @@
+package sender
+
+import (
+	"errors"
+	"fmt"
+	"net/smtp"
+	"strings"
+)
+
+// loginAuth реализует механизм AUTH LOGIN, которого нет в net/smtp.
+// Как и smtp.PlainAuth, отправляет учетные данные только по TLS или на localhost.
+type loginAuth struct {
+	username string
+	password string
+	host     string
+}
+
+// newLoginAuth создает новый loginAuth.
+func newLoginAuth(username, password, host string) smtp.Auth {
+	return &loginAuth{username: username, password: password, host: host}
+}
+
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS && !isLocalhost(server.Name) {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	if server.Name != a.host {
+		return "", nil, errors.New("wrong host name")
+	}
+	return "LOGIN", nil, nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+
+	switch strings.ToLower(strings.TrimSpace(string(fromServer))) {
+	case "username:":
+		return []byte(a.username), nil
+	case "password:":
+		return []byte(a.password), nil
+	default:
+		return nil, fmt.Errorf("unexpected server challenge: %q", fromServer)
+	}
+}
+
+func isLocalhost(name string) bool {
+	return name == "localhost" || name == "127.0.0.1" || name == "::1"
+}
+
+// newSMTPAuth возвращает механизм аутентификации по его названию из конфига.
+// Для "none" и пустого значения аутентификация не используется.
+func newSMTPAuth(mechanism, username, password, host string) (smtp.Auth, error) {
+	switch strings.ToLower(mechanism) {
+	case "", "none":
+		return nil, nil
+	case "plain":
+		return smtp.PlainAuth("", username, password, host), nil
+	case "login":
+		return newLoginAuth(username, password, host), nil
+	case "cram-md5":
+		return smtp.CRAMMD5Auth(username, password), nil
+	default:
+		return nil, fmt.Errorf("unknown smtp auth mechanism %q", mechanism)
+	}
+}
@@ -0,0 +1,148 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"time"
+
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
+)
+
+// smtpConn соединение с SMTP сервером, уже прошедшее TLS и аутентификацию.
+type smtpConn struct {
+	conn     net.Conn
+	client   *smtp.Client
+	lastUsed time.Time
+}
+
+// transmit передает одно письмо в рамках открытой сессии.
+func (sc *smtpConn) transmit(from string, to []string, msg []byte) error {
+	if err := sc.client.Mail(from); err != nil {
+		return err
+	}
+	for _, addr := range to {
+		if err := sc.client.Rcpt(addr); err != nil {
+			return rejection(err)
+		}
+	}
+
+	w, err := sc.client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	return rejection(w.Close())
+}
+
+// rejection оборачивает окончательный отказ сервера (код 5xx) в адресе или письме в *models.RecipientError.
+// Временные отказы 4xx остаются ошибками сервера.
+func rejection(err error) error {
+	var tpErr *textproto.Error
+	if errors.As(err, &tpErr) && tpErr.Code >= 500 {
+		return &models.RecipientError{Err: err}
+	}
+	return err
+}
+
+// close закрывает соединение без обмена командами.
+func (sc *smtpConn) close() {
+	_ = sc.client.Close()
+}
+
+// smtpPool переиспользует соединения с SMTP сервером между письмами.
+// Число одновременно открытых соединений ограничено размером пула.
+type smtpPool struct {
+	dial        func(ctx context.Context) (*smtpConn, error)
+	idleTimeout time.Duration // простаивающие дольше соединения не переиспользуются
+
+	slots chan struct{}  // занятые слоты, по одному на используемое соединение
+	idle  chan *smtpConn // свободные соединения
+}
+
+// newSMTPPool создает новый smtpPool.
+func newSMTPPool(size int, idleTimeout time.Duration, dial func(ctx context.Context) (*smtpConn, error)) *smtpPool {
+	if size < 1 {
+		size = 1
+	}
+
+	return &smtpPool{
+		dial:        dial,
+		idleTimeout: idleTimeout,
+		slots:       make(chan struct{}, size),
+		idle:        make(chan *smtpConn, size),
+	}
+}
+
+// get возвращает свободное соединение или открывает новое, если свободных нет.
+// Блокируется, пока все соединения пула заняты.
+func (p *smtpPool) get(ctx context.Context) (*smtpConn, error) {
+	select {
+	case p.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	for {
+		select {
+		case sc := <-p.idle:
+			if p.idleTimeout > 0 && time.Since(sc.lastUsed) > p.idleTimeout {
+				sc.close()
+				continue
+			}
+
+			// сервер мог закрыть соединение, пока оно простаивало
+			if deadline, ok := ctx.Deadline(); ok {
+				_ = sc.conn.SetDeadline(deadline)
+			}
+			if err := sc.client.Reset(); err != nil {
+				sc.close()
+				continue
+			}
+			return sc, nil
+		default:
+			sc, err := p.dial(ctx)
+			if err != nil {
+				<-p.slots
+				return nil, err
+			}
+			return sc, nil
+		}
+	}
+}
+
+// put возвращает соединение в пул. Соединения с ошибками закрываются.
+func (p *smtpPool) put(sc *smtpConn, reusable bool) {
+	defer func() { <-p.slots }()
+
+	if !reusable {
+		sc.close()
+		return
+	}
+
+	_ = sc.conn.SetDeadline(time.Time{})
+	sc.lastUsed = time.Now()
+	select {
+	case p.idle <- sc:
+	default:
+		sc.close()
+	}
+}
+
+// close корректно завершает сессии всех свободных соединений.
+func (p *smtpPool) close() {
+	for {
+		select {
+		case sc := <-p.idle:
+			_ = sc.conn.SetDeadline(time.Now().Add(5 * time.Second))
+			_ = sc.client.Quit()
+			sc.close()
+		default:
+			return
+		}
+	}
+}
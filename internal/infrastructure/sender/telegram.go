@@ -2,49 +2,253 @@ package sender
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
+	dnmodels "github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
 )
 
+type acknowledger interface {
+	Acknowledge(ctx context.Context, token, recipient, by string) (dnmodels.Acknowledgement, error)
+}
+
+type snoozer interface {
+	Snooze(ctx context.Context, token string, d time.Duration) (string, time.Time, error)
+}
+
+// TelegramConfig описывает подключение к боту.
+type TelegramConfig struct {
+	Token string
+
+	// адрес, на который телеграм присылает обновления; если не задан, они забираются long polling
+	WebhookURL    string
+	WebhookSecret string // телеграм передает его в заголовке X-Telegram-Bot-Api-Secret-Token
+
+	Throttle     *Throttle // общий лимит отправки, Bot API принимает около 30 сообщений в секунду
+	ChatThrottle *Throttle // лимит отправки в один чат, около 1 сообщения в секунду
+}
+
 // Telegram определяет отправщик сообщений через телеграм-канал.
 type Telegram struct {
-	Bot *bot.Bot
+	Bot       *bot.Bot
+	acks      acknowledger  // принимает подтверждения получения по кнопке уведомления
+	snoozes   snoozer       // откладывает уведомления по кнопке "отложить"
+	reminders chatReminders // управляет уведомлениями чата по командам бота
+
+	webhookURL    string
+	webhookSecret string
+
+	throttle     *Throttle
+	chatThrottle *Throttle
+}
+
+// telegramWorkers сколько обновлений бот обрабатывает одновременно.
+const telegramWorkers = 8
+
+// NewTelegram создает новый Telegram. Доступность телеграма при этом не проверяется, см. Ping.
+// Обновления обрабатываются воркерами бота, поэтому Start возвращается, только когда начатые обработчики завершены.
+func NewTelegram(cfg TelegramConfig, acks acknowledger, snoozes snoozer, reminders chatReminders) (*Telegram, error) {
+	b, err := bot.New(cfg.Token, bot.WithSkipGetMe(), bot.WithWorkers(telegramWorkers), bot.WithNotAsyncHandlers(),
+		bot.WithMiddlewares(finishOnStop))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create telegram bot: %w", err)
+	}
+
+	return &Telegram{
+		Bot:           b,
+		acks:          acks,
+		snoozes:       snoozes,
+		reminders:     reminders,
+		webhookURL:    cfg.WebhookURL,
+		webhookSecret: cfg.WebhookSecret,
+		throttle:      cfg.Throttle,
+		chatThrottle:  cfg.ChatThrottle,
+	}, nil
+}
+
+// Ping проверяет, что телеграм доступен и принимает токен бота.
+func (t *Telegram) Ping(ctx context.Context) error {
+	_, err := t.Bot.GetMe(ctx)
+	return err
 }
 
-// NewTelegram создает новый Telegram.
-func NewTelegram(token string) (*Telegram, error) {
-	b, err := bot.New(token)
+// RegisterWebhook устанавливает вебхук бота, а в режиме long polling снимает его,
+// потому что пока вебхук установлен, телеграм не отдает обновления через getUpdates.
+// Реплики устанавливают один и тот же вебхук, так что повторный вызов безопасен.
+func (t *Telegram) RegisterWebhook(ctx context.Context) error {
+	if t.webhookURL == "" {
+		_, err := t.Bot.DeleteWebhook(ctx, &bot.DeleteWebhookParams{})
+		return err
+	}
 
-	return &Telegram{Bot: b}, err
+	_, err := t.Bot.SetWebhook(ctx, &bot.SetWebhookParams{URL: t.webhookURL, SecretToken: t.webhookSecret})
+	return err
 }
 
-// Start запускает работу телеграм отправщика.
+// Start запускает работу телеграм отправщика и возвращается после отмены ctx. В режиме вебхука обновления
+// принимаются обработчиком Bot.WebhookHandler, который нужно подключить к http серверу.
 func (t *Telegram) Start(ctx context.Context) {
 	t.Bot.RegisterHandler(bot.HandlerTypeMessageText, "/start", bot.MatchTypeExact, func(ctx context.Context, b *bot.Bot, update *models.Update) {
 		chatID := update.Message.Chat.ID
 		_, err := b.SendMessage(ctx, &bot.SendMessageParams{
 			ChatID: chatID,
-			Text: fmt.Sprintf(`Ваш чат айди - %d. 
-			Используйте его, если хотите получать уведомления через телеграм-канал.`, chatID),
+			Text:   fmt.Sprintf(startText, chatID),
 		})
 		if err != nil {
 			return
 		}
 	})
 
+	t.registerCommands(ctx)
+
+	// обработчики проверяются по порядку регистрации, поэтому подтверждение регистрируется раньше общего
+	t.Bot.RegisterHandler(bot.HandlerTypeCallbackQueryData, dnmodels.AckCallbackPrefix, bot.MatchTypePrefix, t.handleAck)
+	t.Bot.RegisterHandler(bot.HandlerTypeCallbackQueryData, dnmodels.SnoozeCallbackPrefix, bot.MatchTypePrefix, t.handleSnoozeButton)
+
+	// остальные callback кнопки уведомлений ничего не делают, но ответ нужен, чтобы у пользователя пропал индикатор загрузки
+	t.Bot.RegisterHandler(bot.HandlerTypeCallbackQueryData, "", bot.MatchTypePrefix, func(ctx context.Context, b *bot.Bot, update *models.Update) {
+		_, _ = b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{CallbackQueryID: update.CallbackQuery.ID})
+	})
+
+	if t.webhookURL != "" {
+		t.Bot.StartWebhook(ctx)
+		return
+	}
+
 	t.Bot.Start(ctx)
 }
 
-// Send отправляет сообщение на указанный chatID.
-func (t *Telegram) Send(chatID string, data string) error {
-	_, err := t.Bot.SendMessage(context.Background(), &bot.SendMessageParams{ChatID: chatID, Text: data})
+// finishOnStop доводит обработку начатого обновления до конца, даже если бот уже останавливается.
+func finishOnStop(next bot.HandlerFunc) bot.HandlerFunc {
+	return func(ctx context.Context, b *bot.Bot, update *models.Update) {
+		next(context.WithoutCancel(ctx), b, update)
+	}
+}
+
+// handleAck сохраняет подтверждение получения уведомления от нажавшего кнопку пользователя.
+func (t *Telegram) handleAck(ctx context.Context, b *bot.Bot, update *models.Update) {
+	query := update.CallbackQuery
+	token := strings.TrimPrefix(query.Data, dnmodels.AckCallbackPrefix)
+
+	by := fmt.Sprintf("telegram:%d", query.From.ID)
+	if query.From.Username != "" {
+		by += " (@" + query.From.Username + ")"
+	}
+
+	text := "Получение подтверждено"
+	if _, err := t.acks.Acknowledge(ctx, token, "", by); err != nil {
+		text = "Не удалось подтвердить получение"
+	}
+
+	_, _ = b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{CallbackQueryID: query.ID, Text: text})
+}
+
+// handleSnoozeButton откладывает уведомление на время, указанное в нажатой кнопке.
+func (t *Telegram) handleSnoozeButton(ctx context.Context, b *bot.Bot, update *models.Update) {
+	query := update.CallbackQuery
+	seconds, token, _ := strings.Cut(strings.TrimPrefix(query.Data, dnmodels.SnoozeCallbackPrefix), ":")
+
+	text := "Не удалось отложить уведомление"
+	if n, err := strconv.Atoi(seconds); err == nil {
+		if _, sendAt, err := t.snoozes.Snooze(ctx, token, time.Duration(n)*time.Second); err == nil {
+			text = "Напомню " + sendAt.Format(sendAtLayout)
+		}
+	}
+
+	_, _ = b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{CallbackQueryID: query.ID, Text: text})
+}
+
+// Send отправляет сообщение в указанный в нем чат, дождавшись очереди в лимитах Bot API.
+// Фото и документ отправляются по ссылке, текст при этом становится подписью.
+// Запрос прерывается при отмене контекста. На ответ 429 возвращается *dnmodels.RetryAfterError
+// со временем из retry_after, а на 400 и 403 (чата нет, бот заблокирован) - *dnmodels.RecipientError.
+func (t *Telegram) Send(ctx context.Context, message dnmodels.TelegramMessage) error {
+	// сначала общий лимит: токен чата, взятый до ожидания общей очереди, пропал бы,
+	// если бы сообщение так и не дождалось ее и было отложено
+	if err := t.throttle.Wait(ctx, ""); err != nil {
+		return err
+	}
+	if err := t.chatThrottle.Wait(ctx, message.ChatID); err != nil {
+		return err
+	}
+
+	var (
+		parseMode = models.ParseMode(message.ParseMode)
+		markup    = inlineKeyboard(message.Buttons)
+		err       error
+	)
+
+	switch {
+	case message.PhotoURL != "":
+		_, err = t.Bot.SendPhoto(ctx, &bot.SendPhotoParams{
+			ChatID:              message.ChatID,
+			MessageThreadID:     message.MessageThreadID,
+			Photo:               &models.InputFileString{Data: message.PhotoURL},
+			Caption:             message.Text,
+			ParseMode:           parseMode,
+			DisableNotification: message.Silent,
+			ReplyMarkup:         markup,
+		})
+	case message.DocumentURL != "":
+		_, err = t.Bot.SendDocument(ctx, &bot.SendDocumentParams{
+			ChatID:              message.ChatID,
+			MessageThreadID:     message.MessageThreadID,
+			Document:            &models.InputFileString{Data: message.DocumentURL},
+			Caption:             message.Text,
+			ParseMode:           parseMode,
+			DisableNotification: message.Silent,
+			ReplyMarkup:         markup,
+		})
+	default:
+		_, err = t.Bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:              message.ChatID,
+			MessageThreadID:     message.MessageThreadID,
+			Text:                message.Text,
+			ParseMode:           parseMode,
+			DisableNotification: message.Silent,
+			ReplyMarkup:         markup,
+		})
+	}
+
+	var tooMany *bot.TooManyRequestsError
+	if errors.As(err, &tooMany) {
+		return &dnmodels.RetryAfterError{After: time.Duration(tooMany.RetryAfter) * time.Second, Err: err}
+	}
+	if errors.Is(err, bot.ErrorBadRequest) || errors.Is(err, bot.ErrorForbidden) {
+		return &dnmodels.RecipientError{Err: err}
+	}
 	return err
 }
 
+// inlineKeyboard собирает inline клавиатуру из кнопок уведомления.
+func inlineKeyboard(buttons [][]dnmodels.TelegramButton) models.ReplyMarkup {
+	if len(buttons) == 0 {
+		return nil
+	}
+
+	keyboard := make([][]models.InlineKeyboardButton, len(buttons))
+	for i, row := range buttons {
+		keyboard[i] = make([]models.InlineKeyboardButton, len(row))
+		for j, b := range row {
+			keyboard[i][j] = models.InlineKeyboardButton{Text: b.Text, URL: b.URL, CallbackData: b.CallbackData}
+		}
+	}
+
+	return &models.InlineKeyboardMarkup{InlineKeyboard: keyboard}
+}
+
 // Stop делает попытку закрыть соединение.
+// В режиме вебхука закрывать нечего, а сам вебхук остается другим репликам.
 func (t *Telegram) Stop(ctx context.Context) error {
+	if t.webhookURL != "" {
+		return nil
+	}
+
 	_, err := t.Bot.Close(ctx)
 	return err
 }
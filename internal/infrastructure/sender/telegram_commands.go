@@ -0,0 +1,176 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/internal/usecase"
+	dnmodels "github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+type chatReminders interface {
+	ScheduleChatReminder(ctx context.Context, chatID, phrase string) (dnmodels.ChatNotification, error)
+	ListChatNotifications(ctx context.Context, chatID string) ([]dnmodels.ChatNotification, error)
+	CancelChatNotification(ctx context.Context, chatID, uid string) error
+	SnoozeChatNotification(ctx context.Context, chatID, uid, phrase string) (string, time.Time, error)
+	MuteChat(ctx context.Context, chatID string) error
+	UnmuteChat(ctx context.Context, chatID string) error
+}
+
+const (
+	sendAtLayout        = "02.01.2006 15:04"
+	listTextMaxLength   = 50 // текст уведомления в /list обрезается
+	reminderUsageText   = "Не понял, когда напомнить. Примеры: /remind 30m позвонить маме, /remind через 2 часа созвон, /remind завтра в 10:00 отчет"
+	snoozeUsageText     = "Использование: /snooze <айди> <когда>, например /snooze <айди> 1h или /snooze <айди> завтра в 9:00"
+	cancelUsageText     = "Использование: /cancel <айди>"
+	notFoundText        = "Уведомление не найдено или уже отправлено"
+	commandFailedText   = "Не удалось выполнить команду, попробуйте позже"
+	emptyListText       = "Нет запланированных уведомлений"
+	mutedText           = "Уведомления в этот чат выключены. Включить снова - /unmute"
+	unmutedText         = "Уведомления в этот чат включены"
+	startText           = "Ваш чат айди - %d.\nИспользуйте его, если хотите получать уведомления через телеграм-канал.\n\n" + helpText
+	helpText            = "Команды:\n/remind <когда> <текст> - напомнить\n/list - запланированные уведомления\n/cancel <айди> - отменить\n/snooze <айди> <когда> - отложить\n/mute, /unmute - выключить и включить уведомления в чат"
+	reminderCreatedText = "Напомню %s: %s\nАйди: %s"
+	snoozedText         = "Уведомление отложено до %s"
+	snoozedAgainText    = "Напомню снова %s\nАйди: %s"
+	cancelledText       = "Уведомление отменено"
+)
+
+// botCommands команды бота, показываемые в меню телеграм.
+var botCommands = []models.BotCommand{
+	{Command: "remind", Description: "Напомнить: /remind 30m позвонить маме"},
+	{Command: "list", Description: "Запланированные уведомления"},
+	{Command: "cancel", Description: "Отменить уведомление"},
+	{Command: "snooze", Description: "Отложить уведомление"},
+	{Command: "mute", Description: "Выключить уведомления в чат"},
+	{Command: "unmute", Description: "Включить уведомления в чат"},
+}
+
+// registerCommands регистрирует обработчики команд управления уведомлениями чата.
+func (t *Telegram) registerCommands(ctx context.Context) {
+	handlers := map[string]func(ctx context.Context, chatID, args string) string{
+		"help":   func(context.Context, string, string) string { return helpText },
+		"remind": t.handleRemind,
+		"list":   t.handleList,
+		"cancel": t.handleCancel,
+		"snooze": t.handleSnooze,
+		"mute":   t.handleMute,
+		"unmute": t.handleUnmute,
+	}
+
+	for command, handle := range handlers {
+		t.Bot.RegisterHandler(bot.HandlerTypeMessageText, command, bot.MatchTypeCommandStartOnly, func(ctx context.Context, b *bot.Bot, update *models.Update) {
+			chatID := update.Message.Chat.ID
+			_, args, _ := strings.Cut(update.Message.Text, " ")
+
+			_, _ = b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID: chatID,
+				Text:   handle(ctx, strconv.FormatInt(chatID, 10), strings.TrimSpace(args)),
+			})
+		})
+	}
+
+	_, _ = t.Bot.SetMyCommands(ctx, &bot.SetMyCommandsParams{Commands: botCommands})
+}
+
+// handleRemind обрабатывает /remind <когда> <текст>.
+func (t *Telegram) handleRemind(ctx context.Context, chatID, args string) string {
+	reminder, err := t.reminders.ScheduleChatReminder(ctx, chatID, args)
+	if errors.Is(err, usecase.ErrInvalidReminder) {
+		return reminderUsageText
+	}
+	if err != nil {
+		return commandFailedText
+	}
+
+	return fmt.Sprintf(reminderCreatedText, reminder.SendAt.Format(sendAtLayout), reminder.Text, reminder.ID)
+}
+
+// handleList обрабатывает /list.
+func (t *Telegram) handleList(ctx context.Context, chatID, _ string) string {
+	notifications, err := t.reminders.ListChatNotifications(ctx, chatID)
+	if err != nil {
+		return commandFailedText
+	}
+	if len(notifications) == 0 {
+		return emptyListText
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Запланированные уведомления:")
+	for _, n := range notifications {
+		text := n.Text
+		if n.TemplateID != "" {
+			text = "шаблон " + n.TemplateID
+		}
+		if runes := []rune(text); len(runes) > listTextMaxLength {
+			text = string(runes[:listTextMaxLength]) + "…"
+		}
+		fmt.Fprintf(&sb, "\n\n%s - %s\n%s", n.SendAt.Format(sendAtLayout), text, n.ID)
+	}
+
+	return sb.String()
+}
+
+// handleCancel обрабатывает /cancel <айди>.
+func (t *Telegram) handleCancel(ctx context.Context, chatID, args string) string {
+	if args == "" || strings.ContainsRune(args, ' ') {
+		return cancelUsageText
+	}
+
+	err := t.reminders.CancelChatNotification(ctx, chatID, args)
+	if errors.Is(err, usecase.ErrChatNotificationNotFound) {
+		return notFoundText
+	}
+	if err != nil {
+		return commandFailedText
+	}
+
+	return cancelledText
+}
+
+// handleSnooze обрабатывает /snooze <айди> <когда>.
+func (t *Telegram) handleSnooze(ctx context.Context, chatID, args string) string {
+	uid, when, ok := strings.Cut(args, " ")
+	if !ok {
+		return snoozeUsageText
+	}
+
+	snoozedID, sendAt, err := t.reminders.SnoozeChatNotification(ctx, chatID, uid, when)
+	switch {
+	case errors.Is(err, usecase.ErrInvalidReminder):
+		return snoozeUsageText
+	case errors.Is(err, usecase.ErrChatNotificationNotFound):
+		return notFoundText
+	case err != nil:
+		return commandFailedText
+	}
+
+	if snoozedID != uid {
+		// уведомление уже было отправлено и запланировано заново
+		return fmt.Sprintf(snoozedAgainText, sendAt.Format(sendAtLayout), snoozedID)
+	}
+	return fmt.Sprintf(snoozedText, sendAt.Format(sendAtLayout))
+}
+
+// handleMute обрабатывает /mute.
+func (t *Telegram) handleMute(ctx context.Context, chatID, _ string) string {
+	if err := t.reminders.MuteChat(ctx, chatID); err != nil {
+		return commandFailedText
+	}
+	return mutedText
+}
+
+// handleUnmute обрабатывает /unmute.
+func (t *Telegram) handleUnmute(ctx context.Context, chatID, _ string) string {
+	if err := t.reminders.UnmuteChat(ctx, chatID); err != nil {
+		return commandFailedText
+	}
+	return unmutedText
+}
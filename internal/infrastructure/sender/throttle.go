@@ -0,0 +1,66 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
+)
+
+// throttleMaxWait дольше воркер не ждет пропускной способности провайдера:
+// уведомление откладывается, чтобы не занимать воркер.
+const throttleMaxWait = 5 * time.Second
+
+var errThrottled = errors.New("provider throughput exceeded")
+
+type tokenBucket interface {
+	TakeToken(ctx context.Context, key string, capacity int, window time.Duration) (time.Duration, error)
+}
+
+// Throttle ограничивает число отправок через провайдера в секунду. Счет ведется корзиной токенов
+// в хранилище, поэтому ограничение общее для всех экземпляров сервиса.
+type Throttle struct {
+	buckets   tokenBucket
+	name      string
+	perSecond int
+}
+
+// NewThrottle создает новый Throttle на perSecond отправок в секунду. При perSecond <= 0 отправки не ограничиваются.
+func NewThrottle(buckets tokenBucket, name string, perSecond int) *Throttle {
+	return &Throttle{buckets: buckets, name: name, perSecond: perSecond}
+}
+
+// Wait ждет своей очереди на отправку, общей или по ключу key (например, чату), если он задан.
+// Если очередь не подошла за throttleMaxWait, возвращает *models.RetryAfterError.
+// Nil Throttle ничего не ограничивает.
+func (t *Throttle) Wait(ctx context.Context, key string) error {
+	if t == nil || t.perSecond <= 0 {
+		return nil
+	}
+
+	bucket := "throughput:" + t.name
+	if key != "" {
+		bucket += ":" + key
+	}
+
+	deadline := time.Now().Add(throttleMaxWait)
+	for {
+		wait, err := t.buckets.TakeToken(ctx, bucket, t.perSecond, time.Second)
+		if err != nil || wait <= 0 {
+			// при ошибке хранилища лучше отправить, провайдер в худшем случае ответит 429
+			return nil
+		}
+		if time.Now().Add(wait).After(deadline) {
+			return &models.RetryAfterError{After: wait, Err: errThrottled}
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
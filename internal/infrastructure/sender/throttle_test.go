@@ -0,0 +1,70 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBuckets возвращает заданные ожидания по очереди, а затем пропускает.
+type fakeBuckets struct {
+	waits []time.Duration
+	err   error
+	keys  []string
+}
+
+func (f *fakeBuckets) TakeToken(_ context.Context, key string, capacity int, window time.Duration) (time.Duration, error) {
+	f.keys = append(f.keys, key)
+	if f.err != nil || len(f.waits) == 0 {
+		return 0, f.err
+	}
+	wait := f.waits[0]
+	f.waits = f.waits[1:]
+	return wait, nil
+}
+
+func TestThrottle_Wait(t *testing.T) {
+	t.Parallel()
+
+	t.Run("waits_for_token", func(t *testing.T) {
+		buckets := &fakeBuckets{waits: []time.Duration{20 * time.Millisecond}}
+		start := time.Now()
+
+		require.NoError(t, NewThrottle(buckets, "telegram.chat", 1).Wait(context.Background(), "123456"))
+		assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+		assert.Equal(t, []string{"throughput:telegram.chat:123456", "throughput:telegram.chat:123456"}, buckets.keys)
+	})
+
+	t.Run("too_long_to_wait", func(t *testing.T) {
+		buckets := &fakeBuckets{waits: []time.Duration{time.Minute}}
+
+		err := NewThrottle(buckets, "email", 10).Wait(context.Background(), "")
+		var retryErr *models.RetryAfterError
+		require.ErrorAs(t, err, &retryErr)
+		assert.Equal(t, time.Minute, retryErr.After)
+		assert.Equal(t, []string{"throughput:email"}, buckets.keys)
+	})
+
+	t.Run("context_canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := NewThrottle(&fakeBuckets{waits: []time.Duration{time.Second}}, "email", 10).Wait(ctx, "")
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("storage_error_passes", func(t *testing.T) {
+		require.NoError(t, NewThrottle(&fakeBuckets{err: errors.New("redis down")}, "email", 10).Wait(context.Background(), ""))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		var throttle *Throttle
+		require.NoError(t, throttle.Wait(context.Background(), ""))
+		require.NoError(t, NewThrottle(&fakeBuckets{waits: []time.Duration{time.Minute}}, "email", 0).Wait(context.Background(), ""))
+	})
+}
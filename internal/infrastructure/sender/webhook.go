@@ -0,0 +1,68 @@
+package sender
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/internal/infrastructure/netguard"
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
+)
+
+// Webhook определяет отправщик уведомлений POST запросом с JSON.
+type Webhook struct {
+	client *http.Client
+}
+
+// NewWebhook создает новый Webhook. Без allowPrivate запросы во внутреннюю сеть сервиса не отправляются.
+func NewWebhook(timeout time.Duration, allowPrivate bool) *Webhook {
+	return &Webhook{client: netguard.NewHTTPClient(timeout, allowPrivate)}
+}
+
+// Ping ничего не проверяет: адрес у каждого уведомления свой.
+func (w *Webhook) Ping(context.Context) error {
+	return nil
+}
+
+// Send отправляет сообщение на адрес вебхука. Ответ не из 2xx считается ошибкой,
+// а ответ 4xx, кроме 408 и 429, и адрес во внутренней сети - ошибкой получателя *models.RecipientError.
+func (w *Webhook) Send(ctx context.Context, message models.WebhookMessage) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, message.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if errors.Is(err, netguard.ErrPrivateAddress) {
+		return &models.RecipientError{Err: err}
+	}
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// тело дочитывается, чтобы соединение вернулось в пул
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		err := fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+			resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+			return &models.RecipientError{Err: err}
+		}
+		return err
+	}
+
+	return nil
+}
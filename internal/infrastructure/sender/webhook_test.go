@@ -0,0 +1,73 @@
+package sender
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/internal/infrastructure/netguard"
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhook_Send(t *testing.T) {
+	t.Parallel()
+
+	var got map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		switch r.URL.Path {
+		case "/fail":
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		case "/gone":
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+	}))
+	defer srv.Close()
+
+	wh := NewWebhook(time.Second, true)
+
+	t.Run("success", func(t *testing.T) {
+		err := wh.Send(context.Background(), models.WebhookMessage{
+			URL:            srv.URL + "/hook",
+			NotificationID: "test",
+			Text:           "Сервер недоступен",
+			AckURL:         "https://notify.example.com/ack/t",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{
+			"id":           "test",
+			"notification": "Сервер недоступен",
+			"ack_url":      "https://notify.example.com/ack/t",
+		}, got)
+	})
+
+	t.Run("error_status", func(t *testing.T) {
+		err := wh.Send(context.Background(), models.WebhookMessage{URL: srv.URL + "/fail", NotificationID: "test"})
+		assert.ErrorContains(t, err, "502")
+		var recipientErr *models.RecipientError
+		assert.False(t, errors.As(err, &recipientErr))
+	})
+
+	t.Run("recipient_error", func(t *testing.T) {
+		err := wh.Send(context.Background(), models.WebhookMessage{URL: srv.URL + "/gone", NotificationID: "test"})
+		var recipientErr *models.RecipientError
+		assert.ErrorAs(t, err, &recipientErr)
+	})
+
+	t.Run("private_address", func(t *testing.T) {
+		err := NewWebhook(time.Second, false).Send(context.Background(), models.WebhookMessage{URL: srv.URL + "/hook", NotificationID: "test"})
+		assert.ErrorIs(t, err, netguard.ErrPrivateAddress)
+		var recipientErr *models.RecipientError
+		assert.ErrorAs(t, err, &recipientErr)
+	})
+}
@@ -0,0 +1,169 @@
+package usecase
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
+)
+
+// ackSignatureBytes длина подписи токена: токен вместе с префиксом должен уместиться
+// в 64 байта callback_data кнопки телеграм.
+const ackSignatureBytes = 16
+
+// ErrInvalidAckToken возвращается, если токен подтверждения подделан или поврежден.
+var ErrInvalidAckToken = errors.New("invalid acknowledgement token")
+
+type ackStorage interface {
+	Add(ctx context.Context, key string, value interface{}, exp time.Duration) error
+	AddIfAbsent(ctx context.Context, key string, value interface{}, exp time.Duration) (bool, error)
+	Get(ctx context.Context, key string) (string, error)
+	Remove(ctx context.Context, key string) error
+	SortedSetRemove(ctx context.Context, set string, value interface{}) error
+	ListPushCapped(ctx context.Context, key string, value interface{}, size int64, exp time.Duration) error
+}
+
+// AckManager выдает подписанные токены подтверждения получения уведомлений
+// и сохраняет подтверждения, останавливая повторные напоминания и эскалацию.
+type AckManager struct {
+	storage        ackStorage
+	secret         []byte // ключ HMAC подписи токенов
+	baseURL        string // публичный адрес сервиса для ссылок подтверждения
+	delayedSetName string
+}
+
+// NewAckManager создает новый AckManager.
+func NewAckManager(storage ackStorage, secret []byte, baseURL, delayedSetName string) *AckManager {
+	return &AckManager{
+		storage:        storage,
+		secret:         secret,
+		baseURL:        strings.TrimRight(baseURL, "/"),
+		delayedSetName: delayedSetName,
+	}
+}
+
+// AckLink возвращает ссылку подтверждения для получателя: адреса email или вебхука.
+func (am *AckManager) AckLink(uid, recipient string) string {
+	return am.baseURL + "/ack/" + am.token(uid, recipient) + "?to=" + url.QueryEscape(recipient)
+}
+
+// AckCallbackData возвращает callback_data кнопки подтверждения в телеграм.
+func (am *AckManager) AckCallbackData(uid string) string {
+	return models.AckCallbackPrefix + am.token(uid, "")
+}
+
+// token подписывает айди уведомления вместе с получателем, которому выдается токен.
+// Для телеграм получатель пуст: подтвердившего сообщает сам телеграм.
+func (am *AckManager) token(uid, recipient string) string {
+	return uid + "." + am.signature(uid, recipient)
+}
+
+func (am *AckManager) signature(uid, recipient string) string {
+	return hmacSignature(am.secret, uid+"\n"+recipient, ackSignatureBytes)
+}
+
+// hmacSignature подписывает сообщение ключом и возвращает первые size байт подписи в base64url.
+func hmacSignature(secret []byte, message string, size int) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(message))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:size])
+}
+
+// Acknowledge проверяет токен, выданный получателю recipient, и сохраняет подтверждение от by
+// (по умолчанию сам получатель). Повторные напоминания, следующие шаги эскалации
+// и повторные попытки отправки отменяются.
+// Повторное подтверждение не перезаписывает первое и возвращает его.
+func (am *AckManager) Acknowledge(ctx context.Context, token, recipient, by string) (models.Acknowledgement, error) {
+	uid, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(am.signature(uid, recipient))) {
+		return models.Acknowledgement{}, ErrInvalidAckToken
+	}
+
+	if by == "" {
+		by = recipient
+	}
+	ack := models.Acknowledgement{At: time.Now().UTC(), By: by}
+
+	payload, err := json.Marshal(ack)
+	if err != nil {
+		return models.Acknowledgement{}, err
+	}
+
+	// подтверждение сохраняется, только если его еще нет, чтобы одновременные подтверждения не перезаписали первое
+	added, err := am.storage.AddIfAbsent(ctx, "notification.ack:"+uid, payload, 168*time.Hour)
+	if err != nil {
+		return models.Acknowledgement{}, err
+	}
+	if !added {
+		existing, err := am.GetAcknowledgement(ctx, uid)
+		if err != nil {
+			return models.Acknowledgement{}, err
+		}
+		if existing == nil {
+			return models.Acknowledgement{}, fmt.Errorf("acknowledgement of notification %s expired concurrently", uid)
+		}
+		return *existing, nil
+	}
+
+	if err := am.stopRepeats(ctx, uid); err != nil {
+		return models.Acknowledgement{}, err
+	}
+
+	return ack, nil
+}
+
+// stopRepeats убирает подтвержденное уведомление из отложенной очереди.
+// Если поллер уже забрал его, отправщик сам пропустит подтвержденное уведомление.
+func (am *AckManager) stopRepeats(ctx context.Context, uid string) error {
+	status, err := am.storage.Get(ctx, "notification.status:"+uid)
+	if errors.Is(err, models.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !isPending(models.NotificationStatus(status)) {
+		return nil
+	}
+
+	if err := am.storage.SortedSetRemove(ctx, am.delayedSetName, uid); err != nil {
+		return err
+	}
+	if err := am.storage.Remove(ctx, "notification:"+uid); err != nil {
+		return err
+	}
+
+	return setStatus(ctx, am.storage, uid, models.StatusSent, "acknowledged", 168*time.Hour)
+}
+
+// GetAcknowledgement возвращает подтверждение получения уведомления или nil, если его нет.
+func (am *AckManager) GetAcknowledgement(ctx context.Context, uid string) (*models.Acknowledgement, error) {
+	payload, err := am.storage.Get(ctx, "notification.ack:"+uid)
+	if errors.Is(err, models.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var ack models.Acknowledgement
+	if err := json.Unmarshal([]byte(payload), &ack); err != nil {
+		return nil, err
+	}
+
+	return &ack, nil
+}
+
+// IsAcknowledged сообщает, подтверждено ли получение уведомления.
+func (am *AckManager) IsAcknowledged(ctx context.Context, uid string) (bool, error) {
+	ack, err := am.GetAcknowledgement(ctx, uid)
+	return ack != nil, err
+}
@@ -0,0 +1,85 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	mock_usecase "github.com/child6yo/wbtech-l3-delayed-notifyer/internal/usecase/mock"
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testNotificationID = "0b7e0ef5-5d3f-4c1b-9f57-6a3f1c2d9e10"
+
+func TestAckManager_Tokens(t *testing.T) {
+	t.Parallel()
+
+	manager := NewAckManager(nil, []byte("secret"), "https://notify.example.com/", "delayed_notifications")
+
+	t.Run("callback_data_fits_telegram_limit", func(t *testing.T) {
+		data := manager.AckCallbackData(testNotificationID)
+		assert.True(t, strings.HasPrefix(data, models.AckCallbackPrefix))
+		assert.LessOrEqual(t, len(data), 64)
+	})
+
+	t.Run("link", func(t *testing.T) {
+		link, err := url.Parse(manager.AckLink(testNotificationID, "user+1@example.com"))
+		require.NoError(t, err)
+		assert.Equal(t, "notify.example.com", link.Host)
+		assert.True(t, strings.HasPrefix(link.Path, "/ack/"+testNotificationID+"."))
+		assert.Equal(t, "user+1@example.com", link.Query().Get("to"))
+	})
+}
+
+func TestAckManager_Acknowledge(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := mock_usecase.NewMockackStorage(ctrl)
+	manager := NewAckManager(mockStorage, []byte("secret"), "https://notify.example.com", "delayed_notifications")
+
+	token := manager.token(testNotificationID, "user@example.com")
+
+	t.Run("stops_repeats", func(t *testing.T) {
+		mockStorage.EXPECT().AddIfAbsent(gomock.Any(), "notification.ack:"+testNotificationID, gomock.Any(), 168*time.Hour).Return(true, nil)
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.status:"+testNotificationID).Return(string(models.StatusAwaitingAck), nil)
+		mockStorage.EXPECT().SortedSetRemove(gomock.Any(), "delayed_notifications", testNotificationID).Return(nil)
+		mockStorage.EXPECT().Remove(gomock.Any(), "notification:"+testNotificationID).Return(nil)
+		mockStorage.EXPECT().Add(gomock.Any(), "notification.status:"+testNotificationID, string(models.StatusSent), 168*time.Hour).Return(nil)
+		mockStorage.EXPECT().ListPushCapped(gomock.Any(), "notification.history:"+testNotificationID, gomock.Any(), gomock.Any(), 168*time.Hour).Return(nil)
+
+		ack, err := manager.Acknowledge(context.Background(), token, "user@example.com", "")
+		require.NoError(t, err)
+		assert.Equal(t, "user@example.com", ack.By)
+	})
+
+	t.Run("already_acknowledged", func(t *testing.T) {
+		first := models.Acknowledgement{At: time.Date(2025, 3, 14, 10, 0, 0, 0, time.UTC), By: "telegram:42"}
+		payload, err := json.Marshal(first)
+		require.NoError(t, err)
+		mockStorage.EXPECT().AddIfAbsent(gomock.Any(), "notification.ack:"+testNotificationID, gomock.Any(), 168*time.Hour).Return(false, nil)
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.ack:"+testNotificationID).Return(string(payload), nil)
+
+		ack, err := manager.Acknowledge(context.Background(), token, "user@example.com", "")
+		require.NoError(t, err)
+		assert.Equal(t, first, ack)
+	})
+
+	t.Run("recipient_mismatch", func(t *testing.T) {
+		_, err := manager.Acknowledge(context.Background(), token, "other@example.com", "")
+		assert.ErrorIs(t, err, ErrInvalidAckToken)
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		_, err := manager.Acknowledge(context.Background(), testNotificationID, "", "")
+		assert.ErrorIs(t, err, ErrInvalidAckToken)
+	})
+}
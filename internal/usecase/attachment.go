@@ -0,0 +1,67 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
+)
+
+type attachmentStorage interface {
+	Get(ctx context.Context, key string) (string, error)
+}
+
+type attachmentFetcher interface {
+	Fetch(ctx context.Context, url string) (data []byte, contentType string, err error)
+}
+
+// AttachmentLoader подгружает содержимое вложений письма в момент отправки:
+// из хранилища, если оно было передано в запросе, или по URL.
+type AttachmentLoader struct {
+	storage  attachmentStorage
+	fetcher  attachmentFetcher
+	maxTotal int64 // ограничение на все вложения письма вместе с загруженными по URL, 0 - без ограничения
+}
+
+// NewAttachmentLoader создает новый AttachmentLoader.
+func NewAttachmentLoader(storage attachmentStorage, fetcher attachmentFetcher, maxTotal int64) *AttachmentLoader {
+	return &AttachmentLoader{storage: storage, fetcher: fetcher, maxTotal: maxTotal}
+}
+
+// Load возвращает копии вложений с заполненным содержимым.
+// Возвращает ошибку, если вместе они больше допустимого размера.
+func (l *AttachmentLoader) Load(ctx context.Context, attachments []models.Attachment) ([]models.Attachment, error) {
+	if len(attachments) == 0 {
+		return nil, nil
+	}
+
+	var total int64
+	loaded := make([]models.Attachment, len(attachments))
+	for i, a := range attachments {
+		switch {
+		case a.StorageKey != "":
+			data, err := l.storage.Get(ctx, a.StorageKey)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load attachment %s: %w", a.Filename, err)
+			}
+			a.Data = []byte(data)
+		case a.URL != "":
+			data, contentType, err := l.fetcher.Fetch(ctx, a.URL)
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch attachment %s: %w", a.Filename, err)
+			}
+			a.Data = data
+			if a.ContentType == "" {
+				a.ContentType = contentType
+			}
+		}
+		loaded[i] = a
+
+		total += int64(len(a.Data))
+		if l.maxTotal > 0 && total > l.maxTotal {
+			return nil, fmt.Errorf("attachments are larger than %d bytes in total", l.maxTotal)
+		}
+	}
+
+	return loaded, nil
+}
@@ -0,0 +1,83 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	mock_usecase "github.com/child6yo/wbtech-l3-delayed-notifyer/internal/usecase/mock"
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttachmentLoader_Load(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := mock_usecase.NewMockattachmentStorage(ctrl)
+	mockFetcher := mock_usecase.NewMockattachmentFetcher(ctrl)
+	loader := NewAttachmentLoader(mockStorage, mockFetcher, 16)
+
+	t.Run("success", func(t *testing.T) {
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.attachment:id:0").Return("stored", nil)
+		mockFetcher.EXPECT().Fetch(gomock.Any(), "https://example.com/logo.png").Return([]byte("fetched"), "image/png", nil)
+
+		attachments := []models.Attachment{
+			{Filename: "notes.txt", StorageKey: "notification.attachment:id:0"},
+			{Filename: "logo.png", URL: "https://example.com/logo.png", ContentID: "logo"},
+		}
+
+		loaded, err := loader.Load(context.Background(), attachments)
+		require.NoError(t, err)
+		require.Len(t, loaded, 2)
+		assert.Equal(t, []byte("stored"), loaded[0].Data)
+		assert.Equal(t, []byte("fetched"), loaded[1].Data)
+		assert.Equal(t, "image/png", loaded[1].ContentType)
+
+		// исходные вложения не меняются
+		assert.Nil(t, attachments[0].Data)
+	})
+
+	t.Run("explicit_content_type_kept", func(t *testing.T) {
+		mockFetcher.EXPECT().Fetch(gomock.Any(), "https://example.com/file").Return([]byte("x"), "application/octet-stream", nil)
+
+		loaded, err := loader.Load(context.Background(), []models.Attachment{
+			{Filename: "invite.ics", URL: "https://example.com/file", ContentType: "text/calendar"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "text/calendar", loaded[0].ContentType)
+	})
+
+	t.Run("storage_error", func(t *testing.T) {
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.attachment:id:0").Return("", errors.New("redis: nil"))
+
+		_, err := loader.Load(context.Background(), []models.Attachment{
+			{Filename: "notes.txt", StorageKey: "notification.attachment:id:0"},
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("total_size_exceeded", func(t *testing.T) {
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.attachment:id:0").Return("stored", nil)
+		mockFetcher.EXPECT().Fetch(gomock.Any(), "https://example.com/big").Return([]byte("larger than ten"), "", nil)
+
+		_, err := loader.Load(context.Background(), []models.Attachment{
+			{Filename: "notes.txt", StorageKey: "notification.attachment:id:0"},
+			{Filename: "big.bin", URL: "https://example.com/big"},
+		})
+		assert.ErrorContains(t, err, "16 bytes in total")
+	})
+
+	t.Run("fetch_error", func(t *testing.T) {
+		mockFetcher.EXPECT().Fetch(gomock.Any(), "https://example.com/missing").Return(nil, "", errors.New("unexpected status 404"))
+
+		_, err := loader.Load(context.Background(), []models.Attachment{
+			{Filename: "missing.pdf", URL: "https://example.com/missing"},
+		})
+		assert.Error(t, err)
+	})
+}
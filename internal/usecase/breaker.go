@@ -0,0 +1,127 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
+)
+
+// ErrCircuitOpen возвращается вместо отправки по каналу, отправщик которого отказывает подряд.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// circuitBreaker автомат отключения отправщика одного канала.
+type circuitBreaker struct {
+	mu       sync.Mutex
+	state    models.BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool // пробная отправка в полуоткрытом состоянии уже идет
+	opens    int64
+}
+
+// CircuitBreakers автоматы отключения отправщиков каналов. После threshold ошибок подряд автомат канала
+// размыкается, и отправка по каналу откладывается без обращения к отправщику. Через cooldown одна пробная
+// отправка проверяет отправщик: при успехе автомат замыкается, при ошибке снова размыкается.
+// Состояние у каждого экземпляра сервиса свое.
+type CircuitBreakers struct {
+	threshold int
+	cooldown  time.Duration
+	breakers  map[models.ChannelName]*circuitBreaker
+	now       func() time.Time
+}
+
+// NewCircuitBreakers создает новый CircuitBreakers.
+func NewCircuitBreakers(threshold int, cooldown time.Duration) *CircuitBreakers {
+	return &CircuitBreakers{
+		threshold: threshold,
+		cooldown:  cooldown,
+		breakers:  make(map[models.ChannelName]*circuitBreaker),
+		now:       time.Now,
+	}
+}
+
+// AddChannel включает автомат отключения для канала. Добавлять каналы нужно до начала отправки.
+func (cb *CircuitBreakers) AddChannel(name models.ChannelName) {
+	cb.breakers[name] = &circuitBreaker{state: models.BreakerClosed}
+}
+
+// Allow разрешает отправку по каналу. Если автомат разомкнут, возвращает *models.RetryAfterError
+// с ErrCircuitOpen: отправка откладывается до пробной.
+func (cb *CircuitBreakers) Allow(name models.ChannelName) error {
+	b, ok := cb.breakers[name]
+	if !ok {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case models.BreakerOpen:
+		if wait := b.openedAt.Add(cb.cooldown).Sub(cb.now()); wait > 0 {
+			return &models.RetryAfterError{After: wait, Err: ErrCircuitOpen}
+		}
+		b.state = models.BreakerHalfOpen
+	case models.BreakerHalfOpen:
+	default:
+		return nil
+	}
+
+	if b.probing {
+		return &models.RetryAfterError{After: cb.cooldown, Err: ErrCircuitOpen}
+	}
+	b.probing = true
+	return nil
+}
+
+// Record учитывает результат разрешенной отправки по каналу. Прерванная отправка, ответ провайдера
+// "повторите позже", превышенный лимит отправки получателю и ошибки получателя или содержимого
+// не говорят о неисправности отправщика и не учитываются, но освобождают пробную отправку.
+func (cb *CircuitBreakers) Record(name models.ChannelName, err error) {
+	b, ok := cb.breakers[name]
+	if !ok {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	probe := b.probing
+	b.probing = false
+
+	var (
+		retryErr     *models.RetryAfterError
+		recipientErr *models.RecipientError
+		limitErr     *RateLimitError
+	)
+	switch {
+	case err == nil:
+		b.state, b.failures = models.BreakerClosed, 0
+	case errors.Is(err, context.Canceled), errors.As(err, &retryErr), errors.As(err, &recipientErr),
+		errors.As(err, &limitErr):
+	default:
+		b.failures++
+		if probe || b.failures >= cb.threshold {
+			b.state, b.openedAt = models.BreakerOpen, cb.now()
+			b.opens++
+		}
+	}
+}
+
+// Stats возвращает состояние автоматов отключения каналов.
+func (cb *CircuitBreakers) Stats() map[models.ChannelName]models.BreakerStats {
+	stats := make(map[models.ChannelName]models.BreakerStats, len(cb.breakers))
+	for name, b := range cb.breakers {
+		b.mu.Lock()
+		state := b.state
+		if state == models.BreakerOpen && !cb.now().Before(b.openedAt.Add(cb.cooldown)) {
+			state = models.BreakerHalfOpen // пробная отправка разрешена, но еще не начата
+		}
+		stats[name] = models.BreakerStats{State: state, ConsecutiveFailures: b.failures, Opens: b.opens}
+		b.mu.Unlock()
+	}
+	return stats
+}
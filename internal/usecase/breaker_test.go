@@ -0,0 +1,69 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreakers(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 3, 14, 10, 0, 0, 0, time.UTC)
+	breakers := NewCircuitBreakers(2, 30*time.Second)
+	breakers.now = func() time.Time { return now }
+	breakers.AddChannel(models.ChannelEmail)
+
+	smtpDown := errors.New("connection refused")
+
+	require.NoError(t, breakers.Allow(models.ChannelEmail))
+	breakers.Record(models.ChannelEmail, smtpDown)
+	breakers.Record(models.ChannelEmail, context.Canceled)
+	breakers.Record(models.ChannelEmail, &models.RetryAfterError{After: time.Second, Err: smtpDown})
+	// адрес, которого нет, или заблокированный бот не говорят о неисправности отправщика
+	breakers.Record(models.ChannelEmail, &models.RecipientError{Err: errors.New("550 no such user")})
+	breakers.Record(models.ChannelEmail, fmt.Errorf("email channel: %w", &models.RecipientError{Err: errors.New("attachment: 404")}))
+	assert.Equal(t, models.BreakerStats{State: models.BreakerClosed, ConsecutiveFailures: 1}, breakers.Stats()[models.ChannelEmail])
+
+	breakers.Record(models.ChannelEmail, smtpDown)
+	assert.Equal(t, models.BreakerOpen, breakers.Stats()[models.ChannelEmail].State)
+
+	// разомкнутый автомат откладывает отправку до пробной
+	now = now.Add(10 * time.Second)
+	err := breakers.Allow(models.ChannelEmail)
+	var retryErr *models.RetryAfterError
+	require.ErrorAs(t, err, &retryErr)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, 20*time.Second, retryErr.After)
+
+	// неудачная пробная отправка снова размыкает автомат
+	now = now.Add(20 * time.Second)
+	assert.Equal(t, models.BreakerHalfOpen, breakers.Stats()[models.ChannelEmail].State)
+	require.NoError(t, breakers.Allow(models.ChannelEmail))
+	assert.ErrorIs(t, breakers.Allow(models.ChannelEmail), ErrCircuitOpen, "only one probe at a time")
+	breakers.Record(models.ChannelEmail, smtpDown)
+	assert.Equal(t, models.BreakerStats{State: models.BreakerOpen, ConsecutiveFailures: 3, Opens: 2}, breakers.Stats()[models.ChannelEmail])
+
+	// пробная отправка, не выполненная из-за лимита получателя, освобождает место для следующей
+	now = now.Add(30 * time.Second)
+	require.NoError(t, breakers.Allow(models.ChannelEmail))
+	breakers.Record(models.ChannelEmail, &RateLimitError{Channel: models.ChannelEmail, RetryAfter: time.Minute})
+	assert.Equal(t, models.BreakerHalfOpen, breakers.Stats()[models.ChannelEmail].State)
+
+	// удачная пробная отправка замыкает его
+	now = now.Add(30 * time.Second)
+	require.NoError(t, breakers.Allow(models.ChannelEmail))
+	breakers.Record(models.ChannelEmail, nil)
+	assert.Equal(t, models.BreakerStats{State: models.BreakerClosed, Opens: 2}, breakers.Stats()[models.ChannelEmail])
+
+	// каналы без автомата не ограничиваются
+	require.NoError(t, breakers.Allow(models.ChannelWebhook))
+	breakers.Record(models.ChannelWebhook, smtpDown)
+	assert.NotContains(t, breakers.Stats(), models.ChannelWebhook)
+}
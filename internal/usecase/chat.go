@@ -0,0 +1,220 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
+)
+
+// ErrChatNotificationNotFound возвращается, если в чате нет такого ожидающего отправки уведомления.
+var ErrChatNotificationNotFound = errors.New("notification not found or already sent")
+
+// chatNotificationsKey множество айди уведомлений в чат телеграм, упорядоченное по времени создания.
+func chatNotificationsKey(chatID string) string {
+	return "chat.notifications:" + chatID
+}
+
+func chatMutedKey(chatID string) string {
+	return "chat.muted:" + chatID
+}
+
+// chatNotificationsRetention сколько уведомление хранится в списке чата: созданное раньше уже отправлено,
+// а его статус не хранится.
+const chatNotificationsRetention = maxReminderDelay + 168*time.Hour
+
+// indexChatNotification запоминает уведомление в списке уведомлений чата, чтобы им можно было управлять из телеграм.
+// Отправленные уведомления убираются из списка при просмотре /list, а устаревшие - здесь,
+// чтобы список чата, в котором его не смотрят, не рос бесконечно.
+func (nc *NotificationCreator) indexChatNotification(ctx context.Context, notification models.DelayedNotification) error {
+	chatID := notification.Channels.TelegramChannel.ChatID
+	if chatID == "" {
+		return nil
+	}
+
+	now := time.Now()
+	if err := nc.storage.SortedSetAdd(ctx, chatNotificationsKey(chatID), notification.ID, float64(now.UnixMilli())); err != nil {
+		return err
+	}
+
+	expired := strconv.FormatInt(now.Add(-chatNotificationsRetention).UnixMilli(), 10)
+	return nc.storage.SortedSetRemoveRangeByScore(ctx, chatNotificationsKey(chatID), "-inf", "("+expired)
+}
+
+// ScheduleChatReminder планирует напоминание в чат по фразе вида "30m позвонить маме" (см. parseWhen).
+func (nc *NotificationCreator) ScheduleChatReminder(ctx context.Context, chatID, phrase string) (models.ChatNotification, error) {
+	now := time.Now()
+	sendAt, text, err := parseReminder(phrase, now)
+	if err != nil {
+		return models.ChatNotification{}, err
+	}
+
+	uid, err := nc.ScheduleNotification(ctx, models.DelayedNotification{
+		Notification: models.Notification(text),
+		Delay:        sendAt.Sub(now),
+		Channels:     models.Channels{TelegramChannel: models.TelegramChannel{ChatID: chatID}},
+	})
+	if err != nil {
+		return models.ChatNotification{}, err
+	}
+
+	return models.ChatNotification{ID: uid, Text: text, SendAt: sendAt, Status: models.StatusScheduled}, nil
+}
+
+// ListChatNotifications возвращает уведомления чата, ожидающие отправки.
+// Отправленные и удаленные уведомления попутно убираются из списка чата.
+func (nc *NotificationCreator) ListChatNotifications(ctx context.Context, chatID string) ([]models.ChatNotification, error) {
+	ids, err := nc.storage.SortedSetRangeByScore(ctx, chatNotificationsKey(chatID), "-inf", "+inf", 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var notifications []models.ChatNotification
+	for _, id := range ids {
+		notification, status, err := nc.pendingNotification(ctx, id)
+		if errors.Is(err, models.ErrNotFound) {
+			if err := nc.storage.SortedSetRemove(ctx, chatNotificationsKey(chatID), id); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		score, err := nc.storage.SortedSetScore(ctx, nc.delayedSetName, id)
+		if errors.Is(err, models.ErrNotFound) {
+			continue // уже отправляется
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		n := models.ChatNotification{
+			ID:     id,
+			Text:   string(notification.Notification),
+			SendAt: time.UnixMilli(int64(score)),
+			Status: status,
+		}
+		if notification.Template != nil {
+			n.TemplateID = notification.Template.ID
+		}
+		notifications = append(notifications, n)
+	}
+
+	return notifications, nil
+}
+
+// CancelChatNotification отменяет ожидающее отправки уведомление чата.
+func (nc *NotificationCreator) CancelChatNotification(ctx context.Context, chatID, uid string) error {
+	if err := nc.checkChatNotification(ctx, chatID, uid); err != nil {
+		return err
+	}
+
+	if _, _, err := nc.pendingNotification(ctx, uid); err != nil {
+		if errors.Is(err, models.ErrNotFound) {
+			return ErrChatNotificationNotFound
+		}
+		return err
+	}
+
+	if err := nc.RemoveNotification(ctx, uid, false); err != nil {
+		return err
+	}
+
+	return nc.storage.SortedSetRemove(ctx, chatNotificationsKey(chatID), uid)
+}
+
+// SnoozeChatNotification откладывает уведомление чата на время из фразы вида "1h" (см. parseWhen).
+// Возвращает айди отложенного уведомления и время его отправки.
+func (nc *NotificationCreator) SnoozeChatNotification(ctx context.Context, chatID, uid, phrase string) (string, time.Time, error) {
+	if err := nc.checkChatNotification(ctx, chatID, uid); err != nil {
+		return "", time.Time{}, err
+	}
+
+	sendAt, rest, err := parseWhen(strings.Fields(phrase), time.Now())
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if len(rest) > 0 {
+		return "", time.Time{}, ErrInvalidReminder
+	}
+
+	snoozedID, err := nc.SnoozeNotification(ctx, uid, sendAt)
+	if errors.Is(err, ErrNotificationNotFound) {
+		return "", time.Time{}, ErrChatNotificationNotFound
+	}
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return snoozedID, sendAt, nil
+}
+
+// checkChatNotification проверяет, что уведомление было создано для чата.
+func (nc *NotificationCreator) checkChatNotification(ctx context.Context, chatID, uid string) error {
+	payload, err := nc.storage.Get(ctx, "notification.original:"+uid)
+	if errors.Is(err, models.ErrNotFound) {
+		return ErrChatNotificationNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	var notification models.DelayedNotification
+	if err := json.Unmarshal([]byte(payload), &notification); err != nil {
+		return err
+	}
+	if notification.Channels.TelegramChannel.ChatID != chatID {
+		return ErrChatNotificationNotFound
+	}
+
+	return nil
+}
+
+// pendingNotification возвращает ожидающее отправки уведомление и его статус
+// или models.ErrNotFound, если уведомление уже отправлено или удалено.
+func (nc *NotificationCreator) pendingNotification(ctx context.Context, uid string) (models.DelayedNotification, models.NotificationStatus, error) {
+	status, err := nc.GetNotificationStatus(ctx, uid)
+	if err != nil {
+		return models.DelayedNotification{}, "", err
+	}
+	if !isPending(status) {
+		return models.DelayedNotification{}, "", models.ErrNotFound
+	}
+
+	payload, err := nc.storage.Get(ctx, "notification:"+uid)
+	if err != nil {
+		return models.DelayedNotification{}, "", err
+	}
+
+	var notification models.DelayedNotification
+	if err := json.Unmarshal([]byte(payload), &notification); err != nil {
+		return models.DelayedNotification{}, "", err
+	}
+
+	return notification, status, nil
+}
+
+// MuteChat выключает отправку уведомлений в чат. Уведомления продолжают уходить по другим каналам.
+func (nc *NotificationCreator) MuteChat(ctx context.Context, chatID string) error {
+	return nc.storage.Add(ctx, chatMutedKey(chatID), "1", 0)
+}
+
+// UnmuteChat снова включает отправку уведомлений в чат.
+func (nc *NotificationCreator) UnmuteChat(ctx context.Context, chatID string) error {
+	return nc.storage.Remove(ctx, chatMutedKey(chatID))
+}
+
+// IsChatMuted сообщает, выключена ли отправка уведомлений в чат.
+func (nc *NotificationCreator) IsChatMuted(ctx context.Context, chatID string) (bool, error) {
+	_, err := nc.storage.Get(ctx, chatMutedKey(chatID))
+	if errors.Is(err, models.ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
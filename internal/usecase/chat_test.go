@@ -0,0 +1,171 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	mock_usecase "github.com/child6yo/wbtech-l3-delayed-notifyer/internal/usecase/mock"
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotificationCreator_ScheduleChatReminder(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := allowStatusHistory(mock_usecase.NewMockstorage(ctrl))
+	creator := NewNotificationCreator(mockStorage, nil, nil, nil, "delayed_notifications", nil)
+
+	t.Run("indexed_with_pruning", func(t *testing.T) {
+		mockStorage.EXPECT().Add(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(3)
+		mockStorage.EXPECT().SortedSetAdd(gomock.Any(), "chat.notifications:42", gomock.Any(), gomock.Any()).Return(nil)
+		mockStorage.EXPECT().SortedSetRemoveRangeByScore(gomock.Any(), "chat.notifications:42", "-inf", gomock.Any()).
+			DoAndReturn(func(_ context.Context, _, _, max string) error {
+				// удаляются только уведомления старше срока хранения
+				assert.True(t, strings.HasPrefix(max, "("))
+				score, err := strconv.ParseInt(strings.TrimPrefix(max, "("), 10, 64)
+				require.NoError(t, err)
+				assert.WithinDuration(t, time.Now().Add(-chatNotificationsRetention), time.UnixMilli(score), time.Minute)
+				return nil
+			})
+		mockStorage.EXPECT().SortedSetAdd(gomock.Any(), "delayed_notifications", gomock.Any(), gomock.Any()).Return(nil)
+
+		_, err := creator.ScheduleChatReminder(context.Background(), "42", "30m call mom")
+		require.NoError(t, err)
+	})
+
+	t.Run("queueing_fails", func(t *testing.T) {
+		mockStorage.EXPECT().Add(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(3)
+		mockStorage.EXPECT().SortedSetAdd(gomock.Any(), "chat.notifications:42", gomock.Any(), gomock.Any()).Return(nil)
+		mockStorage.EXPECT().SortedSetRemoveRangeByScore(gomock.Any(), "chat.notifications:42", "-inf", gomock.Any()).Return(nil)
+		mockStorage.EXPECT().SortedSetAdd(gomock.Any(), "delayed_notifications", gomock.Any(), gomock.Any()).Return(errors.New("zadd error"))
+		mockStorage.EXPECT().Remove(gomock.Any(), gomock.Any()).Return(nil).Times(5)
+		// уведомление, которое не встало в очередь, не остается в списке чата
+		mockStorage.EXPECT().SortedSetRemove(gomock.Any(), "chat.notifications:42", gomock.Any()).Return(nil)
+
+		_, err := creator.ScheduleChatReminder(context.Background(), "42", "30m call mom")
+		assert.Error(t, err)
+	})
+}
+
+func TestNotificationCreator_ListChatNotifications(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := allowStatusHistory(mock_usecase.NewMockstorage(ctrl))
+	creator := NewNotificationCreator(mockStorage, nil, nil, nil, "delayed_notifications", nil)
+
+	sendAt := time.Now().Add(time.Hour).Truncate(time.Millisecond)
+	payload, err := json.Marshal(models.DelayedNotification{ID: "pending", Notification: "call mom"})
+	require.NoError(t, err)
+
+	mockStorage.EXPECT().SortedSetRangeByScore(gomock.Any(), "chat.notifications:42", "-inf", "+inf", int64(0), int64(0)).
+		Return([]string{"pending", "sent"}, nil)
+	mockStorage.EXPECT().Get(gomock.Any(), "notification.status:pending").Return(string(models.StatusScheduled), nil)
+	mockStorage.EXPECT().Get(gomock.Any(), "notification:pending").Return(string(payload), nil)
+	mockStorage.EXPECT().SortedSetScore(gomock.Any(), "delayed_notifications", "pending").Return(float64(sendAt.UnixMilli()), nil)
+	// отправленное уведомление убирается из списка чата
+	mockStorage.EXPECT().Get(gomock.Any(), "notification.status:sent").Return(string(models.StatusSent), nil)
+	mockStorage.EXPECT().SortedSetRemove(gomock.Any(), "chat.notifications:42", "sent").Return(nil)
+
+	notifications, err := creator.ListChatNotifications(context.Background(), "42")
+	require.NoError(t, err)
+	require.Len(t, notifications, 1)
+	assert.Equal(t, "pending", notifications[0].ID)
+	assert.Equal(t, "call mom", notifications[0].Text)
+	assert.True(t, sendAt.Equal(notifications[0].SendAt))
+}
+
+func TestNotificationCreator_CancelChatNotification(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := allowStatusHistory(mock_usecase.NewMockstorage(ctrl))
+	creator := NewNotificationCreator(mockStorage, nil, nil, nil, "delayed_notifications", nil)
+
+	t.Run("other_chat", func(t *testing.T) {
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.original:foreign").Return(chatOriginal("7"), nil)
+
+		err := creator.CancelChatNotification(context.Background(), "42", "foreign")
+		assert.ErrorIs(t, err, ErrChatNotificationNotFound)
+	})
+
+	t.Run("success", func(t *testing.T) {
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.original:test-id").Return(chatOriginal("42"), nil)
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.status:test-id").Return(string(models.StatusScheduled), nil).Times(2)
+		mockStorage.EXPECT().Get(gomock.Any(), "notification:test-id").Return(`{"id":"test-id"}`, nil).Times(2)
+		mockStorage.EXPECT().SortedSetRemove(gomock.Any(), "delayed_notifications", "test-id").Return(nil)
+		mockStorage.EXPECT().Remove(gomock.Any(), "notification:test-id").Return(nil)
+		mockStorage.EXPECT().Remove(gomock.Any(), "notification.status:test-id").Return(nil)
+		mockStorage.EXPECT().Remove(gomock.Any(), "notification.history:test-id").Return(nil)
+		mockStorage.EXPECT().Remove(gomock.Any(), "notification.original:test-id").Return(nil)
+		mockStorage.EXPECT().ListRange(gomock.Any(), "notification.digest.members:test-id").Return(nil, nil)
+		mockStorage.EXPECT().Remove(gomock.Any(), "notification.event:test-id").Return(nil)
+		mockStorage.EXPECT().SortedSetRemove(gomock.Any(), "chat.notifications:42", "test-id").Return(nil)
+
+		require.NoError(t, creator.CancelChatNotification(context.Background(), "42", "test-id"))
+	})
+}
+
+func TestNotificationCreator_SnoozeChatNotification(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := allowStatusHistory(mock_usecase.NewMockstorage(ctrl))
+	creator := NewNotificationCreator(mockStorage, nil, nil, nil, "delayed_notifications", nil)
+
+	t.Run("postpones_pending", func(t *testing.T) {
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.original:test-id").Return(chatOriginal("42"), nil)
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.status:test-id").Return(string(models.StatusScheduled), nil).Times(2)
+		mockStorage.EXPECT().Get(gomock.Any(), "notification:test-id").Return(`{"id":"test-id","notification":"call mom"}`, nil)
+		mockStorage.EXPECT().SortedSetScore(gomock.Any(), "delayed_notifications", "test-id").Return(float64(1), nil)
+		mockStorage.EXPECT().Add(gomock.Any(), "notification:test-id", gomock.Any(), gomock.Any()).Return(nil)
+		mockStorage.EXPECT().SortedSetAdd(gomock.Any(), "delayed_notifications", "test-id", gomock.Any()).Return(nil)
+
+		snoozedID, sendAt, err := creator.SnoozeChatNotification(context.Background(), "42", "test-id", "1h")
+		require.NoError(t, err)
+		assert.Equal(t, "test-id", snoozedID)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), sendAt, time.Second)
+	})
+
+	t.Run("other_chat", func(t *testing.T) {
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.original:test-id").Return(chatOriginal("7"), nil)
+
+		_, _, err := creator.SnoozeChatNotification(context.Background(), "42", "test-id", "1h")
+		assert.ErrorIs(t, err, ErrChatNotificationNotFound)
+	})
+
+	t.Run("invalid_time", func(t *testing.T) {
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.original:test-id").Return(chatOriginal("42"), nil)
+
+		_, _, err := creator.SnoozeChatNotification(context.Background(), "42", "test-id", "soon")
+		assert.ErrorIs(t, err, ErrInvalidReminder)
+	})
+}
+
+// chatOriginal возвращает сохраненный оригинал уведомления в чат.
+func chatOriginal(chatID string) string {
+	payload, err := json.Marshal(models.DelayedNotification{
+		ID:       "test-id",
+		Channels: models.Channels{TelegramChannel: models.TelegramChannel{ChatID: chatID}},
+	})
+	if err != nil {
+		panic(err)
+	}
+	return string(payload)
+}
@@ -0,0 +1,90 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
+)
+
+// collapseClaimAttempts сколько раз пробуется занять индекс ключа схлопывания, который одновременно меняют другие запросы.
+const collapseClaimAttempts = 5
+
+// claimCollapseKey атомарно указывает индексом ключа схлопывания на новое уведомление.
+// Если индекс указывает на ожидающее отправки уведомление, при политике ignore индекс не меняется и айди
+// этого уведомления возвращается первым, а при replace он возвращается вторым как айди заменяемого.
+// Индекс, указывающий на уже отправленное или отмененное уведомление, просто занимается.
+func (nc *NotificationCreator) claimCollapseKey(ctx context.Context, notification models.DelayedNotification) (string, string, error) {
+	key := notification.CollapseIndexKey()
+	ttl := notification.Delay + 24*time.Hour
+
+	for range collapseClaimAttempts {
+		claimed, err := nc.storage.AddIfAbsent(ctx, key, notification.ID, ttl)
+		if err != nil || claimed {
+			return "", "", err
+		}
+
+		indexed, err := nc.storage.Get(ctx, key)
+		if errors.Is(err, models.ErrNotFound) {
+			continue // индекс освободили между запросами
+		}
+		if err != nil {
+			return "", "", err
+		}
+
+		pending, err := nc.isPendingNotification(ctx, indexed)
+		if err != nil {
+			return "", "", err
+		}
+		if pending && notification.Collapse.Policy == models.CollapseIgnore {
+			return indexed, "", nil
+		}
+
+		swapped, err := nc.storage.CompareAndSwap(ctx, key, indexed, notification.ID, ttl)
+		if err != nil {
+			return "", "", err
+		}
+		if !swapped {
+			continue // индекс занял другой запрос
+		}
+		if pending {
+			return "", indexed, nil
+		}
+		return "", "", nil
+	}
+
+	return "", "", fmt.Errorf("failed to claim collapse key %s: it is changed concurrently", notification.Collapse.Key)
+}
+
+// isPendingNotification сообщает, ожидает ли уведомление отправки.
+func (nc *NotificationCreator) isPendingNotification(ctx context.Context, uid string) (bool, error) {
+	status, err := nc.GetNotificationStatus(ctx, uid)
+	if errors.Is(err, models.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return isPending(status), nil
+}
+
+// restoreCollapseIndex возвращает индекс ключа схлопывания на заменяемое уведомление replaced
+// (или удаляет его, если заменять было нечего), когда новое уведомление запланировать не удалось.
+func (nc *NotificationCreator) restoreCollapseIndex(ctx context.Context, notification models.DelayedNotification, replaced string) error {
+	_, err := nc.storage.CompareAndSwap(ctx, notification.CollapseIndexKey(), notification.ID, replaced, notification.Delay+24*time.Hour)
+	return err
+}
+
+// releaseCollapseIndex удаляет индекс ключа схлопывания, если он указывает на уведомление.
+// Индекс, который уже указывает на заменившее уведомление, не трогается.
+func (nc *NotificationCreator) releaseCollapseIndex(ctx context.Context, notification models.DelayedNotification) error {
+	key := notification.CollapseIndexKey()
+	if key == "" {
+		return nil
+	}
+
+	_, err := nc.storage.CompareAndSwap(ctx, key, notification.ID, "", 0)
+	return err
+}
@@ -0,0 +1,164 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	mock_usecase "github.com/child6yo/wbtech-l3-delayed-notifyer/internal/usecase/mock"
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotificationCreator_ScheduleNotification_Collapse(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := allowStatusHistory(mock_usecase.NewMockstorage(ctrl))
+	creator := NewNotificationCreator(mockStorage, nil, nil, nil, "delayed_notifications", nil)
+
+	notification := func(policy models.CollapsePolicy) models.DelayedNotification {
+		return models.DelayedNotification{
+			Notification: "test message",
+			Delay:        10 * time.Second,
+			Channels: models.Channels{
+				EmailChannel: models.EmailChannel{Email: "user@example.com"},
+			},
+			Collapse: &models.Collapse{Key: "reminder", Policy: policy},
+		}
+	}
+
+	t.Run("free", func(t *testing.T) {
+		mockStorage.EXPECT().AddIfAbsent(gomock.Any(), "notification.collapse:reminder", gomock.Any(), gomock.Any()).Return(true, nil)
+		mockStorage.EXPECT().Add(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(3)
+		mockStorage.EXPECT().SortedSetAdd(gomock.Any(), "delayed_notifications", gomock.Any(), gomock.Any()).Return(nil)
+
+		uid, err := creator.ScheduleNotification(context.Background(), notification(models.CollapseIgnore))
+		require.NoError(t, err)
+		assert.NotEmpty(t, uid)
+	})
+
+	t.Run("ignore", func(t *testing.T) {
+		mockStorage.EXPECT().AddIfAbsent(gomock.Any(), "notification.collapse:reminder", gomock.Any(), gomock.Any()).Return(false, nil)
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.collapse:reminder").Return("old", nil)
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.status:old").Return(string(models.StatusScheduled), nil)
+
+		uid, err := creator.ScheduleNotification(context.Background(), notification(models.CollapseIgnore))
+		require.NoError(t, err)
+		assert.Equal(t, "old", uid)
+	})
+
+	t.Run("ignore_deferred", func(t *testing.T) {
+		mockStorage.EXPECT().AddIfAbsent(gomock.Any(), "notification.collapse:reminder", gomock.Any(), gomock.Any()).Return(false, nil)
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.collapse:reminder").Return("old", nil)
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.status:old").Return(string(models.StatusDeferred), nil)
+
+		uid, err := creator.ScheduleNotification(context.Background(), notification(models.CollapseIgnore))
+		require.NoError(t, err)
+		assert.Equal(t, "old", uid)
+	})
+
+	t.Run("replace", func(t *testing.T) {
+		var indexed string
+		mockStorage.EXPECT().AddIfAbsent(gomock.Any(), "notification.collapse:reminder", gomock.Any(), gomock.Any()).Return(false, nil)
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.collapse:reminder").Return("old", nil)
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.status:old").Return(string(models.StatusScheduled), nil).Times(2)
+		mockStorage.EXPECT().CompareAndSwap(gomock.Any(), "notification.collapse:reminder", "old", gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, _, _, value string, _ time.Duration) (bool, error) {
+				indexed = value
+				return true, nil
+			})
+		mockStorage.EXPECT().Add(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(3)
+		mockStorage.EXPECT().SortedSetAdd(gomock.Any(), "delayed_notifications", gomock.Any(), gomock.Any()).Return(nil)
+
+		// индекс уже указывает на новое уведомление, поэтому отмена старого его не трогает
+		mockStorage.EXPECT().Get(gomock.Any(), "notification:old").
+			Return(`{"id":"old","collapse":{"key":"reminder","policy":"replace"}}`, nil)
+		mockStorage.EXPECT().CompareAndSwap(gomock.Any(), "notification.collapse:reminder", "old", "", gomock.Any()).Return(false, nil)
+		mockStorage.EXPECT().SortedSetRemove(gomock.Any(), "delayed_notifications", "old").Return(nil)
+		mockStorage.EXPECT().Remove(gomock.Any(), "notification:old").Return(nil)
+		mockStorage.EXPECT().Remove(gomock.Any(), "notification.status:old").Return(nil)
+		mockStorage.EXPECT().Remove(gomock.Any(), "notification.history:old").Return(nil)
+		mockStorage.EXPECT().Remove(gomock.Any(), "notification.original:old").Return(nil)
+		mockStorage.EXPECT().ListRange(gomock.Any(), "notification.digest.members:old").Return(nil, nil)
+		mockStorage.EXPECT().Remove(gomock.Any(), "notification.event:old").Return(nil)
+
+		uid, err := creator.ScheduleNotification(context.Background(), notification(models.CollapseReplace))
+		require.NoError(t, err)
+		assert.NotEqual(t, "old", uid)
+		assert.Equal(t, uid, indexed)
+	})
+
+	t.Run("replaced_taken_by_poller", func(t *testing.T) {
+		// поллер забрал заменяемое уведомление между заменой индекса и его отменой
+		mockStorage.EXPECT().AddIfAbsent(gomock.Any(), "notification.collapse:reminder", gomock.Any(), gomock.Any()).Return(false, nil)
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.collapse:reminder").Return("old", nil)
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.status:old").Return(string(models.StatusScheduled), nil)
+		mockStorage.EXPECT().CompareAndSwap(gomock.Any(), "notification.collapse:reminder", "old", gomock.Any(), gomock.Any()).Return(true, nil)
+		mockStorage.EXPECT().Add(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(3)
+		mockStorage.EXPECT().SortedSetAdd(gomock.Any(), "delayed_notifications", gomock.Any(), gomock.Any()).Return(nil)
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.status:old").Return(string(models.StatusSending), nil)
+
+		uid, err := creator.ScheduleNotification(context.Background(), notification(models.CollapseReplace))
+		require.NoError(t, err)
+		assert.NotEmpty(t, uid)
+		assert.NotEqual(t, "old", uid)
+	})
+
+	t.Run("already_sent", func(t *testing.T) {
+		mockStorage.EXPECT().AddIfAbsent(gomock.Any(), "notification.collapse:reminder", gomock.Any(), gomock.Any()).Return(false, nil)
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.collapse:reminder").Return("old", nil)
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.status:old").Return(string(models.StatusSent), nil)
+		mockStorage.EXPECT().CompareAndSwap(gomock.Any(), "notification.collapse:reminder", "old", gomock.Any(), gomock.Any()).Return(true, nil)
+		mockStorage.EXPECT().Add(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(3)
+		mockStorage.EXPECT().SortedSetAdd(gomock.Any(), "delayed_notifications", gomock.Any(), gomock.Any()).Return(nil)
+
+		uid, err := creator.ScheduleNotification(context.Background(), notification(models.CollapseIgnore))
+		require.NoError(t, err)
+		assert.NotEqual(t, "old", uid)
+	})
+
+	t.Run("concurrent_claim", func(t *testing.T) {
+		// другой запрос занял индекс между проверкой статуса и заменой
+		mockStorage.EXPECT().AddIfAbsent(gomock.Any(), "notification.collapse:reminder", gomock.Any(), gomock.Any()).Return(false, nil).Times(2)
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.collapse:reminder").Return("old", nil)
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.status:old").Return(string(models.StatusSent), nil)
+		mockStorage.EXPECT().CompareAndSwap(gomock.Any(), "notification.collapse:reminder", "old", gomock.Any(), gomock.Any()).Return(false, nil)
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.collapse:reminder").Return("other", nil)
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.status:other").Return(string(models.StatusScheduled), nil)
+
+		uid, err := creator.ScheduleNotification(context.Background(), notification(models.CollapseIgnore))
+		require.NoError(t, err)
+		assert.Equal(t, "other", uid)
+	})
+
+	t.Run("schedule_fails", func(t *testing.T) {
+		mockStorage.EXPECT().AddIfAbsent(gomock.Any(), "notification.collapse:reminder", gomock.Any(), gomock.Any()).Return(false, nil)
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.collapse:reminder").Return("old", nil)
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.status:old").Return(string(models.StatusScheduled), nil)
+		mockStorage.EXPECT().CompareAndSwap(gomock.Any(), "notification.collapse:reminder", "old", gomock.Any(), gomock.Any()).Return(true, nil)
+		mockStorage.EXPECT().Add(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("connection refused"))
+
+		// индекс возвращается на заменяемое уведомление, которое остается запланированным
+		mockStorage.EXPECT().CompareAndSwap(gomock.Any(), "notification.collapse:reminder", gomock.Any(), "old", gomock.Any()).Return(true, nil)
+
+		_, err := creator.ScheduleNotification(context.Background(), notification(models.CollapseReplace))
+		assert.Error(t, err)
+	})
+}
+
+func TestDelayedNotification_CollapseIndexKey(t *testing.T) {
+	t.Parallel()
+
+	collapse := &models.Collapse{Key: "reminder", Policy: models.CollapseReplace}
+
+	assert.Empty(t, models.DelayedNotification{}.CollapseIndexKey())
+	assert.Equal(t, "notification.collapse:reminder", models.DelayedNotification{Collapse: collapse}.CollapseIndexKey())
+	assert.Equal(t, "notification.collapse:user-1:reminder",
+		models.DelayedNotification{RecipientID: "user-1", Collapse: collapse}.CollapseIndexKey())
+}
@@ -0,0 +1,171 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
+	"github.com/google/uuid"
+)
+
+// ErrEmptyDigest возвращается, если все уведомления сводки отменены и отправлять нечего.
+var ErrEmptyDigest = errors.New("digest is empty")
+
+// digestNamespace пространство имен айди сводок: у получателя, ключа и окна сводка всегда одна.
+var digestNamespace = uuid.MustParse("5f0c4a52-8f5e-4d6b-9a39-2b7d1c0e6a11")
+
+func digestMembersKey(digestID string) string {
+	return "notification.digest.members:" + digestID
+}
+
+func digestRefKey(uid string) string {
+	return "notification.digest:" + uid
+}
+
+// digestID возвращает айди сводки получателя с ключом key за окно, которое заканчивается в end.
+func digestID(recipientID, key string, end time.Time) string {
+	name := recipientID + "\n" + key + "\n" + strconv.FormatInt(end.Unix(), 10)
+	return uuid.NewSHA1(digestNamespace, []byte(name)).String()
+}
+
+// enqueueDigest сохраняет уведомление в сводку получателя за окно, на которое приходится его срок,
+// и планирует сводку на конец окна, если она еще не запланирована. Само уведомление в очередь не попадает.
+func (nc *NotificationCreator) enqueueDigest(ctx context.Context, notification models.DelayedNotification) error {
+	policy := notification.Digest
+	end := time.Now().Add(notification.Delay).Truncate(policy.Window).Add(policy.Window)
+	id := digestID(notification.RecipientID, policy.Key, end)
+	exp := time.Until(end) + 168*time.Hour
+
+	payload, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+
+	if err := nc.storage.Add(ctx, "notification:"+notification.ID, payload, exp); err != nil {
+		return err
+	}
+
+	if err := nc.joinDigest(ctx, notification, id, end, exp); err != nil {
+		// иначе уведомление осталось бы запланированным, но ни одна сводка его не отправила бы
+		nc.discardStored(ctx, notification)
+		_ = nc.storage.Remove(ctx, digestRefKey(notification.ID))
+		return err
+	}
+	return nil
+}
+
+// joinDigest добавляет сохраненное уведомление в сводку id и планирует ее, если она еще не запланирована.
+func (nc *NotificationCreator) joinDigest(
+	ctx context.Context, notification models.DelayedNotification, id string, end time.Time, exp time.Duration,
+) error {
+	if err := setStatus(ctx, nc.storage, notification.ID, models.StatusScheduled, "digest "+id, exp); err != nil {
+		return err
+	}
+	if err := nc.storage.Add(ctx, digestRefKey(notification.ID), id, exp); err != nil {
+		return err
+	}
+	if err := nc.storage.ListPush(ctx, digestMembersKey(id), notification.ID, exp); err != nil {
+		return err
+	}
+
+	_, err := nc.storage.Get(ctx, "notification:"+id)
+	if !errors.Is(err, models.ErrNotFound) {
+		return err // сводка уже запланирована другим уведомлением
+	}
+
+	digest := models.DelayedNotification{
+		ID:          id,
+		Delay:       time.Until(end),
+		RecipientID: notification.RecipientID,
+		Locale:      notification.Locale,
+		Digest:      notification.Digest,
+	}
+	// гонка двух первых уведомлений окна безобидна: они планируют одну и ту же сводку
+	return nc.enqueue(ctx, digest)
+}
+
+// DigestMembers возвращает уведомления, собранные в сводку и еще не отмененные.
+func (nc *NotificationCreator) DigestMembers(ctx context.Context, digestID string) ([]models.DelayedNotification, error) {
+	ids, err := nc.storage.ListRange(ctx, digestMembersKey(digestID))
+	if err != nil {
+		return nil, err
+	}
+
+	var members []models.DelayedNotification
+	for _, id := range ids {
+		payload, err := nc.storage.Get(ctx, "notification:"+id)
+		if errors.Is(err, models.ErrNotFound) {
+			continue // отменено
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var member models.DelayedNotification
+		if err := json.Unmarshal([]byte(payload), &member); err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+
+	return members, nil
+}
+
+// CompleteDigest переносит итоговый статус сводки на собранные в нее уведомления:
+// после отправки они получают статус sent_in_digest. Пока сводка ждет повторной попытки, ничего не меняется.
+func (nc *NotificationCreator) CompleteDigest(ctx context.Context, digestID string, status models.NotificationStatus) error {
+	switch status {
+	case models.StatusSent, models.StatusAwaitingAck:
+		status = models.StatusSentInDigest
+	case models.StatusFailed, models.StatusSuppressed, models.StatusRejected, models.StatusDropped:
+	default:
+		return nil
+	}
+
+	members, err := nc.DigestMembers(ctx, digestID)
+	if err != nil {
+		return err
+	}
+
+	for _, member := range members {
+		if err := setStatus(ctx, nc.storage, member.ID, status, "digest "+digestID, 168*time.Hour); err != nil {
+			return err
+		}
+		if err := nc.storage.Remove(ctx, "notification:"+member.ID); err != nil {
+			return err
+		}
+		if err := nc.releaseCollapseIndex(ctx, member); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetDigestID возвращает айди сводки, в которую собрано уведомление, или пустую строку.
+func (nc *NotificationCreator) GetDigestID(ctx context.Context, uid string) (string, error) {
+	id, err := nc.storage.Get(ctx, digestRefKey(uid))
+	if errors.Is(err, models.ErrNotFound) {
+		return "", nil
+	}
+	return id, err
+}
+
+// removeDigestMembers отменяет уведомления, собранные в отменяемую сводку.
+func (nc *NotificationCreator) removeDigestMembers(ctx context.Context, digestID string) error {
+	members, err := nc.DigestMembers(ctx, digestID)
+	if err != nil {
+		return err
+	}
+
+	for _, member := range members {
+		if err := nc.RemoveNotification(ctx, member.ID, false); err != nil && !errors.Is(err, models.ErrNotFound) {
+			return err
+		}
+	}
+
+	return nil
+}
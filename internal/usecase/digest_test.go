@@ -0,0 +1,162 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	mock_usecase "github.com/child6yo/wbtech-l3-delayed-notifyer/internal/usecase/mock"
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotificationCreator_ScheduleNotification_Digest(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := mock_usecase.NewMockstorage(ctrl)
+	creator := NewNotificationCreator(mockStorage, nil, nil, nil, "delayed_notifications", nil)
+
+	notification := models.DelayedNotification{
+		Notification: "test message",
+		Delay:        10 * time.Second,
+		RecipientID:  "user-1",
+		Digest:       &models.DigestPolicy{Key: "reminders", Window: time.Hour},
+	}
+	end := time.Now().Add(notification.Delay).Truncate(time.Hour).Add(time.Hour)
+	id := digestID("user-1", "reminders", end)
+
+	// участники сводки проверяются отдельно от истории статусов
+	mockStorage.EXPECT().ListPush(gomock.Any(), digestMembersKey(id), gomock.Any(), gomock.Any()).Return(nil).Times(2)
+	allowStatusHistory(mockStorage)
+
+	t.Run("schedules_digest", func(t *testing.T) {
+		mockStorage.EXPECT().Add(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(3)
+		mockStorage.EXPECT().Get(gomock.Any(), "notification:"+id).Return("", models.ErrNotFound)
+		mockStorage.EXPECT().Add(gomock.Any(), "notification:"+id, gomock.Any(), gomock.Any()).Return(nil)
+		mockStorage.EXPECT().Add(gomock.Any(), "notification.status:"+id, gomock.Any(), gomock.Any()).Return(nil)
+		mockStorage.EXPECT().Add(gomock.Any(), "notification.original:"+id, gomock.Any(), gomock.Any()).Return(nil)
+		mockStorage.EXPECT().SortedSetAdd(gomock.Any(), "delayed_notifications", id, gomock.Any()).Return(nil)
+
+		uid, err := creator.ScheduleNotification(context.Background(), notification)
+		require.NoError(t, err)
+		assert.NotEqual(t, id, uid)
+	})
+
+	t.Run("joins_scheduled_digest", func(t *testing.T) {
+		mockStorage.EXPECT().Add(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(3)
+		mockStorage.EXPECT().Get(gomock.Any(), "notification:"+id).Return(`{"id":"digest"}`, nil)
+
+		_, err := creator.ScheduleNotification(context.Background(), notification)
+		require.NoError(t, err)
+	})
+}
+
+func TestNotificationCreator_ScheduleNotification_DigestRollback(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := allowStatusHistory(mock_usecase.NewMockstorage(ctrl))
+	creator := NewNotificationCreator(mockStorage, nil, nil, nil, "delayed_notifications", nil)
+
+	notification := models.DelayedNotification{
+		Notification: "test message",
+		Delay:        10 * time.Second,
+		RecipientID:  "user-1",
+		Digest:       &models.DigestPolicy{Key: "reminders", Window: time.Hour},
+	}
+	end := time.Now().Add(notification.Delay).Truncate(time.Hour).Add(time.Hour)
+	id := digestID("user-1", "reminders", end)
+
+	// уведомление не остается запланированным, если в сводку его добавить не удалось
+	mockStorage.EXPECT().Add(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(3)
+	mockStorage.EXPECT().ListPush(gomock.Any(), digestMembersKey(id), gomock.Any(), gomock.Any()).Return(errors.New("connection refused"))
+
+	var removed []string
+	mockStorage.EXPECT().Remove(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, key string) error {
+			removed = append(removed, key)
+			return nil
+		}).Times(6)
+
+	_, err := creator.ScheduleNotification(context.Background(), notification)
+	require.Error(t, err)
+
+	var prefixes []string
+	for _, key := range removed {
+		prefix, _, _ := strings.Cut(key, ":")
+		prefixes = append(prefixes, prefix)
+	}
+	assert.ElementsMatch(t, []string{
+		"notification", "notification.status", "notification.history", "notification.original", "notification.event", "notification.digest",
+	}, prefixes)
+}
+
+func TestNotificationCreator_CompleteDigest(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := mock_usecase.NewMockstorage(ctrl)
+	creator := NewNotificationCreator(mockStorage, nil, nil, nil, "delayed_notifications", nil)
+
+	member, err := json.Marshal(models.DelayedNotification{ID: "member-1", Notification: "first"})
+	require.NoError(t, err)
+
+	t.Run("sent", func(t *testing.T) {
+		mockStorage.EXPECT().ListRange(gomock.Any(), digestMembersKey("digest")).Return([]string{"member-1", "cancelled"}, nil)
+		mockStorage.EXPECT().Get(gomock.Any(), "notification:member-1").Return(string(member), nil)
+		mockStorage.EXPECT().Get(gomock.Any(), "notification:cancelled").Return("", models.ErrNotFound)
+		mockStorage.EXPECT().Add(gomock.Any(), "notification.status:member-1", string(models.StatusSentInDigest), gomock.Any()).Return(nil)
+		mockStorage.EXPECT().ListPushCapped(gomock.Any(), "notification.history:member-1", gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ string, value interface{}, _ int64, _ time.Duration) error {
+				assert.True(t, strings.Contains(string(value.([]byte)), `"note":"digest digest"`))
+				return nil
+			})
+		mockStorage.EXPECT().Remove(gomock.Any(), "notification:member-1").Return(nil)
+
+		require.NoError(t, creator.CompleteDigest(context.Background(), "digest", models.StatusSent))
+	})
+
+	t.Run("retrying", func(t *testing.T) {
+		require.NoError(t, creator.CompleteDigest(context.Background(), "digest", models.StatusRetrying))
+	})
+}
+
+func TestNotificationCreator_DropNotification_Digest(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := mock_usecase.NewMockstorage(ctrl)
+	creator := NewNotificationCreator(mockStorage, nil, nil, nil, "delayed_notifications", nil)
+
+	member, err := json.Marshal(models.DelayedNotification{ID: "member-1", Notification: "first"})
+	require.NoError(t, err)
+
+	// собранные в отброшенную сводку уведомления не остаются запланированными навсегда
+	mockStorage.EXPECT().Get(gomock.Any(), "notification:digest").Return(`{"id":"digest"}`, nil)
+	mockStorage.EXPECT().Remove(gomock.Any(), "notification:digest").Return(nil)
+	mockStorage.EXPECT().Remove(gomock.Any(), "notification.original:digest").Return(nil)
+	mockStorage.EXPECT().Add(gomock.Any(), "notification.status:digest", string(models.StatusDropped), gomock.Any()).Return(nil)
+	mockStorage.EXPECT().ListPushCapped(gomock.Any(), "notification.history:digest", gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	mockStorage.EXPECT().ListRange(gomock.Any(), digestMembersKey("digest")).Return([]string{"member-1"}, nil)
+	mockStorage.EXPECT().Get(gomock.Any(), "notification:member-1").Return(string(member), nil)
+	mockStorage.EXPECT().Add(gomock.Any(), "notification.status:member-1", string(models.StatusDropped), gomock.Any()).Return(nil)
+	mockStorage.EXPECT().ListPushCapped(gomock.Any(), "notification.history:member-1", gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	mockStorage.EXPECT().Remove(gomock.Any(), "notification:member-1").Return(nil)
+
+	digest := models.DelayedNotification{ID: "digest", RecipientID: "user-1", Digest: &models.DigestPolicy{Key: "reminders", Window: time.Hour}}
+	require.NoError(t, creator.DropNotification(context.Background(), digest, "quiet hours until 2025-03-14T07:00:00Z"))
+}
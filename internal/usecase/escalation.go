@@ -0,0 +1,184 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
+	"github.com/google/uuid"
+)
+
+// escalationPoliciesSetName множество айди политик эскалации, упорядоченное по времени создания.
+const escalationPoliciesSetName = "escalation_policies"
+
+var (
+	// ErrEscalationPolicyNotFound возвращается, если политики эскалации нет.
+	ErrEscalationPolicyNotFound = errors.New("escalation policy not found")
+
+	// ErrInvalidEscalationPolicy возвращается, если шаги политики заданы некорректно.
+	ErrInvalidEscalationPolicy = errors.New("invalid escalation policy")
+)
+
+type escalationStorage interface {
+	Add(ctx context.Context, key string, value interface{}, exp time.Duration) error
+	Get(ctx context.Context, key string) (string, error)
+	Remove(ctx context.Context, key string) error
+	SortedSetAdd(ctx context.Context, set string, value interface{}, score float64) error
+	SortedSetRangeByScore(ctx context.Context, key, min, max string, offset, count int64) ([]string, error)
+	SortedSetRemove(ctx context.Context, set string, value interface{}) error
+}
+
+// EscalationManager хранит именованные политики эскалации.
+// Уведомление копирует шаги политики при создании, поэтому изменение
+// и удаление политики не затрагивают уже запланированные уведомления.
+type EscalationManager struct {
+	storage escalationStorage
+}
+
+// NewEscalationManager создает новый EscalationManager.
+func NewEscalationManager(storage escalationStorage) *EscalationManager {
+	return &EscalationManager{storage: storage}
+}
+
+// CreateEscalationPolicy сохраняет новую политику эскалации.
+func (em *EscalationManager) CreateEscalationPolicy(ctx context.Context, policy models.EscalationPolicy) (models.EscalationPolicy, error) {
+	if err := validateEscalationSteps(policy.Steps); err != nil {
+		return models.EscalationPolicy{}, err
+	}
+
+	policy.ID = uuid.NewString()
+	policy.CreatedAt = time.Now().UTC()
+	policy.UpdatedAt = policy.CreatedAt
+
+	if err := em.save(ctx, policy); err != nil {
+		return models.EscalationPolicy{}, err
+	}
+
+	err := em.storage.SortedSetAdd(ctx, escalationPoliciesSetName, policy.ID, float64(policy.CreatedAt.UnixMilli()))
+	if err != nil {
+		return models.EscalationPolicy{}, err
+	}
+
+	return policy, nil
+}
+
+// UpdateEscalationPolicy заменяет шаги и название существующей политики.
+func (em *EscalationManager) UpdateEscalationPolicy(ctx context.Context, id string, policy models.EscalationPolicy) (models.EscalationPolicy, error) {
+	current, err := em.GetEscalationPolicy(ctx, id)
+	if err != nil {
+		return models.EscalationPolicy{}, err
+	}
+
+	if err := validateEscalationSteps(policy.Steps); err != nil {
+		return models.EscalationPolicy{}, err
+	}
+
+	policy.ID = id
+	policy.CreatedAt = current.CreatedAt
+	policy.UpdatedAt = time.Now().UTC()
+
+	if err := em.save(ctx, policy); err != nil {
+		return models.EscalationPolicy{}, err
+	}
+
+	return policy, nil
+}
+
+// GetEscalationPolicy возвращает политику эскалации по айди.
+func (em *EscalationManager) GetEscalationPolicy(ctx context.Context, id string) (models.EscalationPolicy, error) {
+	payload, err := em.storage.Get(ctx, escalationPolicyKey(id))
+	if errors.Is(err, models.ErrNotFound) {
+		return models.EscalationPolicy{}, fmt.Errorf("%w: %s", ErrEscalationPolicyNotFound, id)
+	}
+	if err != nil {
+		return models.EscalationPolicy{}, err
+	}
+
+	var policy models.EscalationPolicy
+	if err := json.Unmarshal([]byte(payload), &policy); err != nil {
+		return models.EscalationPolicy{}, err
+	}
+
+	return policy, nil
+}
+
+// ListEscalationPolicies возвращает все политики эскалации.
+func (em *EscalationManager) ListEscalationPolicies(ctx context.Context) ([]models.EscalationPolicy, error) {
+	ids, err := em.storage.SortedSetRangeByScore(ctx, escalationPoliciesSetName, "-inf", "+inf", 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	policies := make([]models.EscalationPolicy, 0, len(ids))
+	for _, id := range ids {
+		policy, err := em.GetEscalationPolicy(ctx, id)
+		if errors.Is(err, ErrEscalationPolicyNotFound) {
+			continue // удалена параллельно
+		}
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, policy)
+	}
+
+	return policies, nil
+}
+
+// DeleteEscalationPolicy удаляет политику эскалации.
+func (em *EscalationManager) DeleteEscalationPolicy(ctx context.Context, id string) error {
+	if _, err := em.GetEscalationPolicy(ctx, id); err != nil {
+		return err
+	}
+
+	if err := em.storage.SortedSetRemove(ctx, escalationPoliciesSetName, id); err != nil {
+		return err
+	}
+
+	return em.storage.Remove(ctx, escalationPolicyKey(id))
+}
+
+func (em *EscalationManager) save(ctx context.Context, policy models.EscalationPolicy) error {
+	payload, err := json.Marshal(policy)
+	if err != nil {
+		return err
+	}
+
+	return em.storage.Add(ctx, escalationPolicyKey(policy.ID), payload, 0)
+}
+
+func escalationPolicyKey(id string) string {
+	return "escalation_policy:" + id
+}
+
+// validateEscalationSteps проверяет, что первый шаг отправляется сразу,
+// шаги идут строго по возрастанию задержки и у каждого есть хотя бы один канал.
+func validateEscalationSteps(steps []models.EscalationStep) error {
+	if len(steps) == 0 {
+		return fmt.Errorf("%w: no steps", ErrInvalidEscalationPolicy)
+	}
+	if steps[0].DelaySeconds != 0 {
+		return fmt.Errorf("%w: first step must have zero delay", ErrInvalidEscalationPolicy)
+	}
+
+	for i, step := range steps {
+		if i > 0 && step.DelaySeconds <= steps[i-1].DelaySeconds {
+			return fmt.Errorf("%w: step %d must be delayed more than the previous one", ErrInvalidEscalationPolicy, i)
+		}
+		if !step.Channels.Any() {
+			return fmt.Errorf("%w: step %d has no channels", ErrInvalidEscalationPolicy, i)
+		}
+		if len(step.Channels.EmailChannel.Attachments) > 0 {
+			return fmt.Errorf("%w: step %d: attachments are not supported", ErrInvalidEscalationPolicy, i)
+		}
+	}
+
+	return nil
+}
+
+// escalationStepAt возвращает время отправки шага цепочки.
+func escalationStepAt(escalation *models.Escalation, step int) time.Time {
+	return escalation.StartedAt.Add(time.Duration(escalation.Steps[step].DelaySeconds) * time.Second)
+}
@@ -0,0 +1,99 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	mock_usecase "github.com/child6yo/wbtech-l3-delayed-notifyer/internal/usecase/mock"
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateEscalationSteps(t *testing.T) {
+	t.Parallel()
+
+	tg := models.Channels{TelegramChannel: models.TelegramChannel{ChatID: "1"}}
+	email := models.Channels{EmailChannel: models.EmailChannel{Email: "second@example.com"}}
+	webhook := models.Channels{WebhookChannel: models.WebhookChannel{URL: "https://chat.example.com/hook"}}
+
+	tests := []struct {
+		name    string
+		steps   []models.EscalationStep
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			steps: []models.EscalationStep{
+				{Channels: tg},
+				{DelaySeconds: 600, Channels: email},
+				{DelaySeconds: 1800, Channels: webhook},
+			},
+		},
+		{name: "no_steps", wantErr: true},
+		{name: "delayed_first_step", steps: []models.EscalationStep{{DelaySeconds: 60, Channels: tg}}, wantErr: true},
+		{
+			name:    "not_ascending",
+			steps:   []models.EscalationStep{{Channels: tg}, {DelaySeconds: 600, Channels: email}, {DelaySeconds: 600, Channels: webhook}},
+			wantErr: true,
+		},
+		{name: "no_channels", steps: []models.EscalationStep{{Channels: tg}, {DelaySeconds: 600}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateEscalationSteps(tt.steps)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidEscalationPolicy)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestNotificationCreator_ScheduleNotification_Escalation(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := allowStatusHistory(mock_usecase.NewMockstorage(ctrl))
+	mockPolicies := mock_usecase.NewMockescalationPolicyGetter(ctrl)
+	creator := NewNotificationCreator(mockStorage, nil, mockPolicies, nil, "delayed_notifications", nil)
+
+	steps := []models.EscalationStep{
+		{Channels: models.Channels{TelegramChannel: models.TelegramChannel{ChatID: "1"}}},
+		{DelaySeconds: 600, Channels: models.Channels{EmailChannel: models.EmailChannel{Email: "second@example.com"}}},
+	}
+	mockPolicies.EXPECT().GetEscalationPolicy(gomock.Any(), "oncall").Return(models.EscalationPolicy{ID: "oncall", Steps: steps}, nil)
+
+	var stored models.DelayedNotification
+	mockStorage.EXPECT().Add(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, key string, value interface{}, _ time.Duration) error {
+			if payload, ok := value.([]byte); ok {
+				require.NoError(t, json.Unmarshal(payload, &stored))
+			}
+			return nil
+		}).Times(3)
+	mockStorage.EXPECT().SortedSetAdd(gomock.Any(), "delayed_notifications", gomock.Any(), gomock.Any()).Return(nil)
+	mockStorage.EXPECT().SortedSetAdd(gomock.Any(), "chat.notifications:1", gomock.Any(), gomock.Any()).Return(nil)
+	mockStorage.EXPECT().SortedSetRemoveRangeByScore(gomock.Any(), "chat.notifications:1", "-inf", gomock.Any()).Return(nil)
+
+	_, err := creator.ScheduleNotification(context.Background(), models.DelayedNotification{
+		Notification: "Сервер недоступен",
+		Delay:        time.Minute,
+		Escalation:   &models.Escalation{PolicyID: "oncall"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, steps[0].Channels, stored.Channels)
+	require.NotNil(t, stored.Escalation)
+	assert.Equal(t, steps, stored.Escalation.Steps)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), stored.Escalation.StartedAt, time.Second)
+	require.NotNil(t, stored.Ack, "escalation is stopped by acknowledgement")
+	assert.Equal(t, steps[0].Channels, stored.Ack.Channels)
+}
@@ -0,0 +1,32 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
+)
+
+// statusHistorySize сколько последних изменений статуса хранится в истории уведомления.
+const statusHistorySize = 100
+
+type historyStorage interface {
+	Add(ctx context.Context, key string, value interface{}, exp time.Duration) error
+	ListPushCapped(ctx context.Context, key string, value interface{}, size int64, exp time.Duration) error
+}
+
+// setStatus сохраняет статус уведомления и добавляет его в историю статусов с пояснением note.
+// История хранится столько же, сколько статус, и не длиннее statusHistorySize.
+func setStatus(ctx context.Context, storage historyStorage, uid string, status models.NotificationStatus, note string, exp time.Duration) error {
+	if err := storage.Add(ctx, "notification.status:"+uid, string(status), exp); err != nil {
+		return err
+	}
+
+	change, err := json.Marshal(models.StatusChange{Status: status, At: time.Now().UTC(), Note: note})
+	if err != nil {
+		return err
+	}
+
+	return storage.ListPushCapped(ctx, "notification.history:"+uid, change, statusHistorySize, exp)
+}
@@ -0,0 +1,243 @@
+package usecase
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// localeFormat правила форматирования дат, чисел и множественного числа для языка.
+type localeFormat struct {
+	decimalSep  string
+	groupSep    string
+	months      [12]string // названия месяцев в родительном падеже, если язык его различает
+	dateLayout  func(t time.Time, month string) string
+	timeLayout  string
+	pluralIndex func(n int64) int // номер формы слова для числа n
+}
+
+var localeFormats = map[string]localeFormat{
+	"ru": {
+		decimalSep: ",",
+		groupSep:   "\u00a0", // неразрывный пробел
+		months: [12]string{
+			"января", "февраля", "марта", "апреля", "мая", "июня",
+			"июля", "августа", "сентября", "октября", "ноября", "декабря",
+		},
+		dateLayout: func(t time.Time, month string) string {
+			return fmt.Sprintf("%d %s %d г.", t.Day(), month, t.Year())
+		},
+		timeLayout:  "15:04",
+		pluralIndex: slavicPluralIndex,
+	},
+	"kk": {
+		decimalSep: ",",
+		groupSep:   "\u00a0", // неразрывный пробел
+		months: [12]string{
+			"қаңтар", "ақпан", "наурыз", "сәуір", "мамыр", "маусым",
+			"шілде", "тамыз", "қыркүйек", "қазан", "қараша", "желтоқсан",
+		},
+		dateLayout: func(t time.Time, month string) string {
+			return fmt.Sprintf("%d ж. %d %s", t.Year(), t.Day(), month)
+		},
+		timeLayout:  "15:04",
+		pluralIndex: oneOtherPluralIndex,
+	},
+	"en": {
+		decimalSep: ".",
+		groupSep:   ",",
+		months: [12]string{
+			"January", "February", "March", "April", "May", "June",
+			"July", "August", "September", "October", "November", "December",
+		},
+		dateLayout: func(t time.Time, month string) string {
+			return fmt.Sprintf("%s %d, %d", month, t.Day(), t.Year())
+		},
+		timeLayout:  "3:04 PM",
+		pluralIndex: oneOtherPluralIndex,
+	},
+}
+
+// formatFor возвращает правила форматирования языка, по умолчанию английского.
+func formatFor(locale string) localeFormat {
+	if f, ok := localeFormats[baseLanguage(locale)]; ok {
+		return f
+	}
+	return localeFormats["en"]
+}
+
+// templateFuncs функции шаблона, форматирующие значения по правилам языка:
+//
+//	{{date .when}}, {{datetime .when}}, {{number .amount}}, {{plural .count "товар" "товара" "товаров"}}
+//
+// Даты принимаются в формате RFC 3339 и выводятся в указанном в них часовом поясе.
+func templateFuncs(locale string) template.FuncMap {
+	f := formatFor(locale)
+
+	return template.FuncMap{
+		"date": func(v any) (string, error) {
+			t, err := toTime(v)
+			if err != nil {
+				return "", err
+			}
+			return f.dateLayout(t, f.months[t.Month()-1]), nil
+		},
+		"datetime": func(v any) (string, error) {
+			t, err := toTime(v)
+			if err != nil {
+				return "", err
+			}
+			return f.dateLayout(t, f.months[t.Month()-1]) + " " + t.Format(f.timeLayout), nil
+		},
+		"number": func(v any) (string, error) {
+			n, err := toFloat(v)
+			if err != nil {
+				return "", err
+			}
+			return f.formatNumber(n), nil
+		},
+		"plural": func(v any, forms ...string) (string, error) {
+			if len(forms) == 0 {
+				return "", fmt.Errorf("plural: no word forms")
+			}
+			n, err := toFloat(v)
+			if err != nil {
+				return "", err
+			}
+			i := f.pluralIndex(int64(math.Abs(n)))
+			return forms[min(i, len(forms)-1)], nil
+		},
+	}
+}
+
+// formatNumber форматирует число с разделителями разрядов и не более чем двумя знаками после запятой.
+func (f localeFormat) formatNumber(n float64) string {
+	s := strconv.FormatFloat(math.Abs(n), 'f', 2, 64)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+
+	intPart, fracPart, _ := strings.Cut(s, ".")
+
+	var b strings.Builder
+	if n < 0 && s != "0" {
+		b.WriteString("-")
+	}
+	for i, digit := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			b.WriteString(f.groupSep)
+		}
+		b.WriteRune(digit)
+	}
+	if fracPart != "" {
+		b.WriteString(f.decimalSep)
+		b.WriteString(fracPart)
+	}
+	return b.String()
+}
+
+// slavicPluralIndex формы для 1, 2-4 и 5-20: "товар", "товара", "товаров".
+func slavicPluralIndex(n int64) int {
+	switch {
+	case n%10 == 1 && n%100 != 11:
+		return 0
+	case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+		return 1
+	default:
+		return 2
+	}
+}
+
+// oneOtherPluralIndex формы для 1 и остальных чисел: "item", "items".
+func oneOtherPluralIndex(n int64) int {
+	if n == 1 {
+		return 0
+	}
+	return 1
+}
+
+func toTime(v any) (time.Time, error) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, nil
+	case string:
+		return time.Parse(time.RFC3339, t)
+	default:
+		return time.Time{}, fmt.Errorf("expected RFC 3339 date, got %T", v)
+	}
+}
+
+func toFloat(v any) (float64, error) {
+	switch n := v.(type) {
+	case float64:
+		return n, nil
+	case int:
+		return float64(n), nil
+	case int64:
+		return float64(n), nil
+	case string:
+		return strconv.ParseFloat(n, 64)
+	default:
+		return 0, fmt.Errorf("expected number, got %T", v)
+	}
+}
+
+// normalizeLocale приводит тег языка к виду "kk" или "kk-kz".
+func normalizeLocale(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
+
+// baseLanguage возвращает язык без региона: "kk-kz" -> "kk".
+func baseLanguage(locale string) string {
+	lang, _, _ := strings.Cut(normalizeLocale(locale), "-")
+	return lang
+}
+
+// localeChain возвращает языки, которые пробуются по порядку для запрошенного:
+// сам тег, его язык без региона и следующие за ним языки цепочки fallbacks.
+// Если язык не указан или его нет в цепочке, после него пробуются язык по умолчанию
+// и следующие за ним языки цепочки.
+func localeChain(locale, defaultLocale string, fallbacks []string) []string {
+	var chain []string
+	add := func(l string) {
+		for _, existing := range chain {
+			if existing == l {
+				return
+			}
+		}
+		chain = append(chain, l)
+	}
+
+	if l := normalizeLocale(locale); l != "" {
+		add(l)
+		add(baseLanguage(l))
+	}
+
+	start := fallbackIndex(fallbacks, baseLanguage(locale))
+	if start < 0 {
+		if l := normalizeLocale(defaultLocale); l != "" {
+			add(l)
+			add(baseLanguage(l))
+		}
+		start = fallbackIndex(fallbacks, baseLanguage(defaultLocale))
+	}
+	for _, l := range fallbacks[start+1:] {
+		add(normalizeLocale(l))
+	}
+
+	return chain
+}
+
+// fallbackIndex возвращает позицию языка в цепочке fallbacks или -1.
+func fallbackIndex(fallbacks []string, lang string) int {
+	if lang == "" {
+		return -1
+	}
+	for i, l := range fallbacks {
+		if normalizeLocale(l) == lang {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,55 @@
+package usecase
+
+import (
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocaleChain(t *testing.T) {
+	t.Parallel()
+
+	fallbacks := []string{"kk", "ru", "en"}
+
+	assert.Equal(t, []string{"kk-kz", "kk", "ru", "en"}, localeChain("kk_KZ", "ru", fallbacks))
+	assert.Equal(t, []string{"ru", "en"}, localeChain("ru", "ru", fallbacks))
+	assert.Equal(t, []string{"en"}, localeChain("EN", "ru", fallbacks))
+	assert.Equal(t, []string{"de", "ru", "en"}, localeChain("de", "ru", fallbacks))
+	assert.Equal(t, []string{"ru", "en"}, localeChain("", "ru", fallbacks))
+	assert.Equal(t, []string{"uz", "kk", "ru", "en"}, localeChain("", "uz", fallbacks))
+}
+
+func TestSlavicPluralIndex(t *testing.T) {
+	t.Parallel()
+
+	forms := []string{"заказ", "заказа", "заказов"}
+	cases := map[int64]string{0: "заказов", 1: "заказ", 2: "заказа", 5: "заказов", 11: "заказов", 14: "заказов", 21: "заказ", 22: "заказа", 111: "заказов"}
+	for n, want := range cases {
+		assert.Equal(t, want, forms[slavicPluralIndex(n)], n)
+	}
+}
+
+func TestTemplateFuncs(t *testing.T) {
+	t.Parallel()
+
+	const text = `{{date .when}}|{{datetime .when}}|{{number .sum}}|{{plural .n "a" "b"}}`
+	vars := map[string]any{"when": "2025-03-14T10:05:00+05:00", "sum": -1234567.891, "n": float64(1)}
+
+	tests := map[string]string{
+		"ru": "14 марта 2025 г.|14 марта 2025 г. 10:05|-1\u00a0234\u00a0567,89|a",
+		"kk": "2025 ж. 14 наурыз|2025 ж. 14 наурыз 10:05|-1\u00a0234\u00a0567,89|a",
+		"en": "March 14, 2025|March 14, 2025 10:05 AM|-1,234,567.89|a",
+	}
+
+	for locale, want := range tests {
+		tmpl, err := template.New(locale).Funcs(templateFuncs(locale)).Parse(text)
+		require.NoError(t, err)
+
+		var buf strings.Builder
+		require.NoError(t, tmpl.Execute(&buf, vars))
+		assert.Equal(t, want, buf.String(), locale)
+	}
+}
@@ -0,0 +1,122 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/usecase/ack.go
+
+// Package mock_usecase is a generated GoMock package.
+package mock_usecase
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockackStorage is a mock of ackStorage interface.
+type MockackStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockackStorageMockRecorder
+}
+
+// MockackStorageMockRecorder is the mock recorder for MockackStorage.
+type MockackStorageMockRecorder struct {
+	mock *MockackStorage
+}
+
+// NewMockackStorage creates a new mock instance.
+func NewMockackStorage(ctrl *gomock.Controller) *MockackStorage {
+	mock := &MockackStorage{ctrl: ctrl}
+	mock.recorder = &MockackStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockackStorage) EXPECT() *MockackStorageMockRecorder {
+	return m.recorder
+}
+
+// Add mocks base method.
+func (m *MockackStorage) Add(ctx context.Context, key string, value interface{}, exp time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Add", ctx, key, value, exp)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Add indicates an expected call of Add.
+func (mr *MockackStorageMockRecorder) Add(ctx, key, value, exp interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockackStorage)(nil).Add), ctx, key, value, exp)
+}
+
+// AddIfAbsent mocks base method.
+func (m *MockackStorage) AddIfAbsent(ctx context.Context, key string, value interface{}, exp time.Duration) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddIfAbsent", ctx, key, value, exp)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddIfAbsent indicates an expected call of AddIfAbsent.
+func (mr *MockackStorageMockRecorder) AddIfAbsent(ctx, key, value, exp interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddIfAbsent", reflect.TypeOf((*MockackStorage)(nil).AddIfAbsent), ctx, key, value, exp)
+}
+
+// Get mocks base method.
+func (m *MockackStorage) Get(ctx context.Context, key string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, key)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockackStorageMockRecorder) Get(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockackStorage)(nil).Get), ctx, key)
+}
+
+// ListPushCapped mocks base method.
+func (m *MockackStorage) ListPushCapped(ctx context.Context, key string, value interface{}, size int64, exp time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPushCapped", ctx, key, value, size, exp)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ListPushCapped indicates an expected call of ListPushCapped.
+func (mr *MockackStorageMockRecorder) ListPushCapped(ctx, key, value, size, exp interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPushCapped", reflect.TypeOf((*MockackStorage)(nil).ListPushCapped), ctx, key, value, size, exp)
+}
+
+// Remove mocks base method.
+func (m *MockackStorage) Remove(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Remove", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Remove indicates an expected call of Remove.
+func (mr *MockackStorageMockRecorder) Remove(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Remove", reflect.TypeOf((*MockackStorage)(nil).Remove), ctx, key)
+}
+
+// SortedSetRemove mocks base method.
+func (m *MockackStorage) SortedSetRemove(ctx context.Context, set string, value interface{}) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SortedSetRemove", ctx, set, value)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SortedSetRemove indicates an expected call of SortedSetRemove.
+func (mr *MockackStorageMockRecorder) SortedSetRemove(ctx, set, value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SortedSetRemove", reflect.TypeOf((*MockackStorage)(nil).SortedSetRemove), ctx, set, value)
+}
@@ -0,0 +1,89 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/usecase/attachment.go
+
+// Package mock_usecase is a generated GoMock package.
+package mock_usecase
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockattachmentStorage is a mock of attachmentStorage interface.
+type MockattachmentStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockattachmentStorageMockRecorder
+}
+
+// MockattachmentStorageMockRecorder is the mock recorder for MockattachmentStorage.
+type MockattachmentStorageMockRecorder struct {
+	mock *MockattachmentStorage
+}
+
+// NewMockattachmentStorage creates a new mock instance.
+func NewMockattachmentStorage(ctrl *gomock.Controller) *MockattachmentStorage {
+	mock := &MockattachmentStorage{ctrl: ctrl}
+	mock.recorder = &MockattachmentStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockattachmentStorage) EXPECT() *MockattachmentStorageMockRecorder {
+	return m.recorder
+}
+
+// Get mocks base method.
+func (m *MockattachmentStorage) Get(ctx context.Context, key string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, key)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockattachmentStorageMockRecorder) Get(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockattachmentStorage)(nil).Get), ctx, key)
+}
+
+// MockattachmentFetcher is a mock of attachmentFetcher interface.
+type MockattachmentFetcher struct {
+	ctrl     *gomock.Controller
+	recorder *MockattachmentFetcherMockRecorder
+}
+
+// MockattachmentFetcherMockRecorder is the mock recorder for MockattachmentFetcher.
+type MockattachmentFetcherMockRecorder struct {
+	mock *MockattachmentFetcher
+}
+
+// NewMockattachmentFetcher creates a new mock instance.
+func NewMockattachmentFetcher(ctrl *gomock.Controller) *MockattachmentFetcher {
+	mock := &MockattachmentFetcher{ctrl: ctrl}
+	mock.recorder = &MockattachmentFetcherMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockattachmentFetcher) EXPECT() *MockattachmentFetcherMockRecorder {
+	return m.recorder
+}
+
+// Fetch mocks base method.
+func (m *MockattachmentFetcher) Fetch(ctx context.Context, url string) ([]byte, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Fetch", ctx, url)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Fetch indicates an expected call of Fetch.
+func (mr *MockattachmentFetcherMockRecorder) Fetch(ctx, url interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Fetch", reflect.TypeOf((*MockattachmentFetcher)(nil).Fetch), ctx, url)
+}
@@ -9,6 +9,7 @@ import (
 	reflect "reflect"
 	time "time"
 
+	models "github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -49,6 +50,36 @@ func (mr *MockstorageMockRecorder) Add(ctx, key, value, exp interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*Mockstorage)(nil).Add), ctx, key, value, exp)
 }
 
+// AddIfAbsent mocks base method.
+func (m *Mockstorage) AddIfAbsent(ctx context.Context, key string, value interface{}, exp time.Duration) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddIfAbsent", ctx, key, value, exp)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddIfAbsent indicates an expected call of AddIfAbsent.
+func (mr *MockstorageMockRecorder) AddIfAbsent(ctx, key, value, exp interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddIfAbsent", reflect.TypeOf((*Mockstorage)(nil).AddIfAbsent), ctx, key, value, exp)
+}
+
+// CompareAndSwap mocks base method.
+func (m *Mockstorage) CompareAndSwap(ctx context.Context, key, old, value string, exp time.Duration) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompareAndSwap", ctx, key, old, value, exp)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompareAndSwap indicates an expected call of CompareAndSwap.
+func (mr *MockstorageMockRecorder) CompareAndSwap(ctx, key, old, value, exp interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompareAndSwap", reflect.TypeOf((*Mockstorage)(nil).CompareAndSwap), ctx, key, old, value, exp)
+}
+
 // Get mocks base method.
 func (m *Mockstorage) Get(ctx context.Context, key string) (string, error) {
 	m.ctrl.T.Helper()
@@ -64,6 +95,49 @@ func (mr *MockstorageMockRecorder) Get(ctx, key interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*Mockstorage)(nil).Get), ctx, key)
 }
 
+// ListPush mocks base method.
+func (m *Mockstorage) ListPush(ctx context.Context, key string, value interface{}, exp time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPush", ctx, key, value, exp)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ListPush indicates an expected call of ListPush.
+func (mr *MockstorageMockRecorder) ListPush(ctx, key, value, exp interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPush", reflect.TypeOf((*Mockstorage)(nil).ListPush), ctx, key, value, exp)
+}
+
+// ListPushCapped mocks base method.
+func (m *Mockstorage) ListPushCapped(ctx context.Context, key string, value interface{}, size int64, exp time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPushCapped", ctx, key, value, size, exp)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ListPushCapped indicates an expected call of ListPushCapped.
+func (mr *MockstorageMockRecorder) ListPushCapped(ctx, key, value, size, exp interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPushCapped", reflect.TypeOf((*Mockstorage)(nil).ListPushCapped), ctx, key, value, size, exp)
+}
+
+// ListRange mocks base method.
+func (m *Mockstorage) ListRange(ctx context.Context, key string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRange", ctx, key)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRange indicates an expected call of ListRange.
+func (mr *MockstorageMockRecorder) ListRange(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRange", reflect.TypeOf((*Mockstorage)(nil).ListRange), ctx, key)
+}
+
 // Remove mocks base method.
 func (m *Mockstorage) Remove(ctx context.Context, key string) error {
 	m.ctrl.T.Helper()
@@ -91,3 +165,175 @@ func (mr *MockstorageMockRecorder) SortedSetAdd(ctx, set, value, score interface
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SortedSetAdd", reflect.TypeOf((*Mockstorage)(nil).SortedSetAdd), ctx, set, value, score)
 }
+
+// SortedSetRangeByScore mocks base method.
+func (m *Mockstorage) SortedSetRangeByScore(ctx context.Context, key, min, max string, offset, count int64) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SortedSetRangeByScore", ctx, key, min, max, offset, count)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SortedSetRangeByScore indicates an expected call of SortedSetRangeByScore.
+func (mr *MockstorageMockRecorder) SortedSetRangeByScore(ctx, key, min, max, offset, count interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SortedSetRangeByScore", reflect.TypeOf((*Mockstorage)(nil).SortedSetRangeByScore), ctx, key, min, max, offset, count)
+}
+
+// SortedSetRemove mocks base method.
+func (m *Mockstorage) SortedSetRemove(ctx context.Context, set string, value interface{}) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SortedSetRemove", ctx, set, value)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SortedSetRemove indicates an expected call of SortedSetRemove.
+func (mr *MockstorageMockRecorder) SortedSetRemove(ctx, set, value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SortedSetRemove", reflect.TypeOf((*Mockstorage)(nil).SortedSetRemove), ctx, set, value)
+}
+
+// SortedSetRemoveRangeByScore mocks base method.
+func (m *Mockstorage) SortedSetRemoveRangeByScore(ctx context.Context, set, min, max string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SortedSetRemoveRangeByScore", ctx, set, min, max)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SortedSetRemoveRangeByScore indicates an expected call of SortedSetRemoveRangeByScore.
+func (mr *MockstorageMockRecorder) SortedSetRemoveRangeByScore(ctx, set, min, max interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SortedSetRemoveRangeByScore", reflect.TypeOf((*Mockstorage)(nil).SortedSetRemoveRangeByScore), ctx, set, min, max)
+}
+
+// SortedSetScore mocks base method.
+func (m *Mockstorage) SortedSetScore(ctx context.Context, set, value string) (float64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SortedSetScore", ctx, set, value)
+	ret0, _ := ret[0].(float64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SortedSetScore indicates an expected call of SortedSetScore.
+func (mr *MockstorageMockRecorder) SortedSetScore(ctx, set, value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SortedSetScore", reflect.TypeOf((*Mockstorage)(nil).SortedSetScore), ctx, set, value)
+}
+
+// MockescalationPolicyGetter is a mock of escalationPolicyGetter interface.
+type MockescalationPolicyGetter struct {
+	ctrl     *gomock.Controller
+	recorder *MockescalationPolicyGetterMockRecorder
+}
+
+// MockescalationPolicyGetterMockRecorder is the mock recorder for MockescalationPolicyGetter.
+type MockescalationPolicyGetterMockRecorder struct {
+	mock *MockescalationPolicyGetter
+}
+
+// NewMockescalationPolicyGetter creates a new mock instance.
+func NewMockescalationPolicyGetter(ctrl *gomock.Controller) *MockescalationPolicyGetter {
+	mock := &MockescalationPolicyGetter{ctrl: ctrl}
+	mock.recorder = &MockescalationPolicyGetterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockescalationPolicyGetter) EXPECT() *MockescalationPolicyGetterMockRecorder {
+	return m.recorder
+}
+
+// GetEscalationPolicy mocks base method.
+func (m *MockescalationPolicyGetter) GetEscalationPolicy(ctx context.Context, id string) (models.EscalationPolicy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEscalationPolicy", ctx, id)
+	ret0, _ := ret[0].(models.EscalationPolicy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEscalationPolicy indicates an expected call of GetEscalationPolicy.
+func (mr *MockescalationPolicyGetterMockRecorder) GetEscalationPolicy(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEscalationPolicy", reflect.TypeOf((*MockescalationPolicyGetter)(nil).GetEscalationPolicy), ctx, id)
+}
+
+// MockrecipientGetter is a mock of recipientGetter interface.
+type MockrecipientGetter struct {
+	ctrl     *gomock.Controller
+	recorder *MockrecipientGetterMockRecorder
+}
+
+// MockrecipientGetterMockRecorder is the mock recorder for MockrecipientGetter.
+type MockrecipientGetterMockRecorder struct {
+	mock *MockrecipientGetter
+}
+
+// NewMockrecipientGetter creates a new mock instance.
+func NewMockrecipientGetter(ctrl *gomock.Controller) *MockrecipientGetter {
+	mock := &MockrecipientGetter{ctrl: ctrl}
+	mock.recorder = &MockrecipientGetterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockrecipientGetter) EXPECT() *MockrecipientGetterMockRecorder {
+	return m.recorder
+}
+
+// GetRecipient mocks base method.
+func (m *MockrecipientGetter) GetRecipient(ctx context.Context, userID string) (models.Recipient, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRecipient", ctx, userID)
+	ret0, _ := ret[0].(models.Recipient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRecipient indicates an expected call of GetRecipient.
+func (mr *MockrecipientGetterMockRecorder) GetRecipient(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRecipient", reflect.TypeOf((*MockrecipientGetter)(nil).GetRecipient), ctx, userID)
+}
+
+// MocktemplateRenderer is a mock of templateRenderer interface.
+type MocktemplateRenderer struct {
+	ctrl     *gomock.Controller
+	recorder *MocktemplateRendererMockRecorder
+}
+
+// MocktemplateRendererMockRecorder is the mock recorder for MocktemplateRenderer.
+type MocktemplateRendererMockRecorder struct {
+	mock *MocktemplateRenderer
+}
+
+// NewMocktemplateRenderer creates a new mock instance.
+func NewMocktemplateRenderer(ctrl *gomock.Controller) *MocktemplateRenderer {
+	mock := &MocktemplateRenderer{ctrl: ctrl}
+	mock.recorder = &MocktemplateRendererMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MocktemplateRenderer) EXPECT() *MocktemplateRendererMockRecorder {
+	return m.recorder
+}
+
+// RenderTemplate mocks base method.
+func (m *MocktemplateRenderer) RenderTemplate(ctx context.Context, ref models.TemplateRef, locale string, tgParseMode models.TelegramParseMode) (models.RenderedTemplate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenderTemplate", ctx, ref, locale, tgParseMode)
+	ret0, _ := ret[0].(models.RenderedTemplate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RenderTemplate indicates an expected call of RenderTemplate.
+func (mr *MocktemplateRendererMockRecorder) RenderTemplate(ctx, ref, locale, tgParseMode interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenderTemplate", reflect.TypeOf((*MocktemplateRenderer)(nil).RenderTemplate), ctx, ref, locale, tgParseMode)
+}
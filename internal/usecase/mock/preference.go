@@ -0,0 +1,65 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/usecase/preference.go
+
+// Package mock_usecase is a generated GoMock package.
+package mock_usecase
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockpreferenceStorage is a mock of preferenceStorage interface.
+type MockpreferenceStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockpreferenceStorageMockRecorder
+}
+
+// MockpreferenceStorageMockRecorder is the mock recorder for MockpreferenceStorage.
+type MockpreferenceStorageMockRecorder struct {
+	mock *MockpreferenceStorage
+}
+
+// NewMockpreferenceStorage creates a new mock instance.
+func NewMockpreferenceStorage(ctrl *gomock.Controller) *MockpreferenceStorage {
+	mock := &MockpreferenceStorage{ctrl: ctrl}
+	mock.recorder = &MockpreferenceStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockpreferenceStorage) EXPECT() *MockpreferenceStorageMockRecorder {
+	return m.recorder
+}
+
+// Add mocks base method.
+func (m *MockpreferenceStorage) Add(ctx context.Context, key string, value interface{}, exp time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Add", ctx, key, value, exp)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Add indicates an expected call of Add.
+func (mr *MockpreferenceStorageMockRecorder) Add(ctx, key, value, exp interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockpreferenceStorage)(nil).Add), ctx, key, value, exp)
+}
+
+// Get mocks base method.
+func (m *MockpreferenceStorage) Get(ctx context.Context, key string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, key)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockpreferenceStorageMockRecorder) Get(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockpreferenceStorage)(nil).Get), ctx, key)
+}
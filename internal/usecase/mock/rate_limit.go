@@ -0,0 +1,51 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/usecase/rate_limit.go
+
+// Package mock_usecase is a generated GoMock package.
+package mock_usecase
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MocktokenBucket is a mock of tokenBucket interface.
+type MocktokenBucket struct {
+	ctrl     *gomock.Controller
+	recorder *MocktokenBucketMockRecorder
+}
+
+// MocktokenBucketMockRecorder is the mock recorder for MocktokenBucket.
+type MocktokenBucketMockRecorder struct {
+	mock *MocktokenBucket
+}
+
+// NewMocktokenBucket creates a new mock instance.
+func NewMocktokenBucket(ctrl *gomock.Controller) *MocktokenBucket {
+	mock := &MocktokenBucket{ctrl: ctrl}
+	mock.recorder = &MocktokenBucketMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MocktokenBucket) EXPECT() *MocktokenBucketMockRecorder {
+	return m.recorder
+}
+
+// TakeToken mocks base method.
+func (m *MocktokenBucket) TakeToken(ctx context.Context, key string, capacity int, window time.Duration) (time.Duration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TakeToken", ctx, key, capacity, window)
+	ret0, _ := ret[0].(time.Duration)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TakeToken indicates an expected call of TakeToken.
+func (mr *MocktokenBucketMockRecorder) TakeToken(ctx, key, capacity, window interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TakeToken", reflect.TypeOf((*MocktokenBucket)(nil).TakeToken), ctx, key, capacity, window)
+}
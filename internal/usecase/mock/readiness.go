@@ -0,0 +1,49 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/usecase/readiness.go
+
+// Package mock_usecase is a generated GoMock package.
+package mock_usecase
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockchannelPinger is a mock of channelPinger interface.
+type MockchannelPinger struct {
+	ctrl     *gomock.Controller
+	recorder *MockchannelPingerMockRecorder
+}
+
+// MockchannelPingerMockRecorder is the mock recorder for MockchannelPinger.
+type MockchannelPingerMockRecorder struct {
+	mock *MockchannelPinger
+}
+
+// NewMockchannelPinger creates a new mock instance.
+func NewMockchannelPinger(ctrl *gomock.Controller) *MockchannelPinger {
+	mock := &MockchannelPinger{ctrl: ctrl}
+	mock.recorder = &MockchannelPingerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockchannelPinger) EXPECT() *MockchannelPingerMockRecorder {
+	return m.recorder
+}
+
+// Ping mocks base method.
+func (m *MockchannelPinger) Ping(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ping", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ping indicates an expected call of Ping.
+func (mr *MockchannelPingerMockRecorder) Ping(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockchannelPinger)(nil).Ping), ctx)
+}
@@ -0,0 +1,122 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/usecase/recipient.go
+
+// Package mock_usecase is a generated GoMock package.
+package mock_usecase
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockrecipientStorage is a mock of recipientStorage interface.
+type MockrecipientStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockrecipientStorageMockRecorder
+}
+
+// MockrecipientStorageMockRecorder is the mock recorder for MockrecipientStorage.
+type MockrecipientStorageMockRecorder struct {
+	mock *MockrecipientStorage
+}
+
+// NewMockrecipientStorage creates a new mock instance.
+func NewMockrecipientStorage(ctrl *gomock.Controller) *MockrecipientStorage {
+	mock := &MockrecipientStorage{ctrl: ctrl}
+	mock.recorder = &MockrecipientStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockrecipientStorage) EXPECT() *MockrecipientStorageMockRecorder {
+	return m.recorder
+}
+
+// Add mocks base method.
+func (m *MockrecipientStorage) Add(ctx context.Context, key string, value interface{}, exp time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Add", ctx, key, value, exp)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Add indicates an expected call of Add.
+func (mr *MockrecipientStorageMockRecorder) Add(ctx, key, value, exp interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockrecipientStorage)(nil).Add), ctx, key, value, exp)
+}
+
+// Get mocks base method.
+func (m *MockrecipientStorage) Get(ctx context.Context, key string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, key)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockrecipientStorageMockRecorder) Get(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockrecipientStorage)(nil).Get), ctx, key)
+}
+
+// Remove mocks base method.
+func (m *MockrecipientStorage) Remove(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Remove", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Remove indicates an expected call of Remove.
+func (mr *MockrecipientStorageMockRecorder) Remove(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Remove", reflect.TypeOf((*MockrecipientStorage)(nil).Remove), ctx, key)
+}
+
+// SortedSetAdd mocks base method.
+func (m *MockrecipientStorage) SortedSetAdd(ctx context.Context, set string, value interface{}, score float64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SortedSetAdd", ctx, set, value, score)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SortedSetAdd indicates an expected call of SortedSetAdd.
+func (mr *MockrecipientStorageMockRecorder) SortedSetAdd(ctx, set, value, score interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SortedSetAdd", reflect.TypeOf((*MockrecipientStorage)(nil).SortedSetAdd), ctx, set, value, score)
+}
+
+// SortedSetRangeByScore mocks base method.
+func (m *MockrecipientStorage) SortedSetRangeByScore(ctx context.Context, key, min, max string, offset, count int64) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SortedSetRangeByScore", ctx, key, min, max, offset, count)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SortedSetRangeByScore indicates an expected call of SortedSetRangeByScore.
+func (mr *MockrecipientStorageMockRecorder) SortedSetRangeByScore(ctx, key, min, max, offset, count interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SortedSetRangeByScore", reflect.TypeOf((*MockrecipientStorage)(nil).SortedSetRangeByScore), ctx, key, min, max, offset, count)
+}
+
+// SortedSetRemove mocks base method.
+func (m *MockrecipientStorage) SortedSetRemove(ctx context.Context, set string, value interface{}) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SortedSetRemove", ctx, set, value)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SortedSetRemove indicates an expected call of SortedSetRemove.
+func (mr *MockrecipientStorageMockRecorder) SortedSetRemove(ctx, set, value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SortedSetRemove", reflect.TypeOf((*MockrecipientStorage)(nil).SortedSetRemove), ctx, set, value)
+}
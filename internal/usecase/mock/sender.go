@@ -9,6 +9,7 @@ import (
 	reflect "reflect"
 	time "time"
 
+	models "github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -49,6 +50,95 @@ func (mr *MockstorageAdderMockRecorder) Add(ctx, key, value, exp interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockstorageAdder)(nil).Add), ctx, key, value, exp)
 }
 
+// ListPushCapped mocks base method.
+func (m *MockstorageAdder) ListPushCapped(ctx context.Context, key string, value interface{}, size int64, exp time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPushCapped", ctx, key, value, size, exp)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ListPushCapped indicates an expected call of ListPushCapped.
+func (mr *MockstorageAdderMockRecorder) ListPushCapped(ctx, key, value, size, exp interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPushCapped", reflect.TypeOf((*MockstorageAdder)(nil).ListPushCapped), ctx, key, value, size, exp)
+}
+
+// MocknotificationRescheduler is a mock of notificationRescheduler interface.
+type MocknotificationRescheduler struct {
+	ctrl     *gomock.Controller
+	recorder *MocknotificationReschedulerMockRecorder
+}
+
+// MocknotificationReschedulerMockRecorder is the mock recorder for MocknotificationRescheduler.
+type MocknotificationReschedulerMockRecorder struct {
+	mock *MocknotificationRescheduler
+}
+
+// NewMocknotificationRescheduler creates a new mock instance.
+func NewMocknotificationRescheduler(ctrl *gomock.Controller) *MocknotificationRescheduler {
+	mock := &MocknotificationRescheduler{ctrl: ctrl}
+	mock.recorder = &MocknotificationReschedulerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MocknotificationRescheduler) EXPECT() *MocknotificationReschedulerMockRecorder {
+	return m.recorder
+}
+
+// RescheduleNotification mocks base method.
+func (m *MocknotificationRescheduler) RescheduleNotification(ctx context.Context, notification models.DelayedNotification, sendAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RescheduleNotification", ctx, notification, sendAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RescheduleNotification indicates an expected call of RescheduleNotification.
+func (mr *MocknotificationReschedulerMockRecorder) RescheduleNotification(ctx, notification, sendAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RescheduleNotification", reflect.TypeOf((*MocknotificationRescheduler)(nil).RescheduleNotification), ctx, notification, sendAt)
+}
+
+// MockattachmentLoader is a mock of attachmentLoader interface.
+type MockattachmentLoader struct {
+	ctrl     *gomock.Controller
+	recorder *MockattachmentLoaderMockRecorder
+}
+
+// MockattachmentLoaderMockRecorder is the mock recorder for MockattachmentLoader.
+type MockattachmentLoaderMockRecorder struct {
+	mock *MockattachmentLoader
+}
+
+// NewMockattachmentLoader creates a new mock instance.
+func NewMockattachmentLoader(ctrl *gomock.Controller) *MockattachmentLoader {
+	mock := &MockattachmentLoader{ctrl: ctrl}
+	mock.recorder = &MockattachmentLoaderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockattachmentLoader) EXPECT() *MockattachmentLoaderMockRecorder {
+	return m.recorder
+}
+
+// Load mocks base method.
+func (m *MockattachmentLoader) Load(ctx context.Context, attachments []models.Attachment) ([]models.Attachment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Load", ctx, attachments)
+	ret0, _ := ret[0].([]models.Attachment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Load indicates an expected call of Load.
+func (mr *MockattachmentLoaderMockRecorder) Load(ctx, attachments interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Load", reflect.TypeOf((*MockattachmentLoader)(nil).Load), ctx, attachments)
+}
+
 // MocktelegramSender is a mock of telegramSender interface.
 type MocktelegramSender struct {
 	ctrl     *gomock.Controller
@@ -73,17 +163,413 @@ func (m *MocktelegramSender) EXPECT() *MocktelegramSenderMockRecorder {
 }
 
 // Send mocks base method.
-func (m *MocktelegramSender) Send(chatID, data string) error {
+func (m *MocktelegramSender) Send(ctx context.Context, message models.TelegramMessage) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Send", ctx, message)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Send indicates an expected call of Send.
+func (mr *MocktelegramSenderMockRecorder) Send(ctx, message interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MocktelegramSender)(nil).Send), ctx, message)
+}
+
+// MockwebhookSender is a mock of webhookSender interface.
+type MockwebhookSender struct {
+	ctrl     *gomock.Controller
+	recorder *MockwebhookSenderMockRecorder
+}
+
+// MockwebhookSenderMockRecorder is the mock recorder for MockwebhookSender.
+type MockwebhookSenderMockRecorder struct {
+	mock *MockwebhookSender
+}
+
+// NewMockwebhookSender creates a new mock instance.
+func NewMockwebhookSender(ctrl *gomock.Controller) *MockwebhookSender {
+	mock := &MockwebhookSender{ctrl: ctrl}
+	mock.recorder = &MockwebhookSenderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockwebhookSender) EXPECT() *MockwebhookSenderMockRecorder {
+	return m.recorder
+}
+
+// Send mocks base method.
+func (m *MockwebhookSender) Send(ctx context.Context, message models.WebhookMessage) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Send", chatID, data)
+	ret := m.ctrl.Call(m, "Send", ctx, message)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Send indicates an expected call of Send.
-func (mr *MocktelegramSenderMockRecorder) Send(chatID, data interface{}) *gomock.Call {
+func (mr *MockwebhookSenderMockRecorder) Send(ctx, message interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockwebhookSender)(nil).Send), ctx, message)
+}
+
+// MockackTracker is a mock of ackTracker interface.
+type MockackTracker struct {
+	ctrl     *gomock.Controller
+	recorder *MockackTrackerMockRecorder
+}
+
+// MockackTrackerMockRecorder is the mock recorder for MockackTracker.
+type MockackTrackerMockRecorder struct {
+	mock *MockackTracker
+}
+
+// NewMockackTracker creates a new mock instance.
+func NewMockackTracker(ctrl *gomock.Controller) *MockackTracker {
+	mock := &MockackTracker{ctrl: ctrl}
+	mock.recorder = &MockackTrackerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockackTracker) EXPECT() *MockackTrackerMockRecorder {
+	return m.recorder
+}
+
+// AckCallbackData mocks base method.
+func (m *MockackTracker) AckCallbackData(uid string) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AckCallbackData", uid)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// AckCallbackData indicates an expected call of AckCallbackData.
+func (mr *MockackTrackerMockRecorder) AckCallbackData(uid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AckCallbackData", reflect.TypeOf((*MockackTracker)(nil).AckCallbackData), uid)
+}
+
+// AckLink mocks base method.
+func (m *MockackTracker) AckLink(uid, recipient string) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AckLink", uid, recipient)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// AckLink indicates an expected call of AckLink.
+func (mr *MockackTrackerMockRecorder) AckLink(uid, recipient interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AckLink", reflect.TypeOf((*MockackTracker)(nil).AckLink), uid, recipient)
+}
+
+// IsAcknowledged mocks base method.
+func (m *MockackTracker) IsAcknowledged(ctx context.Context, uid string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsAcknowledged", ctx, uid)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsAcknowledged indicates an expected call of IsAcknowledged.
+func (mr *MockackTrackerMockRecorder) IsAcknowledged(ctx, uid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsAcknowledged", reflect.TypeOf((*MockackTracker)(nil).IsAcknowledged), ctx, uid)
+}
+
+// MocksnoozeLinker is a mock of snoozeLinker interface.
+type MocksnoozeLinker struct {
+	ctrl     *gomock.Controller
+	recorder *MocksnoozeLinkerMockRecorder
+}
+
+// MocksnoozeLinkerMockRecorder is the mock recorder for MocksnoozeLinker.
+type MocksnoozeLinkerMockRecorder struct {
+	mock *MocksnoozeLinker
+}
+
+// NewMocksnoozeLinker creates a new mock instance.
+func NewMocksnoozeLinker(ctrl *gomock.Controller) *MocksnoozeLinker {
+	mock := &MocksnoozeLinker{ctrl: ctrl}
+	mock.recorder = &MocksnoozeLinkerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MocksnoozeLinker) EXPECT() *MocksnoozeLinkerMockRecorder {
+	return m.recorder
+}
+
+// SnoozeCallbackData mocks base method.
+func (m *MocksnoozeLinker) SnoozeCallbackData(uid string, d time.Duration) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SnoozeCallbackData", uid, d)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// SnoozeCallbackData indicates an expected call of SnoozeCallbackData.
+func (mr *MocksnoozeLinkerMockRecorder) SnoozeCallbackData(uid, d interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SnoozeCallbackData", reflect.TypeOf((*MocksnoozeLinker)(nil).SnoozeCallbackData), uid, d)
+}
+
+// SnoozeLink mocks base method.
+func (m *MocksnoozeLinker) SnoozeLink(uid string, d time.Duration) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SnoozeLink", uid, d)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// SnoozeLink indicates an expected call of SnoozeLink.
+func (mr *MocksnoozeLinkerMockRecorder) SnoozeLink(uid, d interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SnoozeLink", reflect.TypeOf((*MocksnoozeLinker)(nil).SnoozeLink), uid, d)
+}
+
+// MockpreferenceChecker is a mock of preferenceChecker interface.
+type MockpreferenceChecker struct {
+	ctrl     *gomock.Controller
+	recorder *MockpreferenceCheckerMockRecorder
+}
+
+// MockpreferenceCheckerMockRecorder is the mock recorder for MockpreferenceChecker.
+type MockpreferenceCheckerMockRecorder struct {
+	mock *MockpreferenceChecker
+}
+
+// NewMockpreferenceChecker creates a new mock instance.
+func NewMockpreferenceChecker(ctrl *gomock.Controller) *MockpreferenceChecker {
+	mock := &MockpreferenceChecker{ctrl: ctrl}
+	mock.recorder = &MockpreferenceCheckerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockpreferenceChecker) EXPECT() *MockpreferenceCheckerMockRecorder {
+	return m.recorder
+}
+
+// GetPreferences mocks base method.
+func (m *MockpreferenceChecker) GetPreferences(ctx context.Context, userID string) (models.Preferences, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPreferences", ctx, userID)
+	ret0, _ := ret[0].(models.Preferences)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPreferences indicates an expected call of GetPreferences.
+func (mr *MockpreferenceCheckerMockRecorder) GetPreferences(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPreferences", reflect.TypeOf((*MockpreferenceChecker)(nil).GetPreferences), ctx, userID)
+}
+
+// PreferencesLink mocks base method.
+func (m *MockpreferenceChecker) PreferencesLink(userID string) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PreferencesLink", userID)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// PreferencesLink indicates an expected call of PreferencesLink.
+func (mr *MockpreferenceCheckerMockRecorder) PreferencesLink(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PreferencesLink", reflect.TypeOf((*MockpreferenceChecker)(nil).PreferencesLink), userID)
+}
+
+// UnsubscribeLink mocks base method.
+func (m *MockpreferenceChecker) UnsubscribeLink(userID, category string) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnsubscribeLink", userID, category)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// UnsubscribeLink indicates an expected call of UnsubscribeLink.
+func (mr *MockpreferenceCheckerMockRecorder) UnsubscribeLink(userID, category interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnsubscribeLink", reflect.TypeOf((*MockpreferenceChecker)(nil).UnsubscribeLink), userID, category)
+}
+
+// MockrateLimiter is a mock of rateLimiter interface.
+type MockrateLimiter struct {
+	ctrl     *gomock.Controller
+	recorder *MockrateLimiterMockRecorder
+}
+
+// MockrateLimiterMockRecorder is the mock recorder for MockrateLimiter.
+type MockrateLimiterMockRecorder struct {
+	mock *MockrateLimiter
+}
+
+// NewMockrateLimiter creates a new mock instance.
+func NewMockrateLimiter(ctrl *gomock.Controller) *MockrateLimiter {
+	mock := &MockrateLimiter{ctrl: ctrl}
+	mock.recorder = &MockrateLimiterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockrateLimiter) EXPECT() *MockrateLimiterMockRecorder {
+	return m.recorder
+}
+
+// Take mocks base method.
+func (m *MockrateLimiter) Take(ctx context.Context, channel models.ChannelName, address string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Take", ctx, channel, address)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Take indicates an expected call of Take.
+func (mr *MockrateLimiterMockRecorder) Take(ctx, channel, address interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Take", reflect.TypeOf((*MockrateLimiter)(nil).Take), ctx, channel, address)
+}
+
+// MockchannelBreaker is a mock of channelBreaker interface.
+type MockchannelBreaker struct {
+	ctrl     *gomock.Controller
+	recorder *MockchannelBreakerMockRecorder
+}
+
+// MockchannelBreakerMockRecorder is the mock recorder for MockchannelBreaker.
+type MockchannelBreakerMockRecorder struct {
+	mock *MockchannelBreaker
+}
+
+// NewMockchannelBreaker creates a new mock instance.
+func NewMockchannelBreaker(ctrl *gomock.Controller) *MockchannelBreaker {
+	mock := &MockchannelBreaker{ctrl: ctrl}
+	mock.recorder = &MockchannelBreakerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockchannelBreaker) EXPECT() *MockchannelBreakerMockRecorder {
+	return m.recorder
+}
+
+// Allow mocks base method.
+func (m *MockchannelBreaker) Allow(name models.ChannelName) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Allow", name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Allow indicates an expected call of Allow.
+func (mr *MockchannelBreakerMockRecorder) Allow(name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Allow", reflect.TypeOf((*MockchannelBreaker)(nil).Allow), name)
+}
+
+// Record mocks base method.
+func (m *MockchannelBreaker) Record(name models.ChannelName, err error) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Record", name, err)
+}
+
+// Record indicates an expected call of Record.
+func (mr *MockchannelBreakerMockRecorder) Record(name, err interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Record", reflect.TypeOf((*MockchannelBreaker)(nil).Record), name, err)
+}
+
+// MockdigestStore is a mock of digestStore interface.
+type MockdigestStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockdigestStoreMockRecorder
+}
+
+// MockdigestStoreMockRecorder is the mock recorder for MockdigestStore.
+type MockdigestStoreMockRecorder struct {
+	mock *MockdigestStore
+}
+
+// NewMockdigestStore creates a new mock instance.
+func NewMockdigestStore(ctrl *gomock.Controller) *MockdigestStore {
+	mock := &MockdigestStore{ctrl: ctrl}
+	mock.recorder = &MockdigestStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockdigestStore) EXPECT() *MockdigestStoreMockRecorder {
+	return m.recorder
+}
+
+// CompleteDigest mocks base method.
+func (m *MockdigestStore) CompleteDigest(ctx context.Context, digestID string, status models.NotificationStatus) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteDigest", ctx, digestID, status)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CompleteDigest indicates an expected call of CompleteDigest.
+func (mr *MockdigestStoreMockRecorder) CompleteDigest(ctx, digestID, status interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteDigest", reflect.TypeOf((*MockdigestStore)(nil).CompleteDigest), ctx, digestID, status)
+}
+
+// DigestMembers mocks base method.
+func (m *MockdigestStore) DigestMembers(ctx context.Context, digestID string) ([]models.DelayedNotification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DigestMembers", ctx, digestID)
+	ret0, _ := ret[0].([]models.DelayedNotification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DigestMembers indicates an expected call of DigestMembers.
+func (mr *MockdigestStoreMockRecorder) DigestMembers(ctx, digestID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DigestMembers", reflect.TypeOf((*MockdigestStore)(nil).DigestMembers), ctx, digestID)
+}
+
+// MockchatMuteChecker is a mock of chatMuteChecker interface.
+type MockchatMuteChecker struct {
+	ctrl     *gomock.Controller
+	recorder *MockchatMuteCheckerMockRecorder
+}
+
+// MockchatMuteCheckerMockRecorder is the mock recorder for MockchatMuteChecker.
+type MockchatMuteCheckerMockRecorder struct {
+	mock *MockchatMuteChecker
+}
+
+// NewMockchatMuteChecker creates a new mock instance.
+func NewMockchatMuteChecker(ctrl *gomock.Controller) *MockchatMuteChecker {
+	mock := &MockchatMuteChecker{ctrl: ctrl}
+	mock.recorder = &MockchatMuteCheckerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockchatMuteChecker) EXPECT() *MockchatMuteCheckerMockRecorder {
+	return m.recorder
+}
+
+// IsChatMuted mocks base method.
+func (m *MockchatMuteChecker) IsChatMuted(ctx context.Context, chatID string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsChatMuted", ctx, chatID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsChatMuted indicates an expected call of IsChatMuted.
+func (mr *MockchatMuteCheckerMockRecorder) IsChatMuted(ctx, chatID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MocktelegramSender)(nil).Send), chatID, data)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsChatMuted", reflect.TypeOf((*MockchatMuteChecker)(nil).IsChatMuted), ctx, chatID)
 }
 
 // MockemailSender is a mock of emailSender interface.
@@ -110,15 +596,15 @@ func (m *MockemailSender) EXPECT() *MockemailSenderMockRecorder {
 }
 
 // Send mocks base method.
-func (m *MockemailSender) Send(emailAddr, data string) error {
+func (m *MockemailSender) Send(ctx context.Context, email models.EmailMessage) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Send", emailAddr, data)
+	ret := m.ctrl.Call(m, "Send", ctx, email)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Send indicates an expected call of Send.
-func (mr *MockemailSenderMockRecorder) Send(emailAddr, data interface{}) *gomock.Call {
+func (mr *MockemailSenderMockRecorder) Send(ctx, email interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockemailSender)(nil).Send), emailAddr, data)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockemailSender)(nil).Send), ctx, email)
 }
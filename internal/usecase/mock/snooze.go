@@ -0,0 +1,51 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/usecase/snooze.go
+
+// Package mock_usecase is a generated GoMock package.
+package mock_usecase
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MocknotificationSnoozer is a mock of notificationSnoozer interface.
+type MocknotificationSnoozer struct {
+	ctrl     *gomock.Controller
+	recorder *MocknotificationSnoozerMockRecorder
+}
+
+// MocknotificationSnoozerMockRecorder is the mock recorder for MocknotificationSnoozer.
+type MocknotificationSnoozerMockRecorder struct {
+	mock *MocknotificationSnoozer
+}
+
+// NewMocknotificationSnoozer creates a new mock instance.
+func NewMocknotificationSnoozer(ctrl *gomock.Controller) *MocknotificationSnoozer {
+	mock := &MocknotificationSnoozer{ctrl: ctrl}
+	mock.recorder = &MocknotificationSnoozerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MocknotificationSnoozer) EXPECT() *MocknotificationSnoozerMockRecorder {
+	return m.recorder
+}
+
+// SnoozeNotification mocks base method.
+func (m *MocknotificationSnoozer) SnoozeNotification(ctx context.Context, uid string, sendAt time.Time) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SnoozeNotification", ctx, uid, sendAt)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SnoozeNotification indicates an expected call of SnoozeNotification.
+func (mr *MocknotificationSnoozerMockRecorder) SnoozeNotification(ctx, uid, sendAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SnoozeNotification", reflect.TypeOf((*MocknotificationSnoozer)(nil).SnoozeNotification), ctx, uid, sendAt)
+}
@@ -0,0 +1,137 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/usecase/template.go
+
+// Package mock_usecase is a generated GoMock package.
+package mock_usecase
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MocktemplateStorage is a mock of templateStorage interface.
+type MocktemplateStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MocktemplateStorageMockRecorder
+}
+
+// MocktemplateStorageMockRecorder is the mock recorder for MocktemplateStorage.
+type MocktemplateStorageMockRecorder struct {
+	mock *MocktemplateStorage
+}
+
+// NewMocktemplateStorage creates a new mock instance.
+func NewMocktemplateStorage(ctrl *gomock.Controller) *MocktemplateStorage {
+	mock := &MocktemplateStorage{ctrl: ctrl}
+	mock.recorder = &MocktemplateStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MocktemplateStorage) EXPECT() *MocktemplateStorageMockRecorder {
+	return m.recorder
+}
+
+// Add mocks base method.
+func (m *MocktemplateStorage) Add(ctx context.Context, key string, value interface{}, exp time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Add", ctx, key, value, exp)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Add indicates an expected call of Add.
+func (mr *MocktemplateStorageMockRecorder) Add(ctx, key, value, exp interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MocktemplateStorage)(nil).Add), ctx, key, value, exp)
+}
+
+// Get mocks base method.
+func (m *MocktemplateStorage) Get(ctx context.Context, key string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, key)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MocktemplateStorageMockRecorder) Get(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MocktemplateStorage)(nil).Get), ctx, key)
+}
+
+// Increment mocks base method.
+func (m *MocktemplateStorage) Increment(ctx context.Context, key string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Increment", ctx, key)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Increment indicates an expected call of Increment.
+func (mr *MocktemplateStorageMockRecorder) Increment(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Increment", reflect.TypeOf((*MocktemplateStorage)(nil).Increment), ctx, key)
+}
+
+// Remove mocks base method.
+func (m *MocktemplateStorage) Remove(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Remove", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Remove indicates an expected call of Remove.
+func (mr *MocktemplateStorageMockRecorder) Remove(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Remove", reflect.TypeOf((*MocktemplateStorage)(nil).Remove), ctx, key)
+}
+
+// SortedSetAdd mocks base method.
+func (m *MocktemplateStorage) SortedSetAdd(ctx context.Context, set string, value interface{}, score float64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SortedSetAdd", ctx, set, value, score)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SortedSetAdd indicates an expected call of SortedSetAdd.
+func (mr *MocktemplateStorageMockRecorder) SortedSetAdd(ctx, set, value, score interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SortedSetAdd", reflect.TypeOf((*MocktemplateStorage)(nil).SortedSetAdd), ctx, set, value, score)
+}
+
+// SortedSetRangeByScore mocks base method.
+func (m *MocktemplateStorage) SortedSetRangeByScore(ctx context.Context, key, min, max string, offset, count int64) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SortedSetRangeByScore", ctx, key, min, max, offset, count)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SortedSetRangeByScore indicates an expected call of SortedSetRangeByScore.
+func (mr *MocktemplateStorageMockRecorder) SortedSetRangeByScore(ctx, key, min, max, offset, count interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SortedSetRangeByScore", reflect.TypeOf((*MocktemplateStorage)(nil).SortedSetRangeByScore), ctx, key, min, max, offset, count)
+}
+
+// SortedSetRemove mocks base method.
+func (m *MocktemplateStorage) SortedSetRemove(ctx context.Context, set string, value interface{}) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SortedSetRemove", ctx, set, value)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SortedSetRemove indicates an expected call of SortedSetRemove.
+func (mr *MocktemplateStorageMockRecorder) SortedSetRemove(ctx, set, value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SortedSetRemove", reflect.TypeOf((*MocktemplateStorage)(nil).SortedSetRemove), ctx, set, value)
+}
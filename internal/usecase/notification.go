@@ -3,6 +3,7 @@ package usecase
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -10,57 +11,384 @@ import (
 	"github.com/google/uuid"
 )
 
+// eventUIDSuffix домен уникальных идентификаторов событий в календаре.
+const eventUIDSuffix = "@delayed-notifyer"
+
+var (
+	// ErrNotificationNotFound возвращается, если уведомления нет или срок его хранения истек.
+	ErrNotificationNotFound = errors.New("notification not found")
+
+	// ErrInvalidSnoozeTime возвращается, если уведомление откладывается в прошлое или слишком далеко.
+	ErrInvalidSnoozeTime = errors.New("invalid snooze time")
+)
+
 type storage interface {
 	Add(ctx context.Context, key string, value interface{}, exp time.Duration) error
+	AddIfAbsent(ctx context.Context, key string, value interface{}, exp time.Duration) (bool, error)
+	CompareAndSwap(ctx context.Context, key, old, value string, exp time.Duration) (bool, error)
 	Get(ctx context.Context, key string) (string, error)
 	Remove(ctx context.Context, key string) error
 	SortedSetAdd(ctx context.Context, set string, value interface{}, score float64) error
+	SortedSetRangeByScore(ctx context.Context, key, min, max string, offset, count int64) ([]string, error)
+	SortedSetScore(ctx context.Context, set string, value string) (float64, error)
+	SortedSetRemove(ctx context.Context, set string, value interface{}) error
+	SortedSetRemoveRangeByScore(ctx context.Context, set, min, max string) error
+	ListPush(ctx context.Context, key string, value interface{}, exp time.Duration) error
+	ListPushCapped(ctx context.Context, key string, value interface{}, size int64, exp time.Duration) error
+	ListRange(ctx context.Context, key string) ([]string, error)
+}
+
+type escalationPolicyGetter interface {
+	GetEscalationPolicy(ctx context.Context, id string) (models.EscalationPolicy, error)
+}
+
+type recipientGetter interface {
+	GetRecipient(ctx context.Context, userID string) (models.Recipient, error)
+}
+
+type errorLogger interface {
+	Error(err error)
+}
+
+type templateRenderer interface {
+	RenderTemplate(ctx context.Context, ref models.TemplateRef, locale string, tgParseMode models.TelegramParseMode) (models.RenderedTemplate, error)
 }
 
 // NotificationCreator отвечает за логику создания новых уведомлений в отложенной очереди.
 type NotificationCreator struct {
-	storage        storage // место хранения отложенной очереди.
-	delayedSetName string  // название очереди
+	storage        storage                // место хранения отложенной очереди.
+	templates      templateRenderer       // шаблоны, на которые ссылаются уведомления
+	escalations    escalationPolicyGetter // политики эскалации, на которые ссылаются уведомления
+	recipients     recipientGetter        // реестр получателей, которым адресуются уведомления
+	delayedSetName string                 // название очереди
+	logger         errorLogger            // ошибки, которые не мешают запланировать уведомление
 }
 
 // NewNotificationCreator создает новый NotificationCreator.
-func NewNotificationCreator(storage storage, delayedSetName string) *NotificationCreator {
-	return &NotificationCreator{storage: storage, delayedSetName: delayedSetName}
+func NewNotificationCreator(
+	storage storage, templates templateRenderer, escalations escalationPolicyGetter, recipients recipientGetter, delayedSetName string,
+	logger errorLogger,
+) *NotificationCreator {
+	return &NotificationCreator{
+		storage:        storage,
+		templates:      templates,
+		escalations:    escalations,
+		recipients:     recipients,
+		delayedSetName: delayedSetName,
+		logger:         logger,
+	}
 }
 
 // ScheduleNotification кладет новое уведомление в отложенную очередь.
 // Вощврашает айди запланнированного уведомления.
+// Если уведомление с тем же ключом схлопывания еще запланировано, оно заменяется новым
+// или, с политикой ignore, новое не создается и возвращается айди запланированного.
 func (nc *NotificationCreator) ScheduleNotification(ctx context.Context, notification models.DelayedNotification) (string, error) {
-	uid := uuid.NewString()
-	notification.ID = uid
+	notification.ID = uuid.NewString()
+
+	var collapsed string
+	if notification.Collapse != nil {
+		pending, replaced, err := nc.claimCollapseKey(ctx, notification)
+		if err != nil {
+			return "", err
+		}
+		if pending != "" {
+			return pending, nil
+		}
+		collapsed = replaced
+	}
+
+	if err := nc.schedule(ctx, notification); err != nil {
+		if notification.Collapse != nil {
+			_ = nc.restoreCollapseIndex(ctx, notification, collapsed)
+		}
+		return "", err
+	}
 
+	// заменяемое уведомление отменяется только после того, как новое запланировано.
+	// Новое уже будет отправлено, поэтому его айди возвращается, даже если старое отменить не удалось:
+	// например, поллер успел забрать его на отправку
+	if collapsed != "" {
+		if err := nc.RemoveNotification(ctx, collapsed, false); err != nil && !errors.Is(err, models.ErrNotFound) {
+			nc.logError(fmt.Errorf("failed to remove collapsed notification %s: %w", collapsed, err))
+		}
+	}
+
+	return notification.ID, nil
+}
+
+// schedule готовит новое уведомление к отправке и кладет его в отложенную очередь.
+func (nc *NotificationCreator) schedule(ctx context.Context, notification models.DelayedNotification) error {
+	if err := nc.startEscalation(ctx, &notification); err != nil {
+		return err
+	}
+
+	if err := nc.pinTemplate(ctx, &notification); err != nil {
+		return err
+	}
+
+	attachments, err := nc.storeAttachments(ctx, notification)
+	if err != nil {
+		return err
+	}
+	notification.Channels.EmailChannel.Attachments = attachments
+
+	if notification.Ack != nil {
+		// повторные напоминания идут по всем каналам, даже если до них часть каналов уйдет в повторные попытки
+		ack := *notification.Ack
+		ack.Repeat = 0
+		ack.Channels = notification.Channels
+		notification.Ack = &ack
+	}
+
+	if notification.Event != nil && notification.Event.Method == "" {
+		notification.Event.UID = notification.ID + eventUIDSuffix
+		notification.Event.Method = models.EventRequest
+	}
+
+	if notification.Digest != nil {
+		return nc.enqueueDigest(ctx, notification)
+	}
+	return nc.enqueue(ctx, notification)
+}
+
+// ScheduleRecipientNotifications планирует по уведомлению на каждого получателя из реестра.
+// Адреса получателей подставляются при отправке, так что их изменение применяется и к запланированным уведомлениям.
+// Возвращает айди уведомлений по айди получателей. Если запланировать не удалось, уже созданные уведомления удаляются.
+func (nc *NotificationCreator) ScheduleRecipientNotifications(
+	ctx context.Context, notification models.DelayedNotification, recipientIDs []string,
+) (map[string]string, error) {
+	for _, id := range recipientIDs {
+		if _, err := nc.recipients.GetRecipient(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+
+	uids := make(map[string]string, len(recipientIDs))
+	for _, id := range recipientIDs {
+		n := notification
+		n.RecipientID = id
+
+		uid, err := nc.ScheduleNotification(ctx, n)
+		if err != nil {
+			for _, created := range uids {
+				_ = nc.RemoveNotification(ctx, created, false)
+			}
+			return nil, err
+		}
+		uids[id] = uid
+	}
+
+	return uids, nil
+}
+
+// enqueue сохраняет подготовленное уведомление и кладет его в отложенную очередь.
+func (nc *NotificationCreator) enqueue(ctx context.Context, notification models.DelayedNotification) error {
 	payload, err := json.Marshal(notification)
 	if err != nil {
-		return "", err
+		return err
 	}
 
 	err = nc.storage.Add(ctx, "notification:"+notification.ID, payload, notification.Delay+24*time.Hour)
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	err = nc.storage.Add(ctx, "notification.status:"+notification.ID, string(models.StatusScheduled), notification.Delay+168*time.Hour)
+	err = setStatus(ctx, nc.storage, notification.ID, models.StatusScheduled, "", notification.Delay+168*time.Hour)
 	if err != nil {
-		return "", err
+		nc.discardStored(ctx, notification)
+		return err
+	}
+
+	// поллер удаляет payload перед отправкой, а копия нужна, чтобы отложить уже отправленное уведомление
+	err = nc.storage.Add(ctx, "notification.original:"+notification.ID, payload, notification.Delay+168*time.Hour)
+	if err != nil {
+		nc.discardStored(ctx, notification)
+		return err
+	}
+
+	// заготовка отмены сохраняется до постановки в очередь, чтобы не отправить приглашение, которое нельзя отменить
+	if err := nc.storeEventCancellation(ctx, notification); err != nil {
+		nc.discardStored(ctx, notification)
+		return err
+	}
+
+	if err := nc.indexChatNotification(ctx, notification); err != nil {
+		nc.discardStored(ctx, notification)
+		return err
 	}
 
 	sendAtTimestamp := time.Now().Add(notification.Delay).UnixMilli()
 	err = nc.storage.SortedSetAdd(
 		ctx, nc.delayedSetName, notification.ID, float64(sendAtTimestamp))
 	if err != nil {
-		// на данный момент обеспечивает атомарность операции
-		// т.е. удаляет payload по ключу в случае ошибки при добавлении в sorted set
+		nc.discardStored(ctx, notification)
+		return err
+	}
+
+	return nil
+}
+
+// discardStored на данный момент обеспечивает атомарность постановки в очередь:
+// удаляет сохраненное для уведомления, если поставить его в очередь не удалось.
+func (nc *NotificationCreator) discardStored(ctx context.Context, notification models.DelayedNotification) {
+	uid := notification.ID
+	_ = nc.storage.Remove(ctx, "notification:"+uid)
+	_ = nc.storage.Remove(ctx, "notification.status:"+uid)
+	_ = nc.storage.Remove(ctx, "notification.history:"+uid)
+	_ = nc.storage.Remove(ctx, "notification.original:"+uid)
+	_ = nc.storage.Remove(ctx, "notification.event:"+uid)
+	if chatID := notification.Channels.TelegramChannel.ChatID; chatID != "" {
+		_ = nc.storage.SortedSetRemove(ctx, chatNotificationsKey(chatID), uid)
+	}
+}
+
+// storeEventCancellation сохраняет заготовку отмены события, приглашение на которое
+// уйдет с уведомлением. Она хранится до окончания события, даже после отправки уведомления,
+// чтобы отмену можно было разослать по DELETE /notify/{id}.
+func (nc *NotificationCreator) storeEventCancellation(ctx context.Context, notification models.DelayedNotification) error {
+	event := notification.Event
+	if event == nil || event.Method != models.EventRequest || notification.Channels.EmailChannel.Email == "" {
+		return nil
+	}
+
+	cancelEvent := *event
+	cancelEvent.Method = models.EventCancel
+	cancelEvent.Sequence++
+
+	cancellation := models.DelayedNotification{
+		Notification: notification.Notification,
+		Channels: models.Channels{
+			EmailChannel: models.EmailChannel{
+				Email:   notification.Channels.EmailChannel.Email,
+				Subject: notification.Channels.EmailChannel.Subject,
+			},
+		},
+		Event:    &cancelEvent,
+		Template: notification.Template,
+	}
+
+	payload, err := json.Marshal(cancellation)
+	if err != nil {
+		return err
+	}
+
+	exp := max(time.Until(event.End), notification.Delay) + 24*time.Hour
+	return nc.storage.Add(ctx, "notification.event:"+notification.ID, payload, exp)
+}
+
+// startEscalation копирует в уведомление шаги его политики эскалации и делает первый шаг каналами уведомления.
+// Эскалация останавливается подтверждением получения, поэтому оно требуется всегда.
+func (nc *NotificationCreator) startEscalation(ctx context.Context, notification *models.DelayedNotification) error {
+	if notification.Escalation == nil {
+		return nil
+	}
+
+	policy, err := nc.escalations.GetEscalationPolicy(ctx, notification.Escalation.PolicyID)
+	if err != nil {
+		return err
+	}
+
+	notification.Escalation = &models.Escalation{
+		PolicyID:  policy.ID,
+		Steps:     policy.Steps,
+		StartedAt: time.Now().Add(notification.Delay).UTC(),
+	}
+	notification.Channels = policy.Steps[0].Channels
+	if notification.Ack == nil {
+		notification.Ack = &models.AckPolicy{}
+	}
+
+	return nil
+}
+
+// pinTemplate закрепляет за уведомлением версию шаблона, актуальную на момент создания,
+// и проверяет, что шаблон заполняется переданными переменными для всех каналов.
+func (nc *NotificationCreator) pinTemplate(ctx context.Context, notification *models.DelayedNotification) error {
+	ref := notification.Template
+	if ref == nil {
+		return nil
+	}
+
+	rendered, err := nc.templates.RenderTemplate(
+		ctx, *ref, notification.Locale, notification.Channels.TelegramChannel.ParseMode)
+	if err != nil {
+		return err
+	}
+	for _, channels := range notificationChannels(*notification) {
+		n := *notification
+		n.Channels = channels
+		if err := newNotificationContent(n, &rendered).validate(channels); err != nil {
+			return err
+		}
+	}
+
+	pinned := *ref
+	pinned.Version = rendered.Version
+	notification.Template = &pinned
+	return nil
+}
+
+// notificationChannels возвращает каналы уведомления и всех шагов его эскалации.
+func notificationChannels(notification models.DelayedNotification) []models.Channels {
+	channels := []models.Channels{notification.Channels}
+	if notification.Escalation != nil {
+		for _, step := range notification.Escalation.Steps[1:] {
+			channels = append(channels, step.Channels)
+		}
+	}
+	return channels
+}
+
+// storeAttachments сохраняет переданное в запросе содержимое вложений отдельно от уведомления,
+// чтобы поллер не гонял его через очередь. Возвращает вложения со ссылками на хранилище.
+// Содержимое хранится столько же, сколько статус, и удаляется по истечении срока.
+func (nc *NotificationCreator) storeAttachments(ctx context.Context, notification models.DelayedNotification) ([]models.Attachment, error) {
+	src := notification.Channels.EmailChannel.Attachments
+	if len(src) == 0 {
+		return nil, nil
+	}
+
+	attachments := make([]models.Attachment, len(src))
+	for i, a := range src {
+		if a.Data != nil {
+			a.StorageKey = fmt.Sprintf("notification.attachment:%s:%d", notification.ID, i)
+			if err := nc.storage.Add(ctx, a.StorageKey, a.Data, notification.Delay+168*time.Hour); err != nil {
+				for _, stored := range attachments[:i] {
+					if stored.StorageKey != "" {
+						_ = nc.storage.Remove(ctx, stored.StorageKey)
+					}
+				}
+				return nil, err
+			}
+			a.Data = nil
+		}
+		attachments[i] = a
+	}
+
+	return attachments, nil
+}
+
+// RescheduleNotification повторно кладет уже созданное уведомление в отложенную очередь
+// с сохранением его айди. Используется для повторных попыток отправки.
+func (nc *NotificationCreator) RescheduleNotification(ctx context.Context, notification models.DelayedNotification, sendAt time.Time) error {
+	payload, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+
+	err = nc.storage.Add(ctx, "notification:"+notification.ID, payload, time.Until(sendAt)+24*time.Hour)
+	if err != nil {
+		return err
+	}
+
+	err = nc.storage.SortedSetAdd(ctx, nc.delayedSetName, notification.ID, float64(sendAt.UnixMilli()))
+	if err != nil {
 		_ = nc.storage.Remove(ctx, "notification:"+notification.ID)
-		_ = nc.storage.Remove(ctx, "notification.status:"+notification.ID)
-		return "", err
+		return err
 	}
 
-	return uid, nil
+	return nil
 }
 
 // GetNotificationStatus возвращает уведомление по его айди.
@@ -73,26 +401,384 @@ func (nc *NotificationCreator) GetNotificationStatus(ctx context.Context, uid st
 	return models.NotificationStatus(notification), err
 }
 
-// RemoveNotification удаляет уведомление по айди, если оно еще не отправлено.
-func (nc *NotificationCreator) RemoveNotification(ctx context.Context, uid string) error {
+// GetStatusHistory возвращает историю статусов уведомления от первого к последнему.
+func (nc *NotificationCreator) GetStatusHistory(ctx context.Context, uid string) ([]models.StatusChange, error) {
+	entries, err := nc.storage.ListRange(ctx, "notification.history:"+uid)
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]models.StatusChange, 0, len(entries))
+	for _, entry := range entries {
+		var change models.StatusChange
+		if err := json.Unmarshal([]byte(entry), &change); err != nil {
+			return nil, err
+		}
+		history = append(history, change)
+	}
+
+	return history, nil
+}
+
+// GetDeliveredChannels возвращает каналы, по которым уведомление доставлено, или nil, если их нет.
+func (nc *NotificationCreator) GetDeliveredChannels(ctx context.Context, uid string) ([]models.ChannelName, error) {
+	return nc.getChannels(ctx, "notification.delivery:"+uid)
+}
+
+// GetSuppressedChannels возвращает каналы, от которых получатель уведомления отказался, или nil, если их нет.
+func (nc *NotificationCreator) GetSuppressedChannels(ctx context.Context, uid string) ([]models.ChannelName, error) {
+	return nc.getChannels(ctx, "notification.suppressed:"+uid)
+}
+
+// GetRateLimitedChannels возвращает каналы, не отправленные из-за превышения лимита отправки получателю,
+// или nil, если их нет.
+func (nc *NotificationCreator) GetRateLimitedChannels(ctx context.Context, uid string) ([]models.ChannelName, error) {
+	return nc.getChannels(ctx, "notification.rate_limited:"+uid)
+}
+
+// getChannels читает сохраненный отправщиком список каналов.
+func (nc *NotificationCreator) getChannels(ctx context.Context, key string) ([]models.ChannelName, error) {
+	payload, err := nc.storage.Get(ctx, key)
+	if errors.Is(err, models.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var channels []models.ChannelName
+	if err := json.Unmarshal([]byte(payload), &channels); err != nil {
+		return nil, err
+	}
+
+	return channels, nil
+}
+
+// SetNotificationStatus сохраняет статус уведомления и добавляет его в историю статусов с пояснением note.
+func (nc *NotificationCreator) SetNotificationStatus(ctx context.Context, uid string, status models.NotificationStatus, note string) error {
+	return setStatus(ctx, nc.storage, uid, status, note, 168*time.Hour)
+}
+
+// DropNotification отбрасывает снятое поллером с очереди уведомление, пришедшееся на тихие часы получателя,
+// вместе со всем, что хранится отдельно от него. Уведомления, собранные в отброшенную сводку, отбрасываются вместе с ней.
+func (nc *NotificationCreator) DropNotification(ctx context.Context, notification models.DelayedNotification, note string) error {
+	if err := nc.releasePayload(ctx, notification.ID); err != nil {
+		return err
+	}
+
+	if err := nc.storage.Remove(ctx, "notification:"+notification.ID); err != nil {
+		return err
+	}
+
+	if err := nc.storage.Remove(ctx, "notification.original:"+notification.ID); err != nil {
+		return err
+	}
+
+	if chatID := notification.Channels.TelegramChannel.ChatID; chatID != "" {
+		if err := nc.storage.SortedSetRemove(ctx, chatNotificationsKey(chatID), notification.ID); err != nil {
+			return err
+		}
+	}
+
+	if err := setStatus(ctx, nc.storage, notification.ID, models.StatusDropped, note, 168*time.Hour); err != nil {
+		return err
+	}
+
+	if notification.Digest != nil {
+		return nc.CompleteDigest(ctx, notification.ID, models.StatusDropped)
+	}
+	return nil
+}
+
+// RemoveNotification удаляет уведомление по айди, если оно еще не отправлено
+// или ожидает повторной попытки. Вместе со сводкой удаляются собранные в нее уведомления.
+// Если cancelEvent и приглашение на событие уже могло уйти получателю,
+// дополнительно рассылает отмену события, чтобы оно пропало из календаря.
+// Отмену можно разослать и для уже отправленного уведомления.
+func (nc *NotificationCreator) RemoveNotification(ctx context.Context, uid string, cancelEvent bool) error {
 	status, err := nc.GetNotificationStatus(ctx, uid)
 	if err != nil {
 		return err
 	}
 
-	if status != models.StatusScheduled {
+	pending := isPending(status)
+	if !pending && !cancelEvent {
 		return fmt.Errorf("notification %s already sent", uid)
 	}
 
-	err = nc.storage.Remove(ctx, "notification:"+uid)
+	if pending {
+		err = nc.storage.SortedSetRemove(ctx, nc.delayedSetName, uid)
+		if err != nil {
+			return err
+		}
+
+		err = nc.releasePayload(ctx, uid)
+		if err != nil {
+			return err
+		}
+
+		err = nc.storage.Remove(ctx, "notification:"+uid)
+		if err != nil {
+			return err
+		}
+
+		err = nc.storage.Remove(ctx, "notification.status:"+uid)
+		if err != nil {
+			return err
+		}
+
+		err = nc.storage.Remove(ctx, "notification.history:"+uid)
+		if err != nil {
+			return err
+		}
+
+		err = nc.storage.Remove(ctx, "notification.original:"+uid)
+		if err != nil {
+			return err
+		}
+
+		// собранные в отмененную сводку уведомления отменяются вместе с ней
+		err = nc.removeDigestMembers(ctx, uid)
+		if err != nil {
+			return err
+		}
+	}
+
+	// уведомление еще ни разу не отправлялось, так что и приглашения у получателя нет
+	if !cancelEvent || status == models.StatusScheduled {
+		return nc.removeEventCancellation(ctx, uid)
+	}
+
+	err = nc.sendEventCancellation(ctx, uid)
+	if errors.Is(err, models.ErrNotFound) {
+		if pending {
+			return nil
+		}
+		return fmt.Errorf("notification %s has no event to cancel", uid)
+	}
+	return err
+}
+
+// releasePayload удаляет то, что хранится отдельно от отменяемого уведомления: содержимое вложений,
+// переданное в запросе, и индекс ключа схлопывания, если он указывает на уведомление.
+func (nc *NotificationCreator) releasePayload(ctx context.Context, uid string) error {
+	payload, err := nc.storage.Get(ctx, "notification:"+uid)
+	if errors.Is(err, models.ErrNotFound) {
+		// пока уведомление отправляется, его payload есть только в копии
+		payload, err = nc.storage.Get(ctx, "notification.original:"+uid)
+	}
+	if errors.Is(err, models.ErrNotFound) {
+		return nil
+	}
 	if err != nil {
 		return err
 	}
 
-	err = nc.storage.Remove(ctx, "notification.status:"+uid)
+	var notification models.DelayedNotification
+	if err := json.Unmarshal([]byte(payload), &notification); err != nil {
+		return err
+	}
+
+	for _, a := range notification.Channels.EmailChannel.Attachments {
+		if a.StorageKey == "" {
+			continue
+		}
+		if err := nc.storage.Remove(ctx, a.StorageKey); err != nil {
+			return err
+		}
+	}
+
+	return nc.releaseCollapseIndex(ctx, notification)
+}
+
+// sendEventCancellation планирует немедленную отправку сохраненной отмены события.
+func (nc *NotificationCreator) sendEventCancellation(ctx context.Context, uid string) error {
+	payload, err := nc.storage.Get(ctx, "notification.event:"+uid)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	var cancellation models.DelayedNotification
+	if err := json.Unmarshal([]byte(payload), &cancellation); err != nil {
+		return err
+	}
+
+	if _, err := nc.ScheduleNotification(ctx, cancellation); err != nil {
+		return fmt.Errorf("failed to schedule event cancellation: %w", err)
+	}
+
+	return nc.removeEventCancellation(ctx, uid)
+}
+
+// SnoozeNotification откладывает уведомление до sendAt и возвращает айди отложенного уведомления.
+// Ожидающее отправки уведомление просто переносится, а уже отправленное создается заново
+// с теми же содержимым и каналами новым уведомлением, связанным с исходным.
+func (nc *NotificationCreator) SnoozeNotification(ctx context.Context, uid string, sendAt time.Time) (string, error) {
+	if d := time.Until(sendAt); d <= 0 || d > maxReminderDelay {
+		return "", fmt.Errorf("%w: time must be within %s", ErrInvalidSnoozeTime, maxReminderDelay)
+	}
+
+	status, err := nc.GetNotificationStatus(ctx, uid)
+	if errors.Is(err, models.ErrNotFound) {
+		return "", fmt.Errorf("%w: %s", ErrNotificationNotFound, uid)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if isPending(status) {
+		err := nc.postpone(ctx, uid, sendAt)
+		if !errors.Is(err, models.ErrNotFound) {
+			return uid, err
+		}
+		// поллер уже забрал уведомление, поэтому оно откладывается как отправленное
+	}
+
+	return nc.recreate(ctx, uid, sendAt)
+}
+
+// postpone переносит ожидающее отправки уведомление.
+// Возвращает models.ErrNotFound, если поллер уже забрал его из очереди.
+func (nc *NotificationCreator) postpone(ctx context.Context, uid string, sendAt time.Time) error {
+	notification, _, err := nc.pendingNotification(ctx, uid)
+	if err != nil {
+		return err
+	}
+
+	if _, err := nc.storage.SortedSetScore(ctx, nc.delayedSetName, uid); err != nil {
+		return err
+	}
+
+	if esc := notification.Escalation; esc != nil && esc.Step == 0 {
+		// следующие шаги эскалации отсчитываются от первого и переносятся вместе с ним
+		esc.StartedAt = sendAt.UTC()
+	}
+
+	return nc.RescheduleNotification(ctx, notification, sendAt)
+}
+
+// recreate создает отложенную копию уже отправленного уведомления из сохраненного при создании оригинала.
+// Вложения копируются, потому что хранятся не дольше исходного уведомления.
+// Пока копия ждет отправки, повторное откладывание переносит ее, а не создает еще одну.
+func (nc *NotificationCreator) recreate(ctx context.Context, uid string, sendAt time.Time) (string, error) {
+	payload, err := nc.storage.Get(ctx, "notification.original:"+uid)
+	if errors.Is(err, models.ErrNotFound) {
+		return "", fmt.Errorf("%w: %s", ErrNotificationNotFound, uid)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	var notification models.DelayedNotification
+	if err := json.Unmarshal([]byte(payload), &notification); err != nil {
+		return "", err
+	}
+
+	notification.ID = uuid.NewString()
+	snoozedKey := "notification.snoozed:" + uid
+	claimed, err := nc.storage.AddIfAbsent(ctx, snoozedKey, notification.ID, time.Until(sendAt))
+	if err != nil {
+		return "", err
+	}
+	if !claimed {
+		return nc.postponeSnoozed(ctx, snoozedKey, sendAt)
+	}
+
+	id, err := nc.enqueueSnoozed(ctx, notification, uid, sendAt)
+	if err != nil {
+		_ = nc.storage.Remove(ctx, snoozedKey)
+		return "", err
+	}
+	return id, nil
+}
+
+// postponeSnoozed переносит уже созданную и еще не отправленную копию уведомления.
+func (nc *NotificationCreator) postponeSnoozed(ctx context.Context, snoozedKey string, sendAt time.Time) (string, error) {
+	id, err := nc.storage.Get(ctx, snoozedKey)
+	if err != nil {
+		return "", err
+	}
+
+	err = nc.postpone(ctx, id, sendAt)
+	if errors.Is(err, models.ErrNotFound) {
+		return id, nil // поллер уже забрал копию
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return id, nc.storage.Add(ctx, snoozedKey, id, time.Until(sendAt))
+}
+
+// enqueueSnoozed ставит в очередь копию уведомления uid, отложенную до sendAt.
+func (nc *NotificationCreator) enqueueSnoozed(ctx context.Context, notification models.DelayedNotification, uid string, sendAt time.Time) (string, error) {
+	notification.SnoozedFrom = uid
+	notification.Delay = time.Until(sendAt)
+	if esc := notification.Escalation; esc != nil {
+		esc.StartedAt = sendAt.UTC()
+	}
+
+	attachments, err := nc.copyAttachments(ctx, notification)
+	if err != nil {
+		return "", err
+	}
+	notification.Channels.EmailChannel.Attachments = attachments
+
+	if err := nc.enqueue(ctx, notification); err != nil {
+		return "", err
+	}
+
+	return notification.ID, nil
+}
+
+// copyAttachments копирует сохраненное содержимое вложений под айди нового уведомления.
+func (nc *NotificationCreator) copyAttachments(ctx context.Context, notification models.DelayedNotification) ([]models.Attachment, error) {
+	src := notification.Channels.EmailChannel.Attachments
+	if len(src) == 0 {
+		return nil, nil
+	}
+
+	attachments := make([]models.Attachment, len(src))
+	for i, a := range src {
+		if a.StorageKey != "" {
+			data, err := nc.storage.Get(ctx, a.StorageKey)
+			if errors.Is(err, models.ErrNotFound) {
+				return nil, fmt.Errorf("%w: attachment %s expired", ErrNotificationNotFound, a.Filename)
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			a.StorageKey = fmt.Sprintf("notification.attachment:%s:%d", notification.ID, i)
+			if err := nc.storage.Add(ctx, a.StorageKey, data, notification.Delay+168*time.Hour); err != nil {
+				return nil, err
+			}
+		}
+		attachments[i] = a
+	}
+
+	return attachments, nil
+}
+
+// removeEventCancellation удаляет заготовку отмены события, если она есть.
+func (nc *NotificationCreator) removeEventCancellation(ctx context.Context, uid string) error {
+	return nc.storage.Remove(ctx, "notification.event:"+uid)
+}
+
+// logError пишет в лог ошибку, которая не помешала выполнить запрос.
+func (nc *NotificationCreator) logError(err error) {
+	if nc.logger != nil {
+		nc.logger.Error(err)
+	}
+}
+
+// isPending сообщает, лежит ли уведомление в отложенной очереди в ожидании отправки.
+func isPending(status models.NotificationStatus) bool {
+	switch status {
+	case models.StatusScheduled, models.StatusRetrying, models.StatusInterrupted, models.StatusAwaitingAck,
+		models.StatusDeferred:
+		return true
+	default:
+		return false
+	}
 }
@@ -2,7 +2,9 @@ package usecase
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -19,8 +21,8 @@ func TestNotificationCreator_ScheduleNotification(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	mockStorage := mock_usecase.NewMockstorage(ctrl)
-	creator := NewNotificationCreator(mockStorage, "delayed_notifications")
+	mockStorage := allowStatusHistory(mock_usecase.NewMockstorage(ctrl))
+	creator := NewNotificationCreator(mockStorage, nil, nil, nil, "delayed_notifications", nil)
 
 	notification := models.DelayedNotification{
 		Notification: "test message",
@@ -31,7 +33,7 @@ func TestNotificationCreator_ScheduleNotification(t *testing.T) {
 	}
 
 	t.Run("success", func(t *testing.T) {
-		mockStorage.EXPECT().Add(gomock.Any(), gomock.Not(gomock.Nil()), gomock.Any(), gomock.Any()).Return(nil).Times(2)
+		mockStorage.EXPECT().Add(gomock.Any(), gomock.Not(gomock.Nil()), gomock.Any(), gomock.Any()).Return(nil).Times(3)
 		mockStorage.EXPECT().SortedSetAdd(gomock.Any(), "delayed_notifications", gomock.Any(), gomock.Any()).Return(nil)
 
 		id, err := creator.ScheduleNotification(context.Background(), notification)
@@ -49,17 +51,128 @@ func TestNotificationCreator_ScheduleNotification(t *testing.T) {
 	t.Run("storage_add_fails_on_status", func(t *testing.T) {
 		mockStorage.EXPECT().Add(gomock.Any(), gomock.Not(gomock.Nil()), gomock.Any(), gomock.Any()).Return(nil)
 		mockStorage.EXPECT().Add(gomock.Any(), gomock.Not(gomock.Nil()), gomock.Any(), gomock.Any()).Return(errors.New("storage error"))
+		mockStorage.EXPECT().Remove(gomock.Any(), gomock.Not(gomock.Nil())).Return(nil).Times(5)
 
 		_, err := creator.ScheduleNotification(context.Background(), notification)
 		assert.Error(t, err)
 	})
 
 	t.Run("sorted_set_add_fails", func(t *testing.T) {
-		mockStorage.EXPECT().Add(gomock.Any(), gomock.Not(gomock.Nil()), gomock.Any(), gomock.Any()).Return(nil).Times(2)
+		mockStorage.EXPECT().Add(gomock.Any(), gomock.Not(gomock.Nil()), gomock.Any(), gomock.Any()).Return(nil).Times(3)
 		mockStorage.EXPECT().SortedSetAdd(gomock.Any(), "delayed_notifications", gomock.Any(), gomock.Any()).Return(errors.New("zadd error"))
-		mockStorage.EXPECT().Remove(gomock.Any(), gomock.Not(gomock.Nil())).Return(nil).Times(2)
+		mockStorage.EXPECT().Remove(gomock.Any(), gomock.Not(gomock.Nil())).Return(nil).Times(5)
+
+		_, err := creator.ScheduleNotification(context.Background(), notification)
+		assert.Error(t, err)
+	})
+}
+
+func TestNotificationCreator_ScheduleNotification_Attachments(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := allowStatusHistory(mock_usecase.NewMockstorage(ctrl))
+	creator := NewNotificationCreator(mockStorage, nil, nil, nil, "delayed_notifications", nil)
+
+	notification := models.DelayedNotification{
+		Notification: "invoice",
+		Delay:        time.Minute,
+		Channels: models.Channels{
+			EmailChannel: models.EmailChannel{
+				Email: "user@example.com",
+				Attachments: []models.Attachment{
+					{Filename: "invoice.pdf", Data: []byte("%PDF")},
+					{Filename: "logo.png", URL: "https://example.com/logo.png"},
+				},
+			},
+		},
+	}
+
+	t.Run("content_stored_separately", func(t *testing.T) {
+		var payload []byte
+		mockStorage.EXPECT().
+			Add(gomock.Any(), gomock.Any(), []byte("%PDF"), time.Minute+168*time.Hour).
+			DoAndReturn(func(_ context.Context, key string, _ interface{}, _ time.Duration) error {
+				assert.Regexp(t, `^notification\.attachment:.+:0$`, key)
+				return nil
+			})
+		mockStorage.EXPECT().
+			Add(gomock.Any(), gomock.Any(), gomock.Any(), time.Minute+24*time.Hour).
+			DoAndReturn(func(_ context.Context, _ string, value interface{}, _ time.Duration) error {
+				payload = value.([]byte)
+				return nil
+			})
+		mockStorage.EXPECT().Add(gomock.Any(), gomock.Any(), string(models.StatusScheduled), gomock.Any()).Return(nil)
+		mockStorage.EXPECT().
+			Add(gomock.Any(), gomock.Any(), gomock.Any(), time.Minute+168*time.Hour).
+			DoAndReturn(func(_ context.Context, key string, value interface{}, _ time.Duration) error {
+				assert.Regexp(t, `^notification\.original:`, key)
+				assert.Equal(t, payload, value)
+				return nil
+			})
+		mockStorage.EXPECT().SortedSetAdd(gomock.Any(), "delayed_notifications", gomock.Any(), gomock.Any()).Return(nil)
 
 		_, err := creator.ScheduleNotification(context.Background(), notification)
+		require.NoError(t, err)
+
+		assert.NotContains(t, string(payload), `"content"`)
+		assert.Contains(t, string(payload), `"storage_key"`)
+		assert.Contains(t, string(payload), `"url":"https://example.com/logo.png"`)
+
+		// вложения вызывающего не меняются
+		assert.Equal(t, []byte("%PDF"), notification.Channels.EmailChannel.Attachments[0].Data)
+	})
+
+	t.Run("content_store_fails", func(t *testing.T) {
+		mockStorage.EXPECT().Add(gomock.Any(), gomock.Any(), []byte("%PDF"), gomock.Any()).Return(errors.New("storage error"))
+
+		_, err := creator.ScheduleNotification(context.Background(), notification)
+		assert.Error(t, err)
+	})
+}
+
+func TestNotificationCreator_RescheduleNotification(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := allowStatusHistory(mock_usecase.NewMockstorage(ctrl))
+	creator := NewNotificationCreator(mockStorage, nil, nil, nil, "delayed_notifications", nil)
+
+	notification := models.DelayedNotification{
+		ID:           "test-id",
+		Notification: "test message",
+		Channels: models.Channels{
+			EmailChannel: models.EmailChannel{Email: "user@example.com"},
+		},
+		Attempt: 1,
+	}
+	sendAt := time.Now().Add(time.Minute)
+
+	t.Run("success", func(t *testing.T) {
+		mockStorage.EXPECT().Add(gomock.Any(), "notification:test-id", gomock.Any(), gomock.Any()).Return(nil)
+		mockStorage.EXPECT().SortedSetAdd(gomock.Any(), "delayed_notifications", "test-id", float64(sendAt.UnixMilli())).Return(nil)
+
+		err := creator.RescheduleNotification(context.Background(), notification, sendAt)
+		assert.NoError(t, err)
+	})
+
+	t.Run("storage_add_fails", func(t *testing.T) {
+		mockStorage.EXPECT().Add(gomock.Any(), "notification:test-id", gomock.Any(), gomock.Any()).Return(errors.New("storage error"))
+
+		err := creator.RescheduleNotification(context.Background(), notification, sendAt)
+		assert.Error(t, err)
+	})
+
+	t.Run("sorted_set_add_fails", func(t *testing.T) {
+		mockStorage.EXPECT().Add(gomock.Any(), "notification:test-id", gomock.Any(), gomock.Any()).Return(nil)
+		mockStorage.EXPECT().SortedSetAdd(gomock.Any(), "delayed_notifications", "test-id", gomock.Any()).Return(errors.New("zadd error"))
+		mockStorage.EXPECT().Remove(gomock.Any(), "notification:test-id").Return(nil)
+
+		err := creator.RescheduleNotification(context.Background(), notification, sendAt)
 		assert.Error(t, err)
 	})
 }
@@ -70,8 +183,8 @@ func TestNotificationCreator_GetNotificationStatus(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	mockStorage := mock_usecase.NewMockstorage(ctrl)
-	creator := NewNotificationCreator(mockStorage, "delayed_notifications")
+	mockStorage := allowStatusHistory(mock_usecase.NewMockstorage(ctrl))
+	creator := NewNotificationCreator(mockStorage, nil, nil, nil, "delayed_notifications", nil)
 
 	t.Run("success", func(t *testing.T) {
 		expectedStatus := string(models.StatusScheduled)
@@ -90,64 +203,323 @@ func TestNotificationCreator_GetNotificationStatus(t *testing.T) {
 	})
 }
 
+func TestNotificationCreator_GetDeliveredChannels(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := allowStatusHistory(mock_usecase.NewMockstorage(ctrl))
+	creator := NewNotificationCreator(mockStorage, nil, nil, nil, "delayed_notifications", nil)
+
+	t.Run("success", func(t *testing.T) {
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.delivery:test-id").Return(`["email"]`, nil)
+
+		delivered, err := creator.GetDeliveredChannels(context.Background(), "test-id")
+		require.NoError(t, err)
+		assert.Equal(t, []models.ChannelName{models.ChannelEmail}, delivered)
+	})
+
+	t.Run("not_delivered", func(t *testing.T) {
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.delivery:test-id").Return("", models.ErrNotFound)
+
+		delivered, err := creator.GetDeliveredChannels(context.Background(), "test-id")
+		require.NoError(t, err)
+		assert.Nil(t, delivered)
+	})
+}
+
 func TestNotificationCreator_RemoveNotification(t *testing.T) {
 	t.Parallel()
 
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	mockStorage := mock_usecase.NewMockstorage(ctrl)
-	creator := NewNotificationCreator(mockStorage, "delayed_notifications")
+	mockStorage := allowStatusHistory(mock_usecase.NewMockstorage(ctrl))
+	creator := NewNotificationCreator(mockStorage, nil, nil, nil, "delayed_notifications", nil)
 
 	t.Run("success", func(t *testing.T) {
 		mockStorage.EXPECT().Get(gomock.Any(), "notification.status:test-id").Return(string(models.StatusScheduled), nil)
+		mockStorage.EXPECT().Get(gomock.Any(), "notification:test-id").Return(`{"id":"test-id"}`, nil)
+		mockStorage.EXPECT().SortedSetRemove(gomock.Any(), "delayed_notifications", "test-id").Return(nil)
+		mockStorage.EXPECT().Remove(gomock.Any(), "notification:test-id").Return(nil)
+		mockStorage.EXPECT().Remove(gomock.Any(), "notification.status:test-id").Return(nil)
+		mockStorage.EXPECT().Remove(gomock.Any(), "notification.history:test-id").Return(nil)
+		mockStorage.EXPECT().Remove(gomock.Any(), "notification.original:test-id").Return(nil)
+		mockStorage.EXPECT().ListRange(gomock.Any(), "notification.digest.members:test-id").Return(nil, nil)
+		mockStorage.EXPECT().Remove(gomock.Any(), "notification.event:test-id").Return(nil)
+
+		err := creator.RemoveNotification(context.Background(), "test-id", false)
+		assert.NoError(t, err)
+	})
+
+	t.Run("interrupted", func(t *testing.T) {
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.status:test-id").Return(string(models.StatusInterrupted), nil)
+		mockStorage.EXPECT().Get(gomock.Any(), "notification:test-id").Return(`{"id":"test-id"}`, nil)
+		mockStorage.EXPECT().SortedSetRemove(gomock.Any(), "delayed_notifications", "test-id").Return(nil)
 		mockStorage.EXPECT().Remove(gomock.Any(), "notification:test-id").Return(nil)
 		mockStorage.EXPECT().Remove(gomock.Any(), "notification.status:test-id").Return(nil)
+		mockStorage.EXPECT().Remove(gomock.Any(), "notification.history:test-id").Return(nil)
+		mockStorage.EXPECT().Remove(gomock.Any(), "notification.original:test-id").Return(nil)
+		mockStorage.EXPECT().ListRange(gomock.Any(), "notification.digest.members:test-id").Return(nil, nil)
+		mockStorage.EXPECT().Remove(gomock.Any(), "notification.event:test-id").Return(nil)
 
-		err := creator.RemoveNotification(context.Background(), "test-id")
+		err := creator.RemoveNotification(context.Background(), "test-id", false)
+		assert.NoError(t, err)
+	})
+
+	t.Run("retrying", func(t *testing.T) {
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.status:test-id").Return(string(models.StatusRetrying), nil)
+		mockStorage.EXPECT().Get(gomock.Any(), "notification:test-id").Return(`{"id":"test-id"}`, nil)
+		mockStorage.EXPECT().SortedSetRemove(gomock.Any(), "delayed_notifications", "test-id").Return(nil)
+		mockStorage.EXPECT().Remove(gomock.Any(), "notification:test-id").Return(nil)
+		mockStorage.EXPECT().Remove(gomock.Any(), "notification.status:test-id").Return(nil)
+		mockStorage.EXPECT().Remove(gomock.Any(), "notification.history:test-id").Return(nil)
+		mockStorage.EXPECT().Remove(gomock.Any(), "notification.original:test-id").Return(nil)
+		mockStorage.EXPECT().ListRange(gomock.Any(), "notification.digest.members:test-id").Return(nil, nil)
+		mockStorage.EXPECT().Remove(gomock.Any(), "notification.event:test-id").Return(nil)
+
+		err := creator.RemoveNotification(context.Background(), "test-id", false)
+		assert.NoError(t, err)
+	})
+
+	t.Run("stored_attachments", func(t *testing.T) {
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.status:test-id").Return(string(models.StatusScheduled), nil)
+		mockStorage.EXPECT().Get(gomock.Any(), "notification:test-id").Return("", models.ErrNotFound)
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.original:test-id").Return(`{"id":"test-id","channels":{"email_channel":{"email":"user@example.com",`+
+			`"attachments":[{"filename":"a.txt","storage_key":"notification.attachment:test-id:0"},{"filename":"b.png","url":"https://example.com/b.png"}]}}}`, nil)
+		mockStorage.EXPECT().Remove(gomock.Any(), "notification.attachment:test-id:0").Return(nil)
+		mockStorage.EXPECT().SortedSetRemove(gomock.Any(), "delayed_notifications", "test-id").Return(nil)
+		mockStorage.EXPECT().Remove(gomock.Any(), "notification:test-id").Return(nil)
+		mockStorage.EXPECT().Remove(gomock.Any(), "notification.status:test-id").Return(nil)
+		mockStorage.EXPECT().Remove(gomock.Any(), "notification.history:test-id").Return(nil)
+		mockStorage.EXPECT().Remove(gomock.Any(), "notification.original:test-id").Return(nil)
+		mockStorage.EXPECT().ListRange(gomock.Any(), "notification.digest.members:test-id").Return(nil, nil)
+		mockStorage.EXPECT().Remove(gomock.Any(), "notification.event:test-id").Return(nil)
+
+		err := creator.RemoveNotification(context.Background(), "test-id", false)
 		assert.NoError(t, err)
 	})
 
 	t.Run("already_sent", func(t *testing.T) {
 		mockStorage.EXPECT().Get(gomock.Any(), "notification.status:test-id").Return(string(models.StatusSent), nil)
 
-		err := creator.RemoveNotification(context.Background(), "test-id")
+		err := creator.RemoveNotification(context.Background(), "test-id", false)
 		assert.EqualError(t, err, "notification test-id already sent")
 	})
 
 	t.Run("get_status_fails", func(t *testing.T) {
 		mockStorage.EXPECT().Get(gomock.Any(), "notification.status:test-id").Return("", errors.New("storage error"))
 
-		err := creator.RemoveNotification(context.Background(), "test-id")
+		err := creator.RemoveNotification(context.Background(), "test-id", false)
 		assert.Error(t, err)
 	})
 
 	t.Run("remove_payload_fails", func(t *testing.T) {
 		mockStorage.EXPECT().Get(gomock.Any(), "notification.status:test-id").Return(string(models.StatusScheduled), nil)
+		mockStorage.EXPECT().Get(gomock.Any(), "notification:test-id").Return(`{"id":"test-id"}`, nil)
+		mockStorage.EXPECT().SortedSetRemove(gomock.Any(), "delayed_notifications", "test-id").Return(nil)
 		mockStorage.EXPECT().Remove(gomock.Any(), "notification:test-id").Return(errors.New("remove error"))
 
-		err := creator.RemoveNotification(context.Background(), "test-id")
+		err := creator.RemoveNotification(context.Background(), "test-id", false)
 		assert.Error(t, err)
 	})
 
 	t.Run("remove_status_fails", func(t *testing.T) {
 		mockStorage.EXPECT().Get(gomock.Any(), "notification.status:test-id").Return(string(models.StatusScheduled), nil)
+		mockStorage.EXPECT().Get(gomock.Any(), "notification:test-id").Return(`{"id":"test-id"}`, nil)
+		mockStorage.EXPECT().SortedSetRemove(gomock.Any(), "delayed_notifications", "test-id").Return(nil)
 		mockStorage.EXPECT().Remove(gomock.Any(), "notification:test-id").Return(nil)
 		mockStorage.EXPECT().Remove(gomock.Any(), "notification.status:test-id").Return(errors.New("remove error"))
 
-		err := creator.RemoveNotification(context.Background(), "test-id")
+		err := creator.RemoveNotification(context.Background(), "test-id", false)
 		assert.Error(t, err)
 	})
 }
 
-func TestNotificationCreator_ConcurrentSchedule(t *testing.T) {
+func TestNotificationCreator_DropNotification(t *testing.T) {
 	t.Parallel()
 
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockStorage := mock_usecase.NewMockstorage(ctrl)
-	creator := NewNotificationCreator(mockStorage, "delayed_notifications")
+	creator := NewNotificationCreator(mockStorage, nil, nil, nil, "delayed_notifications", nil)
+
+	// отброшенное уведомление не оставляет после себя копию, вложения и запись в списке чата
+	mockStorage.EXPECT().Get(gomock.Any(), "notification:test-id").Return(`{"id":"test-id","channels":{"tg_channel":{"chat_id":"42"},`+
+		`"email_channel":{"email":"user@example.com","attachments":[{"filename":"a.txt","storage_key":"notification.attachment:test-id:0"}]}}}`, nil)
+	mockStorage.EXPECT().Remove(gomock.Any(), "notification.attachment:test-id:0").Return(nil)
+	mockStorage.EXPECT().Remove(gomock.Any(), "notification:test-id").Return(nil)
+	mockStorage.EXPECT().Remove(gomock.Any(), "notification.original:test-id").Return(nil)
+	mockStorage.EXPECT().SortedSetRemove(gomock.Any(), chatNotificationsKey("42"), "test-id").Return(nil)
+	mockStorage.EXPECT().Add(gomock.Any(), "notification.status:test-id", string(models.StatusDropped), gomock.Any()).Return(nil)
+	mockStorage.EXPECT().ListPushCapped(gomock.Any(), "notification.history:test-id", gomock.Any(), int64(statusHistorySize), gomock.Any()).Return(nil)
+
+	notification := models.DelayedNotification{
+		ID:       "test-id",
+		Channels: models.Channels{TelegramChannel: models.TelegramChannel{ChatID: "42"}},
+	}
+	require.NoError(t, creator.DropNotification(context.Background(), notification, "quiet hours until 2025-03-14T07:00:00Z"))
+}
+
+func TestNotificationCreator_ScheduleNotification_Event(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := allowStatusHistory(mock_usecase.NewMockstorage(ctrl))
+	creator := NewNotificationCreator(mockStorage, nil, nil, nil, "delayed_notifications", nil)
+
+	start := time.Now().Add(time.Hour)
+	notification := models.DelayedNotification{
+		Notification: "meeting soon",
+		Delay:        time.Minute,
+		Channels: models.Channels{
+			EmailChannel: models.EmailChannel{Email: "user@example.com", Subject: "Meeting"},
+		},
+		Event: &models.Event{Start: start, End: start.Add(time.Hour)},
+	}
+
+	var payload, cancellation []byte
+	mockStorage.EXPECT().Add(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, key string, value interface{}, _ time.Duration) error {
+			switch {
+			case strings.HasPrefix(key, "notification.event:"):
+				cancellation = value.([]byte)
+			case strings.HasPrefix(key, "notification:"):
+				payload = value.([]byte)
+			}
+			return nil
+		}).Times(4)
+	mockStorage.EXPECT().SortedSetAdd(gomock.Any(), "delayed_notifications", gomock.Any(), gomock.Any()).Return(nil)
+
+	id, err := creator.ScheduleNotification(context.Background(), notification)
+	require.NoError(t, err)
+
+	var scheduled models.DelayedNotification
+	require.NoError(t, json.Unmarshal(payload, &scheduled))
+	require.NotNil(t, scheduled.Event)
+	assert.Equal(t, id+"@delayed-notifyer", scheduled.Event.UID)
+	assert.Equal(t, models.EventRequest, scheduled.Event.Method)
+
+	var cancel models.DelayedNotification
+	require.NoError(t, json.Unmarshal(cancellation, &cancel))
+	require.NotNil(t, cancel.Event)
+	assert.Equal(t, scheduled.Event.UID, cancel.Event.UID)
+	assert.Equal(t, models.EventCancel, cancel.Event.Method)
+	assert.Equal(t, 1, cancel.Event.Sequence)
+	assert.Equal(t, "user@example.com", cancel.Channels.EmailChannel.Email)
+	assert.Empty(t, cancel.Channels.TelegramChannel.ChatID)
+
+	t.Run("cancellation_not_stored", func(t *testing.T) {
+		// без заготовки отмены уведомление не ставится в очередь, а сохраненное удаляется
+		mockStorage.EXPECT().Add(gomock.Any(), gomock.Not(gomock.Nil()), gomock.Any(), gomock.Any()).Return(nil).Times(3)
+		mockStorage.EXPECT().Add(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, key string, _ interface{}, _ time.Duration) error {
+				assert.True(t, strings.HasPrefix(key, "notification.event:"))
+				return errors.New("storage error")
+			})
+		mockStorage.EXPECT().Remove(gomock.Any(), gomock.Not(gomock.Nil())).Return(nil).Times(5)
+
+		_, err := creator.ScheduleNotification(context.Background(), notification)
+		assert.Error(t, err)
+	})
+}
+
+func TestNotificationCreator_RemoveNotification_CancelEvent(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := allowStatusHistory(mock_usecase.NewMockstorage(ctrl))
+	creator := NewNotificationCreator(mockStorage, nil, nil, nil, "delayed_notifications", nil)
+
+	cancellation, err := json.Marshal(models.DelayedNotification{
+		Notification: "meeting soon",
+		Channels:     models.Channels{EmailChannel: models.EmailChannel{Email: "user@example.com"}},
+		Event:        &models.Event{UID: "test-id@delayed-notifyer", Method: models.EventCancel, Sequence: 1},
+	})
+	require.NoError(t, err)
+
+	t.Run("sent", func(t *testing.T) {
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.status:test-id").Return(string(models.StatusSent), nil)
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.event:test-id").Return(string(cancellation), nil)
+		mockStorage.EXPECT().Add(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(3)
+		mockStorage.EXPECT().SortedSetAdd(gomock.Any(), "delayed_notifications", gomock.Any(), gomock.Any()).Return(nil)
+		mockStorage.EXPECT().Remove(gomock.Any(), "notification.event:test-id").Return(nil)
+
+		err := creator.RemoveNotification(context.Background(), "test-id", true)
+		assert.NoError(t, err)
+	})
+
+	t.Run("retrying", func(t *testing.T) {
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.status:test-id").Return(string(models.StatusRetrying), nil)
+		mockStorage.EXPECT().Get(gomock.Any(), "notification:test-id").Return(`{"id":"test-id"}`, nil)
+		mockStorage.EXPECT().SortedSetRemove(gomock.Any(), "delayed_notifications", "test-id").Return(nil)
+		mockStorage.EXPECT().Remove(gomock.Any(), "notification:test-id").Return(nil)
+		mockStorage.EXPECT().Remove(gomock.Any(), "notification.status:test-id").Return(nil)
+		mockStorage.EXPECT().Remove(gomock.Any(), "notification.history:test-id").Return(nil)
+		mockStorage.EXPECT().Remove(gomock.Any(), "notification.original:test-id").Return(nil)
+		mockStorage.EXPECT().ListRange(gomock.Any(), "notification.digest.members:test-id").Return(nil, nil)
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.event:test-id").Return(string(cancellation), nil)
+		mockStorage.EXPECT().Add(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(3)
+		mockStorage.EXPECT().SortedSetAdd(gomock.Any(), "delayed_notifications", gomock.Any(), gomock.Any()).Return(nil)
+		mockStorage.EXPECT().Remove(gomock.Any(), "notification.event:test-id").Return(nil)
+
+		err := creator.RemoveNotification(context.Background(), "test-id", true)
+		assert.NoError(t, err)
+	})
+
+	t.Run("not_sent_yet", func(t *testing.T) {
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.status:test-id").Return(string(models.StatusScheduled), nil)
+		mockStorage.EXPECT().Get(gomock.Any(), "notification:test-id").Return(`{"id":"test-id"}`, nil)
+		mockStorage.EXPECT().SortedSetRemove(gomock.Any(), "delayed_notifications", "test-id").Return(nil)
+		mockStorage.EXPECT().Remove(gomock.Any(), "notification:test-id").Return(nil)
+		mockStorage.EXPECT().Remove(gomock.Any(), "notification.status:test-id").Return(nil)
+		mockStorage.EXPECT().Remove(gomock.Any(), "notification.history:test-id").Return(nil)
+		mockStorage.EXPECT().Remove(gomock.Any(), "notification.original:test-id").Return(nil)
+		mockStorage.EXPECT().ListRange(gomock.Any(), "notification.digest.members:test-id").Return(nil, nil)
+		mockStorage.EXPECT().Remove(gomock.Any(), "notification.event:test-id").Return(nil)
+
+		err := creator.RemoveNotification(context.Background(), "test-id", true)
+		assert.NoError(t, err)
+	})
+
+	t.Run("pending_without_event", func(t *testing.T) {
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.status:test-id").Return(string(models.StatusInterrupted), nil)
+		mockStorage.EXPECT().Get(gomock.Any(), "notification:test-id").Return(`{"id":"test-id"}`, nil)
+		mockStorage.EXPECT().SortedSetRemove(gomock.Any(), "delayed_notifications", "test-id").Return(nil)
+		mockStorage.EXPECT().Remove(gomock.Any(), "notification:test-id").Return(nil)
+		mockStorage.EXPECT().Remove(gomock.Any(), "notification.status:test-id").Return(nil)
+		mockStorage.EXPECT().Remove(gomock.Any(), "notification.history:test-id").Return(nil)
+		mockStorage.EXPECT().Remove(gomock.Any(), "notification.original:test-id").Return(nil)
+		mockStorage.EXPECT().ListRange(gomock.Any(), "notification.digest.members:test-id").Return(nil, nil)
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.event:test-id").Return("", models.ErrNotFound)
+
+		err := creator.RemoveNotification(context.Background(), "test-id", true)
+		assert.NoError(t, err)
+	})
+
+	t.Run("sent_without_event", func(t *testing.T) {
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.status:test-id").Return(string(models.StatusSent), nil)
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.event:test-id").Return("", models.ErrNotFound)
+
+		err := creator.RemoveNotification(context.Background(), "test-id", true)
+		assert.EqualError(t, err, "notification test-id has no event to cancel")
+	})
+}
+
+func TestNotificationCreator_ConcurrentSchedule(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := allowStatusHistory(mock_usecase.NewMockstorage(ctrl))
+	creator := NewNotificationCreator(mockStorage, nil, nil, nil, "delayed_notifications", nil)
 
 	notification := models.DelayedNotification{
 		Notification: "concurrent test",
@@ -160,8 +532,10 @@ func TestNotificationCreator_ConcurrentSchedule(t *testing.T) {
 	const goroutines = 10
 	errCh := make(chan error, goroutines)
 
-	mockStorage.EXPECT().Add(gomock.Any(), gomock.Not(gomock.Nil()), gomock.Any(), gomock.Any()).Return(nil).Times(2 * goroutines)
+	mockStorage.EXPECT().Add(gomock.Any(), gomock.Not(gomock.Nil()), gomock.Any(), gomock.Any()).Return(nil).Times(3 * goroutines)
 	mockStorage.EXPECT().SortedSetAdd(gomock.Any(), "delayed_notifications", gomock.Any(), gomock.Any()).Return(nil).Times(goroutines)
+	mockStorage.EXPECT().SortedSetAdd(gomock.Any(), "chat.notifications:123456", gomock.Any(), gomock.Any()).Return(nil).Times(goroutines)
+	mockStorage.EXPECT().SortedSetRemoveRangeByScore(gomock.Any(), "chat.notifications:123456", "-inf", gomock.Any()).Return(nil).Times(goroutines)
 
 	for i := 0; i < goroutines; i++ {
 		go func() {
@@ -175,3 +549,229 @@ func TestNotificationCreator_ConcurrentSchedule(t *testing.T) {
 		assert.NoError(t, err)
 	}
 }
+
+func TestNotificationCreator_ScheduleNotification_Template(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := allowStatusHistory(mock_usecase.NewMockstorage(ctrl))
+	mockTemplates := mock_usecase.NewMocktemplateRenderer(ctrl)
+	creator := NewNotificationCreator(mockStorage, mockTemplates, nil, nil, "delayed_notifications", nil)
+
+	t.Run("pins_latest_version", func(t *testing.T) {
+		mockTemplates.EXPECT().RenderTemplate(gomock.Any(), gomock.Any(), "en", models.TelegramParseMode("")).
+			Return(models.RenderedTemplate{Version: 3, Locale: "en", SMS: "Code 42"}, nil)
+
+		var payload []byte
+		mockStorage.EXPECT().Add(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, key string, value interface{}, _ time.Duration) error {
+				if strings.HasPrefix(key, "notification:") {
+					payload = value.([]byte)
+				}
+				return nil
+			}).Times(3)
+		mockStorage.EXPECT().SortedSetAdd(gomock.Any(), "delayed_notifications", gomock.Any(), gomock.Any()).Return(nil)
+		mockStorage.EXPECT().SortedSetAdd(gomock.Any(), "chat.notifications:1", gomock.Any(), gomock.Any()).Return(nil)
+		mockStorage.EXPECT().SortedSetRemoveRangeByScore(gomock.Any(), "chat.notifications:1", "-inf", gomock.Any()).Return(nil)
+
+		_, err := creator.ScheduleNotification(context.Background(), models.DelayedNotification{
+			Delay:    time.Minute,
+			Channels: models.Channels{TelegramChannel: models.TelegramChannel{ChatID: "1"}},
+			Template: &models.TemplateRef{ID: "tmpl-1", Variables: map[string]any{"code": "42"}},
+			Locale:   "en",
+		})
+		require.NoError(t, err)
+
+		var scheduled models.DelayedNotification
+		require.NoError(t, json.Unmarshal(payload, &scheduled))
+		assert.Equal(t, 3, scheduled.Template.Version)
+	})
+
+	t.Run("render_fails", func(t *testing.T) {
+		mockTemplates.EXPECT().RenderTemplate(gomock.Any(), gomock.Any(), "", models.TelegramParseMode("")).
+			Return(models.RenderedTemplate{}, ErrInvalidTemplate)
+
+		_, err := creator.ScheduleNotification(context.Background(), models.DelayedNotification{
+			Channels: models.Channels{TelegramChannel: models.TelegramChannel{ChatID: "1"}},
+			Template: &models.TemplateRef{ID: "tmpl-1"},
+		})
+		assert.ErrorIs(t, err, ErrInvalidTemplate)
+	})
+}
+
+func TestNotificationCreator_SnoozeNotification(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := allowStatusHistory(mock_usecase.NewMockstorage(ctrl))
+	creator := NewNotificationCreator(mockStorage, nil, nil, nil, "delayed_notifications", nil)
+
+	sendAt := time.Now().Add(time.Hour)
+	original, err := json.Marshal(models.DelayedNotification{
+		ID:           "test-id",
+		Notification: "invoice",
+		Channels: models.Channels{
+			EmailChannel: models.EmailChannel{
+				Email:       "user@example.com",
+				Attachments: []models.Attachment{{Filename: "invoice.pdf", StorageKey: "notification.attachment:test-id:0"}},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	t.Run("pending_is_postponed", func(t *testing.T) {
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.status:test-id").Return(string(models.StatusScheduled), nil).Times(2)
+		mockStorage.EXPECT().Get(gomock.Any(), "notification:test-id").Return(string(original), nil)
+		mockStorage.EXPECT().SortedSetScore(gomock.Any(), "delayed_notifications", "test-id").Return(float64(0), nil)
+		mockStorage.EXPECT().Add(gomock.Any(), "notification:test-id", gomock.Any(), gomock.Any()).Return(nil)
+		mockStorage.EXPECT().SortedSetAdd(gomock.Any(), "delayed_notifications", "test-id", float64(sendAt.UnixMilli())).Return(nil)
+
+		id, err := creator.SnoozeNotification(context.Background(), "test-id", sendAt)
+		require.NoError(t, err)
+		assert.Equal(t, "test-id", id)
+	})
+
+	t.Run("sent_is_recreated", func(t *testing.T) {
+		var payload []byte
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.status:test-id").Return(string(models.StatusSent), nil)
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.original:test-id").Return(string(original), nil)
+		mockStorage.EXPECT().AddIfAbsent(gomock.Any(), "notification.snoozed:test-id", gomock.Any(), gomock.Any()).Return(true, nil)
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.attachment:test-id:0").Return("%PDF", nil)
+		mockStorage.EXPECT().Add(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, key string, value interface{}, _ time.Duration) error {
+				assert.NotContains(t, key, "test-id")
+				if strings.HasPrefix(key, "notification:") {
+					payload = value.([]byte)
+				}
+				return nil
+			}).Times(4)
+		mockStorage.EXPECT().SortedSetAdd(gomock.Any(), "delayed_notifications", gomock.Any(), gomock.Any()).Return(nil)
+
+		id, err := creator.SnoozeNotification(context.Background(), "test-id", sendAt)
+		require.NoError(t, err)
+		assert.NotEqual(t, "test-id", id)
+
+		var snoozed models.DelayedNotification
+		require.NoError(t, json.Unmarshal(payload, &snoozed))
+		assert.Equal(t, id, snoozed.ID)
+		assert.Equal(t, "test-id", snoozed.SnoozedFrom)
+		assert.Equal(t, "notification.attachment:"+id+":0", snoozed.Channels.EmailChannel.Attachments[0].StorageKey)
+	})
+
+	t.Run("sent_twice_postpones_copy", func(t *testing.T) {
+		copied, err := json.Marshal(models.DelayedNotification{ID: "copy-id", SnoozedFrom: "test-id"})
+		require.NoError(t, err)
+
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.status:test-id").Return(string(models.StatusSent), nil)
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.original:test-id").Return(string(original), nil)
+		mockStorage.EXPECT().AddIfAbsent(gomock.Any(), "notification.snoozed:test-id", gomock.Any(), gomock.Any()).Return(false, nil)
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.snoozed:test-id").Return("copy-id", nil)
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.status:copy-id").Return(string(models.StatusScheduled), nil)
+		mockStorage.EXPECT().Get(gomock.Any(), "notification:copy-id").Return(string(copied), nil)
+		mockStorage.EXPECT().SortedSetScore(gomock.Any(), "delayed_notifications", "copy-id").Return(float64(0), nil)
+		mockStorage.EXPECT().Add(gomock.Any(), "notification:copy-id", gomock.Any(), gomock.Any()).Return(nil)
+		mockStorage.EXPECT().SortedSetAdd(gomock.Any(), "delayed_notifications", "copy-id", float64(sendAt.UnixMilli())).Return(nil)
+		mockStorage.EXPECT().Add(gomock.Any(), "notification.snoozed:test-id", "copy-id", gomock.Any()).Return(nil)
+
+		id, err := creator.SnoozeNotification(context.Background(), "test-id", sendAt)
+		require.NoError(t, err)
+		assert.Equal(t, "copy-id", id)
+	})
+
+	t.Run("original_expired", func(t *testing.T) {
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.status:test-id").Return(string(models.StatusSent), nil)
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.original:test-id").Return("", models.ErrNotFound)
+
+		_, err := creator.SnoozeNotification(context.Background(), "test-id", sendAt)
+		assert.ErrorIs(t, err, ErrNotificationNotFound)
+	})
+
+	t.Run("not_found", func(t *testing.T) {
+		mockStorage.EXPECT().Get(gomock.Any(), "notification.status:test-id").Return("", models.ErrNotFound)
+
+		_, err := creator.SnoozeNotification(context.Background(), "test-id", sendAt)
+		assert.ErrorIs(t, err, ErrNotificationNotFound)
+	})
+
+	t.Run("time_in_past", func(t *testing.T) {
+		_, err := creator.SnoozeNotification(context.Background(), "test-id", time.Now().Add(-time.Minute))
+		assert.ErrorIs(t, err, ErrInvalidSnoozeTime)
+	})
+}
+
+func TestNotificationCreator_ScheduleRecipientNotifications(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := allowStatusHistory(mock_usecase.NewMockstorage(ctrl))
+	mockRecipients := mock_usecase.NewMockrecipientGetter(ctrl)
+	creator := NewNotificationCreator(mockStorage, nil, nil, mockRecipients, "delayed_notifications", nil)
+
+	notification := models.DelayedNotification{Notification: "test message", Delay: 10 * time.Second}
+
+	t.Run("one_per_recipient", func(t *testing.T) {
+		mockRecipients.EXPECT().GetRecipient(gomock.Any(), gomock.Any()).Return(models.Recipient{}, nil).Times(2)
+
+		var scheduled []models.DelayedNotification
+		mockStorage.EXPECT().Add(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, key string, value interface{}, _ time.Duration) error {
+				if strings.HasPrefix(key, "notification:") {
+					var n models.DelayedNotification
+					require.NoError(t, json.Unmarshal(value.([]byte), &n))
+					scheduled = append(scheduled, n)
+				}
+				return nil
+			}).Times(6)
+		mockStorage.EXPECT().SortedSetAdd(gomock.Any(), "delayed_notifications", gomock.Any(), gomock.Any()).Return(nil).Times(2)
+
+		uids, err := creator.ScheduleRecipientNotifications(context.Background(), notification, []string{"user-1", "user-2"})
+		require.NoError(t, err)
+		require.Len(t, uids, 2)
+		require.Len(t, scheduled, 2)
+		for _, n := range scheduled {
+			assert.Equal(t, uids[n.RecipientID], n.ID)
+		}
+	})
+
+	t.Run("unknown_recipient", func(t *testing.T) {
+		mockRecipients.EXPECT().GetRecipient(gomock.Any(), "user-1").Return(models.Recipient{}, nil)
+		mockRecipients.EXPECT().GetRecipient(gomock.Any(), "ghost").Return(models.Recipient{}, ErrRecipientNotFound)
+
+		_, err := creator.ScheduleRecipientNotifications(context.Background(), notification, []string{"user-1", "ghost"})
+		assert.ErrorIs(t, err, ErrRecipientNotFound)
+	})
+}
+
+// allowStatusHistory разрешает запись истории статусов при планировании уведомлений.
+func allowStatusHistory(storage *mock_usecase.Mockstorage) *mock_usecase.Mockstorage {
+	storage.EXPECT().ListPushCapped(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	return storage
+}
+
+func TestNotificationCreator_GetStatusHistory(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := mock_usecase.NewMockstorage(ctrl)
+	creator := NewNotificationCreator(mockStorage, nil, nil, nil, "delayed_notifications", nil)
+
+	mockStorage.EXPECT().ListRange(gomock.Any(), "notification.history:test-id").Return([]string{
+		`{"status":"scheduled","at":"2025-03-14T21:00:00Z"}`,
+		`{"status":"deferred","at":"2025-03-14T23:00:00Z","note":"quiet hours until 2025-03-15T05:00:00Z"}`,
+	}, nil)
+
+	history, err := creator.GetStatusHistory(context.Background(), "test-id")
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, models.StatusScheduled, history[0].Status)
+	assert.Equal(t, models.StatusDeferred, history[1].Status)
+	assert.Equal(t, "quiet hours until 2025-03-15T05:00:00Z", history[1].Note)
+}
@@ -0,0 +1,169 @@
+package usecase
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
+)
+
+// preferencesSignatureBytes длина подписи токена страницы настроек.
+const preferencesSignatureBytes = 16
+
+// ограничения категорий отписки, как и в запросе замены настроек
+const (
+	maxCategoryLength     = 64
+	maxOptedOutCategories = 100
+)
+
+// ErrInvalidPreferencesToken возвращается, если токен страницы настроек подделан или поврежден.
+var ErrInvalidPreferencesToken = errors.New("invalid preferences token")
+
+// ErrInvalidCategory возвращается, если от категории нельзя отписаться: она слишком длинная
+// или получатель уже отписан от предельного числа категорий.
+var ErrInvalidCategory = errors.New("invalid category")
+
+type preferenceStorage interface {
+	Add(ctx context.Context, key string, value interface{}, exp time.Duration) error
+	Get(ctx context.Context, key string) (string, error)
+}
+
+// PreferenceManager хранит настройки получателей из реестра: от каких каналов и категорий уведомлений
+// они отказались, и выдает подписанные ссылки на страницу настроек и отписку.
+type PreferenceManager struct {
+	storage    preferenceStorage
+	recipients recipientGetter
+	secret     []byte // ключ HMAC подписи токенов
+	baseURL    string // публичный адрес сервиса для ссылок
+}
+
+// NewPreferenceManager создает новый PreferenceManager.
+func NewPreferenceManager(storage preferenceStorage, recipients recipientGetter, secret []byte, baseURL string) *PreferenceManager {
+	return &PreferenceManager{
+		storage:    storage,
+		recipients: recipients,
+		secret:     secret,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+	}
+}
+
+// PreferencesLink возвращает ссылку на страницу настроек получателя.
+func (pm *PreferenceManager) PreferencesLink(userID string) string {
+	return pm.baseURL + "/preferences/" + url.PathEscape(pm.token(userID))
+}
+
+// UnsubscribeLink возвращает ссылку отписки в один клик (RFC 8058) от категории category,
+// а если она пуста - от писем.
+func (pm *PreferenceManager) UnsubscribeLink(userID, category string) string {
+	link := pm.PreferencesLink(userID) + "/unsubscribe"
+	if category != "" {
+		link += "?category=" + url.QueryEscape(category)
+	}
+	return link
+}
+
+// token подписывает айди получателя. Айди может содержать точку, поэтому подпись отделяется последней.
+func (pm *PreferenceManager) token(userID string) string {
+	return userID + "." + pm.signature(userID)
+}
+
+func (pm *PreferenceManager) signature(userID string) string {
+	return hmacSignature(pm.secret, "preferences\n"+userID, preferencesSignatureBytes)
+}
+
+// ParsePreferencesToken проверяет токен страницы настроек и возвращает айди получателя.
+func (pm *PreferenceManager) ParsePreferencesToken(token string) (string, error) {
+	i := strings.LastIndex(token, ".")
+	if i < 0 || !hmac.Equal([]byte(token[i+1:]), []byte(pm.signature(token[:i]))) {
+		return "", ErrInvalidPreferencesToken
+	}
+	return token[:i], nil
+}
+
+// GetPreferences возвращает настройки получателя. Пока получатель их не менял, он принимает все уведомления.
+func (pm *PreferenceManager) GetPreferences(ctx context.Context, userID string) (models.Preferences, error) {
+	payload, err := pm.storage.Get(ctx, preferencesKey(userID))
+	if errors.Is(err, models.ErrNotFound) {
+		if _, err := pm.recipients.GetRecipient(ctx, userID); err != nil {
+			return models.Preferences{}, err
+		}
+		return models.Preferences{OptedOutChannels: []models.ChannelName{}, OptedOutCategories: []string{}}, nil
+	}
+	if err != nil {
+		return models.Preferences{}, err
+	}
+
+	var prefs models.Preferences
+	if err := json.Unmarshal([]byte(payload), &prefs); err != nil {
+		return models.Preferences{}, err
+	}
+
+	return prefs, nil
+}
+
+// UpdatePreferences заменяет настройки существующего получателя.
+// Они применяются и к уже запланированным уведомлениям.
+func (pm *PreferenceManager) UpdatePreferences(ctx context.Context, userID string, prefs models.Preferences) (models.Preferences, error) {
+	if _, err := pm.recipients.GetRecipient(ctx, userID); err != nil {
+		return models.Preferences{}, err
+	}
+
+	prefs.OptedOutChannels = sortedUnique(prefs.OptedOutChannels)
+	prefs.OptedOutCategories = sortedUnique(prefs.OptedOutCategories)
+
+	payload, err := json.Marshal(prefs)
+	if err != nil {
+		return models.Preferences{}, err
+	}
+	if err := pm.storage.Add(ctx, preferencesKey(userID), payload, 0); err != nil {
+		return models.Preferences{}, err
+	}
+
+	return prefs, nil
+}
+
+// Unsubscribe проверяет токен и отписывает получателя от категории category, а если она пуста - от писем.
+func (pm *PreferenceManager) Unsubscribe(ctx context.Context, token, category string) error {
+	userID, err := pm.ParsePreferencesToken(token)
+	if err != nil {
+		return err
+	}
+
+	prefs, err := pm.GetPreferences(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case len(category) > maxCategoryLength:
+		return fmt.Errorf("%w: longer than %d characters", ErrInvalidCategory, maxCategoryLength)
+	case category != "" && !slices.Contains(prefs.OptedOutCategories, category) &&
+		len(prefs.OptedOutCategories) >= maxOptedOutCategories:
+		return fmt.Errorf("%w: opted out of %d categories already", ErrInvalidCategory, maxOptedOutCategories)
+	case category != "":
+		prefs.OptedOutCategories = append(prefs.OptedOutCategories, category)
+	default:
+		prefs.OptedOutChannels = append(prefs.OptedOutChannels, models.ChannelEmail)
+	}
+
+	_, err = pm.UpdatePreferences(ctx, userID, prefs)
+	return err
+}
+
+// sortedUnique возвращает отсортированные значения без повторов, не nil.
+func sortedUnique[T ~string](values []T) []T {
+	values = slices.Clone(values)
+	slices.Sort(values)
+	return append(make([]T, 0, len(values)), slices.Compact(values)...)
+}
+
+func preferencesKey(userID string) string {
+	return "recipient.preferences:" + userID
+}
@@ -0,0 +1,164 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	mock_usecase "github.com/child6yo/wbtech-l3-delayed-notifyer/internal/usecase/mock"
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreferenceManager_Tokens(t *testing.T) {
+	t.Parallel()
+
+	manager := NewPreferenceManager(nil, nil, []byte("secret"), "https://notify.example.com/")
+
+	t.Run("round_trip", func(t *testing.T) {
+		link, err := url.Parse(manager.PreferencesLink("team.lead"))
+		require.NoError(t, err)
+		assert.Equal(t, "notify.example.com", link.Host)
+
+		token := strings.TrimPrefix(link.Path, "/preferences/")
+		userID, err := manager.ParsePreferencesToken(token)
+		require.NoError(t, err)
+		assert.Equal(t, "team.lead", userID)
+	})
+
+	t.Run("unsubscribe_link", func(t *testing.T) {
+		link, err := url.Parse(manager.UnsubscribeLink("user-1", "digest & news"))
+		require.NoError(t, err)
+		assert.True(t, strings.HasSuffix(link.Path, "/unsubscribe"))
+		assert.Equal(t, "digest & news", link.Query().Get("category"))
+	})
+
+	t.Run("tampered", func(t *testing.T) {
+		token := manager.token("user-1")
+		_, err := manager.ParsePreferencesToken("user-2" + token[len("user-1"):])
+		assert.ErrorIs(t, err, ErrInvalidPreferencesToken)
+
+		_, err = manager.ParsePreferencesToken("user-1")
+		assert.ErrorIs(t, err, ErrInvalidPreferencesToken)
+	})
+}
+
+func TestPreferenceManager_GetPreferences(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := mock_usecase.NewMockpreferenceStorage(ctrl)
+	mockRecipients := mock_usecase.NewMockrecipientGetter(ctrl)
+	manager := NewPreferenceManager(mockStorage, mockRecipients, []byte("secret"), "https://notify.example.com")
+
+	t.Run("defaults_accept_everything", func(t *testing.T) {
+		mockStorage.EXPECT().Get(gomock.Any(), "recipient.preferences:user-1").Return("", models.ErrNotFound)
+		mockRecipients.EXPECT().GetRecipient(gomock.Any(), "user-1").Return(models.Recipient{UserID: "user-1"}, nil)
+
+		prefs, err := manager.GetPreferences(context.Background(), "user-1")
+		require.NoError(t, err)
+		assert.Empty(t, prefs.OptedOutChannels)
+		assert.Empty(t, prefs.OptedOutCategories)
+	})
+
+	t.Run("recipient_not_found", func(t *testing.T) {
+		mockStorage.EXPECT().Get(gomock.Any(), "recipient.preferences:ghost").Return("", models.ErrNotFound)
+		mockRecipients.EXPECT().GetRecipient(gomock.Any(), "ghost").Return(models.Recipient{}, ErrRecipientNotFound)
+
+		_, err := manager.GetPreferences(context.Background(), "ghost")
+		assert.ErrorIs(t, err, ErrRecipientNotFound)
+	})
+}
+
+func TestPreferenceManager_Unsubscribe(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := mock_usecase.NewMockpreferenceStorage(ctrl)
+	mockRecipients := mock_usecase.NewMockrecipientGetter(ctrl)
+	manager := NewPreferenceManager(mockStorage, mockRecipients, []byte("secret"), "https://notify.example.com")
+
+	token := manager.token("user-1")
+	current := `{"opted_out_channels":["telegram"],"opted_out_categories":["marketing"]}`
+
+	tests := []struct {
+		name     string
+		category string
+		want     models.Preferences
+	}{
+		{
+			name:     "category",
+			category: "digest",
+			want: models.Preferences{
+				OptedOutChannels:   []models.ChannelName{models.ChannelTelegram},
+				OptedOutCategories: []string{"digest", "marketing"},
+			},
+		},
+		{
+			name:     "already_opted_out",
+			category: "marketing",
+			want: models.Preferences{
+				OptedOutChannels:   []models.ChannelName{models.ChannelTelegram},
+				OptedOutCategories: []string{"marketing"},
+			},
+		},
+		{
+			name: "email_without_category",
+			want: models.Preferences{
+				OptedOutChannels:   []models.ChannelName{models.ChannelEmail, models.ChannelTelegram},
+				OptedOutCategories: []string{"marketing"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage.EXPECT().Get(gomock.Any(), "recipient.preferences:user-1").Return(current, nil)
+			mockRecipients.EXPECT().GetRecipient(gomock.Any(), "user-1").Return(models.Recipient{UserID: "user-1"}, nil)
+			mockStorage.EXPECT().Add(gomock.Any(), "recipient.preferences:user-1", gomock.Any(), time.Duration(0)).
+				DoAndReturn(func(_ context.Context, _ string, value interface{}, _ time.Duration) error {
+					var saved models.Preferences
+					require.NoError(t, json.Unmarshal(value.([]byte), &saved))
+					assert.Equal(t, tt.want, saved)
+					return nil
+				})
+
+			require.NoError(t, manager.Unsubscribe(context.Background(), token, tt.category))
+		})
+	}
+
+	t.Run("category_too_long", func(t *testing.T) {
+		mockStorage.EXPECT().Get(gomock.Any(), "recipient.preferences:user-1").Return(current, nil)
+
+		err := manager.Unsubscribe(context.Background(), token, strings.Repeat("a", 65))
+		assert.ErrorIs(t, err, ErrInvalidCategory)
+	})
+
+	t.Run("too_many_categories", func(t *testing.T) {
+		prefs := models.Preferences{}
+		for i := range 100 {
+			prefs.OptedOutCategories = append(prefs.OptedOutCategories, fmt.Sprintf("category-%d", i))
+		}
+		full, err := json.Marshal(prefs)
+		require.NoError(t, err)
+		mockStorage.EXPECT().Get(gomock.Any(), "recipient.preferences:user-1").Return(string(full), nil)
+
+		err = manager.Unsubscribe(context.Background(), token, "digest")
+		assert.ErrorIs(t, err, ErrInvalidCategory)
+	})
+
+	t.Run("invalid_token", func(t *testing.T) {
+		err := manager.Unsubscribe(context.Background(), "user-1.forged", "digest")
+		assert.ErrorIs(t, err, ErrInvalidPreferencesToken)
+	})
+}
@@ -0,0 +1,58 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
+)
+
+// QuietHoursChecker проверяет, не приходится ли отправка уведомления на тихие часы:
+// заданные в самом уведомлении или у получателя из реестра.
+type QuietHoursChecker struct {
+	recipients recipientGetter
+}
+
+// NewQuietHoursChecker создает новый QuietHoursChecker.
+func NewQuietHoursChecker(recipients recipientGetter) *QuietHoursChecker {
+	return &QuietHoursChecker{recipients: recipients}
+}
+
+// QuietUntil возвращает конец тихих часов и действие с уведомлением, если момент now в них попадает.
+// Нулевое время означает, что уведомление можно отправлять.
+// Срочные уведомления с флагом BypassQuietHours отправляются всегда.
+func (qc *QuietHoursChecker) QuietUntil(
+	ctx context.Context, notification models.DelayedNotification, now time.Time,
+) (time.Time, models.QuietHoursAction, error) {
+	if notification.BypassQuietHours {
+		return time.Time{}, "", nil
+	}
+
+	quiet := notification.QuietHours
+	if quiet == nil && notification.RecipientID != "" {
+		recipient, err := qc.recipients.GetRecipient(ctx, notification.RecipientID)
+		if errors.Is(err, ErrRecipientNotFound) {
+			// удаленного получателя обработает отправщик
+			return time.Time{}, "", nil
+		}
+		if err != nil {
+			return time.Time{}, "", err
+		}
+		quiet = recipient.QuietHours
+	}
+	if quiet == nil {
+		return time.Time{}, "", nil
+	}
+
+	until, ok := quiet.Until(now)
+	if !ok {
+		return time.Time{}, "", nil
+	}
+
+	action := quiet.Action
+	if action == "" {
+		action = models.QuietHoursDefer
+	}
+	return until, action, nil
+}
@@ -0,0 +1,100 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	mock_usecase "github.com/child6yo/wbtech-l3-delayed-notifyer/internal/usecase/mock"
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuietHoursChecker_QuietUntil(t *testing.T) {
+	t.Parallel()
+
+	almaty, err := time.LoadLocation("Asia/Almaty")
+	require.NoError(t, err)
+
+	night := &models.QuietHours{Start: "22:00", End: "08:00", Timezone: "Asia/Almaty"}
+	lunch := &models.QuietHours{Start: "13:00", End: "14:00", Action: models.QuietHoursDrop}
+
+	tests := []struct {
+		name         string
+		notification models.DelayedNotification
+		now          time.Time
+		wantUntil    time.Time
+		wantAction   models.QuietHoursAction
+	}{
+		{
+			name:         "evening_part_of_overnight_window",
+			notification: models.DelayedNotification{QuietHours: night},
+			now:          time.Date(2025, 3, 14, 23, 30, 0, 0, almaty),
+			wantUntil:    time.Date(2025, 3, 15, 8, 0, 0, 0, almaty),
+			wantAction:   models.QuietHoursDefer,
+		},
+		{
+			name:         "morning_part_of_overnight_window",
+			notification: models.DelayedNotification{QuietHours: night},
+			now:          time.Date(2025, 3, 15, 3, 0, 0, 0, almaty),
+			wantUntil:    time.Date(2025, 3, 15, 8, 0, 0, 0, almaty),
+			wantAction:   models.QuietHoursDefer,
+		},
+		{
+			name:         "window_in_recipient_timezone",
+			notification: models.DelayedNotification{QuietHours: night},
+			now:          time.Date(2025, 3, 14, 17, 0, 0, 0, time.UTC), // 22:00 в Алматы
+			wantUntil:    time.Date(2025, 3, 15, 8, 0, 0, 0, almaty),
+			wantAction:   models.QuietHoursDefer,
+		},
+		{
+			name:         "end_of_window_is_outside",
+			notification: models.DelayedNotification{QuietHours: night},
+			now:          time.Date(2025, 3, 15, 8, 0, 0, 0, almaty),
+		},
+		{
+			name:         "same_day_window_drops",
+			notification: models.DelayedNotification{QuietHours: lunch},
+			now:          time.Date(2025, 3, 14, 13, 15, 0, 0, time.UTC),
+			wantUntil:    time.Date(2025, 3, 14, 14, 0, 0, 0, time.UTC),
+			wantAction:   models.QuietHoursDrop,
+		},
+		{
+			name:         "bypass_for_urgent",
+			notification: models.DelayedNotification{QuietHours: night, BypassQuietHours: true},
+			now:          time.Date(2025, 3, 14, 23, 30, 0, 0, almaty),
+		},
+		{
+			name:         "recipient_quiet_hours",
+			notification: models.DelayedNotification{RecipientID: "user-1"},
+			now:          time.Date(2025, 3, 14, 23, 30, 0, 0, almaty),
+			wantUntil:    time.Date(2025, 3, 15, 8, 0, 0, 0, almaty),
+			wantAction:   models.QuietHoursDefer,
+		},
+		{
+			name:         "notification_overrides_recipient",
+			notification: models.DelayedNotification{RecipientID: "user-1", QuietHours: lunch},
+			now:          time.Date(2025, 3, 14, 23, 30, 0, 0, almaty),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRecipients := mock_usecase.NewMockrecipientGetter(ctrl)
+			mockRecipients.EXPECT().GetRecipient(gomock.Any(), "user-1").
+				Return(models.Recipient{UserID: "user-1", QuietHours: night}, nil).AnyTimes()
+
+			until, action, err := NewQuietHoursChecker(mockRecipients).QuietUntil(context.Background(), tt.notification, tt.now)
+			require.NoError(t, err)
+			assert.True(t, tt.wantUntil.Equal(until), "until %s, want %s", until, tt.wantUntil)
+			assert.Equal(t, tt.wantAction, action)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
+)
+
+type tokenBucket interface {
+	TakeToken(ctx context.Context, key string, capacity int, window time.Duration) (time.Duration, error)
+}
+
+// RateLimitError возвращается отправкой по каналу, если превышен лимит отправки получателю.
+type RateLimitError struct {
+	Channel    models.ChannelName
+	Action     models.RateLimitAction
+	RetryAfter time.Duration // через сколько лимит пополнится
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("%s rate limit exceeded, retry after %s", e.Channel, e.RetryAfter)
+}
+
+// RateLimiter ограничивает частоту отправки по каналу одному получателю: адресу email, чату телеграм
+// или хосту вебхука. Лимиты считаются корзинами токенов в хранилище, поэтому общие для всех экземпляров сервиса.
+type RateLimiter struct {
+	buckets tokenBucket
+	limits  map[models.ChannelName]models.RateLimit
+	action  models.RateLimitAction
+}
+
+// NewRateLimiter создает новый RateLimiter. Каналы без лимита в limits не ограничиваются.
+func NewRateLimiter(buckets tokenBucket, limits map[models.ChannelName]models.RateLimit, action models.RateLimitAction) *RateLimiter {
+	return &RateLimiter{buckets: buckets, limits: limits, action: action}
+}
+
+// Take расходует лимит отправки по каналу получателю address.
+// Если лимит исчерпан, возвращает *RateLimitError с действием, заданным политикой.
+func (rl *RateLimiter) Take(ctx context.Context, channel models.ChannelName, address string) error {
+	limit, ok := rl.limits[channel]
+	if !ok || limit.Limit <= 0 || limit.Window <= 0 {
+		return nil
+	}
+
+	wait, err := rl.buckets.TakeToken(ctx, rateLimitKey(channel, address), limit.Limit, limit.Window)
+	if err != nil || wait <= 0 {
+		// при ошибке хранилища лучше отправить уведомление
+		return nil
+	}
+
+	return &RateLimitError{Channel: channel, Action: rl.action, RetryAfter: wait}
+}
+
+func rateLimitKey(channel models.ChannelName, address string) string {
+	return "ratelimit:" + string(channel) + ":" + address
+}
@@ -0,0 +1,60 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	mock_usecase "github.com/child6yo/wbtech-l3-delayed-notifyer/internal/usecase/mock"
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter_Take(t *testing.T) {
+	t.Parallel()
+
+	limits := map[models.ChannelName]models.RateLimit{
+		models.ChannelTelegram: {Limit: 20, Window: time.Hour},
+	}
+
+	tests := []struct {
+		name      string
+		channel   models.ChannelName
+		wait      time.Duration
+		bucketErr error
+		calls     int
+		wantErr   bool
+	}{
+		{name: "allowed", channel: models.ChannelTelegram, calls: 1},
+		{name: "exceeded", channel: models.ChannelTelegram, wait: 3 * time.Minute, calls: 1, wantErr: true},
+		{name: "storage_error_allows", channel: models.ChannelTelegram, bucketErr: errors.New("redis down"), calls: 1},
+		{name: "channel_without_limit", channel: models.ChannelEmail},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockBuckets := mock_usecase.NewMocktokenBucket(ctrl)
+			mockBuckets.EXPECT().TakeToken(gomock.Any(), "ratelimit:telegram:123456", 20, time.Hour).
+				Return(tt.wait, tt.bucketErr).Times(tt.calls)
+
+			limiter := NewRateLimiter(mockBuckets, limits, models.RateLimitReject)
+			err := limiter.Take(context.Background(), tt.channel, "123456")
+			if !tt.wantErr {
+				require.NoError(t, err)
+				return
+			}
+
+			var limitErr *RateLimitError
+			require.ErrorAs(t, err, &limitErr)
+			assert.Equal(t, RateLimitError{Channel: models.ChannelTelegram, Action: models.RateLimitReject, RetryAfter: 3 * time.Minute}, *limitErr)
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package usecase
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
+)
+
+// readinessCheckTimeout ограничение на проверку одного отправщика.
+const readinessCheckTimeout = 5 * time.Second
+
+type channelPinger interface {
+	Ping(ctx context.Context) error
+}
+
+// ReadinessChecker проверяет доступность отправщиков включенных каналов.
+type ReadinessChecker struct {
+	senders     map[models.ChannelName]channelPinger
+	unavailable map[models.ChannelName]error // каналы, отправщики которых не удалось создать
+	interval    time.Duration                // как долго переиспользуется результат проверки
+
+	mu        sync.Mutex
+	checkedAt time.Time
+	results   map[models.ChannelName]error
+}
+
+// NewReadinessChecker создает новый ReadinessChecker.
+// Результат проверки переиспользуется в течение interval, чтобы частые пробы не нагружали SMTP сервер и Bot API.
+func NewReadinessChecker(interval time.Duration) *ReadinessChecker {
+	return &ReadinessChecker{
+		senders:     make(map[models.ChannelName]channelPinger),
+		unavailable: make(map[models.ChannelName]error),
+		interval:    interval,
+	}
+}
+
+// AddChannel добавляет к проверке отправщик включенного канала.
+func (rc *ReadinessChecker) AddChannel(name models.ChannelName, sender channelPinger) {
+	rc.senders[name] = sender
+}
+
+// AddUnavailableChannel отмечает включенный канал, отправщик которого не удалось создать.
+func (rc *ReadinessChecker) AddUnavailableChannel(name models.ChannelName, err error) {
+	rc.unavailable[name] = err
+}
+
+// CheckChannels возвращает результат проверки для каждого включенного канала:
+// nil, если канал доступен, иначе причину недоступности. Проверка выполняется не чаще раза в interval.
+func (rc *ReadinessChecker) CheckChannels(ctx context.Context) map[models.ChannelName]error {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if rc.results == nil || time.Since(rc.checkedAt) >= rc.interval {
+		rc.results = rc.check(ctx)
+		rc.checkedAt = time.Now()
+	}
+	return maps.Clone(rc.results)
+}
+
+// check параллельно проверяет отправщики.
+func (rc *ReadinessChecker) check(ctx context.Context) map[models.ChannelName]error {
+	ctx, cancel := context.WithTimeout(ctx, readinessCheckTimeout)
+	defer cancel()
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		results = make(map[models.ChannelName]error, len(rc.senders)+len(rc.unavailable))
+	)
+
+	for name, err := range rc.unavailable {
+		results[name] = err
+	}
+
+	for name, sender := range rc.senders {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := sender.Ping(ctx)
+
+			mu.Lock()
+			defer mu.Unlock()
+			results[name] = err
+		}()
+	}
+
+	wg.Wait()
+	return results
+}
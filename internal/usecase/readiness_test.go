@@ -0,0 +1,40 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	mock_usecase "github.com/child6yo/wbtech-l3-delayed-notifyer/internal/usecase/mock"
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadinessChecker_CheckChannels(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockEmail := mock_usecase.NewMockchannelPinger(ctrl)
+	mockWebhook := mock_usecase.NewMockchannelPinger(ctrl)
+
+	mockEmail.EXPECT().Ping(gomock.Any()).Return(errors.New("connection refused"))
+	mockWebhook.EXPECT().Ping(gomock.Any()).Return(nil)
+
+	checker := NewReadinessChecker(time.Minute)
+	checker.AddChannel(models.ChannelEmail, mockEmail)
+	checker.AddChannel(models.ChannelWebhook, mockWebhook)
+	checker.AddUnavailableChannel(models.ChannelTelegram, errors.New("empty token"))
+
+	results := checker.CheckChannels(context.Background())
+	assert.Len(t, results, 3)
+	assert.EqualError(t, results[models.ChannelEmail], "connection refused")
+	assert.EqualError(t, results[models.ChannelTelegram], "empty token")
+	assert.NoError(t, results[models.ChannelWebhook])
+
+	// повторная проверка в пределах интервала не обращается к отправщикам
+	assert.Equal(t, results, checker.CheckChannels(context.Background()))
+}
@@ -0,0 +1,155 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
+)
+
+// recipientsSetName множество айди получателей, упорядоченное по времени создания.
+const recipientsSetName = "recipients"
+
+var (
+	// ErrRecipientNotFound возвращается, если получателя нет в реестре.
+	ErrRecipientNotFound = errors.New("recipient not found")
+
+	// ErrRecipientExists возвращается при создании получателя с уже занятым айди.
+	ErrRecipientExists = errors.New("recipient already exists")
+)
+
+type recipientStorage interface {
+	Add(ctx context.Context, key string, value interface{}, exp time.Duration) error
+	Get(ctx context.Context, key string) (string, error)
+	Remove(ctx context.Context, key string) error
+	SortedSetAdd(ctx context.Context, set string, value interface{}, score float64) error
+	SortedSetRangeByScore(ctx context.Context, key, min, max string, offset, count int64) ([]string, error)
+	SortedSetRemove(ctx context.Context, set string, value interface{}) error
+}
+
+// RecipientManager хранит реестр получателей уведомлений.
+// Изменение адресов применяется и к уже запланированным уведомлениям получателя.
+type RecipientManager struct {
+	storage recipientStorage
+}
+
+// NewRecipientManager создает новый RecipientManager.
+func NewRecipientManager(storage recipientStorage) *RecipientManager {
+	return &RecipientManager{storage: storage}
+}
+
+// CreateRecipient сохраняет нового получателя под айди пользователя вызывающей системы.
+func (rm *RecipientManager) CreateRecipient(ctx context.Context, recipient models.Recipient) (models.Recipient, error) {
+	_, err := rm.GetRecipient(ctx, recipient.UserID)
+	if err == nil {
+		return models.Recipient{}, fmt.Errorf("%w: %s", ErrRecipientExists, recipient.UserID)
+	}
+	if !errors.Is(err, ErrRecipientNotFound) {
+		return models.Recipient{}, err
+	}
+
+	recipient.CreatedAt = time.Now().UTC()
+	recipient.UpdatedAt = recipient.CreatedAt
+
+	if err := rm.save(ctx, recipient); err != nil {
+		return models.Recipient{}, err
+	}
+
+	err = rm.storage.SortedSetAdd(ctx, recipientsSetName, recipient.UserID, float64(recipient.CreatedAt.UnixMilli()))
+	if err != nil {
+		return models.Recipient{}, err
+	}
+
+	return recipient, nil
+}
+
+// UpdateRecipient заменяет адреса существующего получателя.
+func (rm *RecipientManager) UpdateRecipient(ctx context.Context, userID string, recipient models.Recipient) (models.Recipient, error) {
+	current, err := rm.GetRecipient(ctx, userID)
+	if err != nil {
+		return models.Recipient{}, err
+	}
+
+	recipient.UserID = userID
+	recipient.CreatedAt = current.CreatedAt
+	recipient.UpdatedAt = time.Now().UTC()
+
+	if err := rm.save(ctx, recipient); err != nil {
+		return models.Recipient{}, err
+	}
+
+	return recipient, nil
+}
+
+// GetRecipient возвращает получателя по айди пользователя.
+func (rm *RecipientManager) GetRecipient(ctx context.Context, userID string) (models.Recipient, error) {
+	payload, err := rm.storage.Get(ctx, recipientKey(userID))
+	if errors.Is(err, models.ErrNotFound) {
+		return models.Recipient{}, fmt.Errorf("%w: %s", ErrRecipientNotFound, userID)
+	}
+	if err != nil {
+		return models.Recipient{}, err
+	}
+
+	var recipient models.Recipient
+	if err := json.Unmarshal([]byte(payload), &recipient); err != nil {
+		return models.Recipient{}, err
+	}
+
+	return recipient, nil
+}
+
+// ListRecipients возвращает всех получателей.
+func (rm *RecipientManager) ListRecipients(ctx context.Context) ([]models.Recipient, error) {
+	ids, err := rm.storage.SortedSetRangeByScore(ctx, recipientsSetName, "-inf", "+inf", 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	recipients := make([]models.Recipient, 0, len(ids))
+	for _, id := range ids {
+		recipient, err := rm.GetRecipient(ctx, id)
+		if errors.Is(err, ErrRecipientNotFound) {
+			continue // удален параллельно
+		}
+		if err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, recipient)
+	}
+
+	return recipients, nil
+}
+
+// DeleteRecipient удаляет получателя. Его запланированные уведомления завершатся ошибкой отправки.
+func (rm *RecipientManager) DeleteRecipient(ctx context.Context, userID string) error {
+	if _, err := rm.GetRecipient(ctx, userID); err != nil {
+		return err
+	}
+
+	if err := rm.storage.SortedSetRemove(ctx, recipientsSetName, userID); err != nil {
+		return err
+	}
+
+	if err := rm.storage.Remove(ctx, preferencesKey(userID)); err != nil {
+		return err
+	}
+
+	return rm.storage.Remove(ctx, recipientKey(userID))
+}
+
+func (rm *RecipientManager) save(ctx context.Context, recipient models.Recipient) error {
+	payload, err := json.Marshal(recipient)
+	if err != nil {
+		return err
+	}
+
+	return rm.storage.Add(ctx, recipientKey(recipient.UserID), payload, 0)
+}
+
+func recipientKey(userID string) string {
+	return "recipient:" + userID
+}
@@ -0,0 +1,78 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	mock_usecase "github.com/child6yo/wbtech-l3-delayed-notifyer/internal/usecase/mock"
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecipientManager_CreateRecipient(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := mock_usecase.NewMockrecipientStorage(ctrl)
+	manager := NewRecipientManager(mockStorage)
+
+	t.Run("success", func(t *testing.T) {
+		mockStorage.EXPECT().Get(gomock.Any(), "recipient:user-1").Return("", models.ErrNotFound)
+		mockStorage.EXPECT().Add(gomock.Any(), "recipient:user-1", gomock.Any(), time.Duration(0)).Return(nil)
+		mockStorage.EXPECT().SortedSetAdd(gomock.Any(), "recipients", "user-1", gomock.Any()).Return(nil)
+
+		recipient, err := manager.CreateRecipient(context.Background(), models.Recipient{UserID: "user-1", Email: "user@example.com"})
+		require.NoError(t, err)
+		assert.Equal(t, "user@example.com", recipient.Email)
+		assert.False(t, recipient.CreatedAt.IsZero())
+	})
+
+	t.Run("already_exists", func(t *testing.T) {
+		mockStorage.EXPECT().Get(gomock.Any(), "recipient:user-1").Return(`{"user_id":"user-1"}`, nil)
+
+		_, err := manager.CreateRecipient(context.Background(), models.Recipient{UserID: "user-1"})
+		assert.ErrorIs(t, err, ErrRecipientExists)
+	})
+}
+
+func TestRecipientManager_UpdateRecipient(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := mock_usecase.NewMockrecipientStorage(ctrl)
+	manager := NewRecipientManager(mockStorage)
+
+	createdAt := time.Date(2025, 3, 14, 10, 0, 0, 0, time.UTC)
+	current, err := json.Marshal(models.Recipient{UserID: "user-1", Email: "old@example.com", CreatedAt: createdAt})
+	require.NoError(t, err)
+
+	t.Run("replaces_addresses", func(t *testing.T) {
+		var saved models.Recipient
+		mockStorage.EXPECT().Get(gomock.Any(), "recipient:user-1").Return(string(current), nil)
+		mockStorage.EXPECT().Add(gomock.Any(), "recipient:user-1", gomock.Any(), time.Duration(0)).
+			DoAndReturn(func(_ context.Context, _ string, value interface{}, _ time.Duration) error {
+				return json.Unmarshal(value.([]byte), &saved)
+			})
+
+		_, err := manager.UpdateRecipient(context.Background(), "user-1", models.Recipient{Email: "new@example.com"})
+		require.NoError(t, err)
+		assert.Equal(t, "user-1", saved.UserID)
+		assert.Equal(t, "new@example.com", saved.Email)
+		assert.Equal(t, createdAt, saved.CreatedAt)
+	})
+
+	t.Run("not_found", func(t *testing.T) {
+		mockStorage.EXPECT().Get(gomock.Any(), "recipient:user-2").Return("", models.ErrNotFound)
+
+		_, err := manager.UpdateRecipient(context.Background(), "user-2", models.Recipient{Email: "new@example.com"})
+		assert.ErrorIs(t, err, ErrRecipientNotFound)
+	})
+}
@@ -0,0 +1,186 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	maxReminderDelay      = 30 * 24 * time.Hour // как и delay_seconds в API
+	maxReminderTextLength = 1000
+	defaultReminderHour   = 9 // для "завтра" без времени
+)
+
+// ErrInvalidReminder возвращается, если из фразы не удалось понять, когда и о чем напомнить.
+var ErrInvalidReminder = errors.New("invalid reminder")
+
+// parseReminder разбирает фразу напоминания: сначала время, затем текст.
+// Время задается так же, как в parseWhen.
+func parseReminder(phrase string, now time.Time) (time.Time, string, error) {
+	sendAt, rest, err := parseWhen(strings.Fields(phrase), now)
+	if err != nil {
+		return time.Time{}, "", err
+	}
+
+	text := strings.Join(rest, " ")
+	if text == "" {
+		return time.Time{}, "", fmt.Errorf("%w: no text", ErrInvalidReminder)
+	}
+	if utf8.RuneCountInString(text) > maxReminderTextLength {
+		return time.Time{}, "", fmt.Errorf("%w: text is longer than %d characters", ErrInvalidReminder, maxReminderTextLength)
+	}
+
+	return sendAt, text, nil
+}
+
+// parseWhen разбирает время в начале фразы и возвращает оставшиеся слова. Поддерживаются:
+//   - длительность: 30m, 1h30m, 2d;
+//   - "in 2 hours", "через 2 часа", "через час";
+//   - "at 18:30", "в 18:30" - сегодня, а если время уже прошло, то завтра;
+//   - "tomorrow", "завтра" с необязательным "at 18:30", по умолчанию в 9:00.
+//
+// Время суток отсчитывается в часовом поясе now.
+func parseWhen(fields []string, now time.Time) (time.Time, []string, error) {
+	if len(fields) == 0 {
+		return time.Time{}, nil, fmt.Errorf("%w: no time", ErrInvalidReminder)
+	}
+
+	var (
+		sendAt time.Time
+		rest   []string
+		err    error
+	)
+	switch strings.ToLower(fields[0]) {
+	case "in", "через":
+		sendAt, rest, err = parseIn(fields[1:], now)
+	case "at", "в":
+		sendAt, rest, err = parseAt(fields[1:], now)
+	case "tomorrow", "завтра":
+		sendAt, rest, err = parseTomorrow(fields[1:], now)
+	default:
+		var d time.Duration
+		d, err = parseDelay(fields[0])
+		sendAt, rest = now.Add(d), fields[1:]
+	}
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+
+	if d := sendAt.Sub(now); d <= 0 || d > maxReminderDelay {
+		return time.Time{}, nil, fmt.Errorf("%w: time must be within %s", ErrInvalidReminder, maxReminderDelay)
+	}
+
+	return sendAt, rest, nil
+}
+
+// parseIn разбирает "30m", "2 hours", "2 часа" или "час" после "in"/"через".
+func parseIn(fields []string, now time.Time) (time.Time, []string, error) {
+	if len(fields) == 0 {
+		return time.Time{}, nil, fmt.Errorf("%w: no time", ErrInvalidReminder)
+	}
+	if d, err := parseDelay(fields[0]); err == nil {
+		return now.Add(d), fields[1:], nil
+	}
+
+	n, err := strconv.Atoi(fields[0])
+	if err != nil {
+		// "через час"
+		n = 1
+	} else {
+		fields = fields[1:]
+	}
+	if len(fields) == 0 || n <= 0 {
+		return time.Time{}, nil, fmt.Errorf("%w: no time unit", ErrInvalidReminder)
+	}
+
+	unit, ok := parseUnit(fields[0])
+	if !ok {
+		return time.Time{}, nil, fmt.Errorf("%w: unknown time unit %q", ErrInvalidReminder, fields[0])
+	}
+
+	return now.Add(time.Duration(n) * unit), fields[1:], nil
+}
+
+// parseAt разбирает время суток после "at"/"в".
+func parseAt(fields []string, now time.Time) (time.Time, []string, error) {
+	if len(fields) == 0 {
+		return time.Time{}, nil, fmt.Errorf("%w: no time of day", ErrInvalidReminder)
+	}
+
+	hour, minute, err := parseClock(fields[0])
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+
+	sendAt := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
+	if !sendAt.After(now) {
+		sendAt = sendAt.AddDate(0, 0, 1)
+	}
+
+	return sendAt, fields[1:], nil
+}
+
+// parseTomorrow разбирает необязательное время суток после "tomorrow"/"завтра".
+func parseTomorrow(fields []string, now time.Time) (time.Time, []string, error) {
+	hour, minute := defaultReminderHour, 0
+	if len(fields) >= 2 && (strings.EqualFold(fields[0], "at") || strings.EqualFold(fields[0], "в")) {
+		var err error
+		if hour, minute, err = parseClock(fields[1]); err != nil {
+			return time.Time{}, nil, err
+		}
+		fields = fields[2:]
+	}
+
+	tomorrow := now.AddDate(0, 0, 1)
+	return time.Date(tomorrow.Year(), tomorrow.Month(), tomorrow.Day(), hour, minute, 0, 0, now.Location()), fields, nil
+}
+
+// parseDelay разбирает длительность в формате time.ParseDuration, дополнительно с днями: 2d.
+func parseDelay(s string) (time.Duration, error) {
+	var (
+		d   time.Duration
+		err error
+	)
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		var n int
+		n, err = strconv.Atoi(days)
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		d, err = time.ParseDuration(s)
+	}
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("%w: invalid duration %q", ErrInvalidReminder, s)
+	}
+
+	return d, nil
+}
+
+// parseClock разбирает время суток в формате 18:30.
+func parseClock(s string) (int, int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: invalid time of day %q", ErrInvalidReminder, s)
+	}
+	return t.Hour(), t.Minute(), nil
+}
+
+// parseUnit разбирает единицу времени на английском или русском в любом числе и падеже.
+func parseUnit(s string) (time.Duration, bool) {
+	s = strings.ToLower(s)
+	switch {
+	case s == "s" || s == "sec" || strings.HasPrefix(s, "second") || strings.HasPrefix(s, "сек"):
+		return time.Second, true
+	case s == "m" || s == "min" || s == "mins" || strings.HasPrefix(s, "minute") || strings.HasPrefix(s, "мин"):
+		return time.Minute, true
+	case s == "h" || strings.HasPrefix(s, "hour") || strings.HasPrefix(s, "час"):
+		return time.Hour, true
+	case s == "d" || strings.HasPrefix(s, "day") || s == "день" || s == "дня" || s == "дней":
+		return 24 * time.Hour, true
+	default:
+		return 0, false
+	}
+}
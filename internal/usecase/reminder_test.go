@@ -0,0 +1,58 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReminder(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 3, 14, 15, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		sendAt time.Time
+		text   string
+	}{
+		"30m call mom":                  {now.Add(30 * time.Minute), "call mom"},
+		"1h30m созвон":                  {now.Add(90 * time.Minute), "созвон"},
+		"2d продлить домен":             {now.Add(48 * time.Hour), "продлить домен"},
+		"in 2 hours standup":            {now.Add(2 * time.Hour), "standup"},
+		"через 5 минут чайник":          {now.Add(5 * time.Minute), "чайник"},
+		"через час обед":                {now.Add(time.Hour), "обед"},
+		"in 10m stretch":                {now.Add(10 * time.Minute), "stretch"},
+		"at 18:30 gym":                  {time.Date(2025, 3, 14, 18, 30, 0, 0, time.UTC), "gym"},
+		"в 9:00 отчет":                  {time.Date(2025, 3, 15, 9, 0, 0, 0, time.UTC), "отчет"},
+		"завтра купить хлеб":            {time.Date(2025, 3, 15, 9, 0, 0, 0, time.UTC), "купить хлеб"},
+		"tomorrow at 7:15 flight check": {time.Date(2025, 3, 15, 7, 15, 0, 0, time.UTC), "flight check"},
+	}
+
+	for phrase, want := range tests {
+		sendAt, text, err := parseReminder(phrase, now)
+		require.NoError(t, err, phrase)
+		assert.Equal(t, want.sendAt, sendAt, phrase)
+		assert.Equal(t, want.text, text, phrase)
+	}
+}
+
+func TestParseReminder_Invalid(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 3, 14, 15, 0, 0, 0, time.UTC)
+
+	for _, phrase := range []string{
+		"",
+		"call mom",
+		"30m",
+		"-5m call mom",
+		"31d call mom",
+		"через 2 недели отпуск",
+		"at 25:00 gym",
+	} {
+		_, _, err := parseReminder(phrase, now)
+		assert.ErrorIs(t, err, ErrInvalidReminder, phrase)
+	}
+}
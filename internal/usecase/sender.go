@@ -2,122 +2,1003 @@ package usecase
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"html"
+	"math"
+	"net/url"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
-	"github.com/wb-go/wbf/retry"
 )
 
 type storageAdder interface {
 	Add(ctx context.Context, key string, value interface{}, exp time.Duration) error
+	ListPushCapped(ctx context.Context, key string, value interface{}, size int64, exp time.Duration) error
+}
+
+type notificationRescheduler interface {
+	RescheduleNotification(ctx context.Context, notification models.DelayedNotification, sendAt time.Time) error
+}
+
+type attachmentLoader interface {
+	Load(ctx context.Context, attachments []models.Attachment) ([]models.Attachment, error)
 }
 
 type telegramSender interface {
-	Send(chatID string, data string) error
+	Send(ctx context.Context, message models.TelegramMessage) error
+}
+
+type webhookSender interface {
+	Send(ctx context.Context, message models.WebhookMessage) error
+}
+
+type ackTracker interface {
+	AckLink(uid, recipient string) string
+	AckCallbackData(uid string) string
+	IsAcknowledged(ctx context.Context, uid string) (bool, error)
+}
+
+type snoozeLinker interface {
+	SnoozeLink(uid string, d time.Duration) string
+	SnoozeCallbackData(uid string, d time.Duration) string
+}
+
+type preferenceChecker interface {
+	GetPreferences(ctx context.Context, userID string) (models.Preferences, error)
+	PreferencesLink(userID string) string
+	UnsubscribeLink(userID, category string) string
+}
+
+type rateLimiter interface {
+	Take(ctx context.Context, channel models.ChannelName, address string) error
+}
+
+type channelBreaker interface {
+	Allow(name models.ChannelName) error
+	Record(name models.ChannelName, err error)
+}
+
+type digestStore interface {
+	DigestMembers(ctx context.Context, digestID string) ([]models.DelayedNotification, error)
+	CompleteDigest(ctx context.Context, digestID string, status models.NotificationStatus) error
+}
+
+type chatMuteChecker interface {
+	IsChatMuted(ctx context.Context, chatID string) (bool, error)
 }
 
 type emailSender interface {
-	Send(emailAddr string, data string) error
+	Send(ctx context.Context, email models.EmailMessage) error
 }
 
+// ErrSendInterrupted возвращается, если отправка прервана отменой контекста.
+// Такое уведомление возвращается в отложенную очередь без расхода попытки.
+var ErrSendInterrupted = errors.New("sending interrupted")
+
+// ErrNoRecipientAddress возвращается, если у получателя из реестра нет адреса ни в одном включенном канале.
+var ErrNoRecipientAddress = errors.New("recipient has no address in enabled channels")
+
+// ErrChannelDisabled возвращается при отправке по каналу, который выключен в конфиге или не настроен.
+var ErrChannelDisabled = errors.New("channel is disabled")
+
+// errLoadAttachments оборачивает ошибку загрузки вложений письма.
+var errLoadAttachments = errors.New("failed to load attachments")
+
+// channelError ошибка отправки по каналу channel.
+type channelError struct {
+	channel models.ChannelName
+	err     error
+}
+
+func (e *channelError) Error() string {
+	return fmt.Sprintf("%s channel: %v", e.channel, e.err)
+}
+
+func (e *channelError) Unwrap() error {
+	return e.err
+}
+
+// ackButtonText подпись кнопки и ссылки подтверждения получения.
+const ackButtonText = "Подтвердить получение"
+
+// preferencesLinkText подпись ссылки на страницу настроек получателя в письме.
+const preferencesLinkText = "Настроить уведомления"
+
+// digestSubject тема письма со сводкой уведомлений.
+const digestSubject = "Сводка уведомлений"
+
 // NotificationSender рассылает уведомления по разным каналам их отправщиками.
 type NotificationSender struct {
 	emailSender  emailSender
 	tgSender     telegramSender
+	whSender     webhookSender
 	storageAdder storageAdder
+	rescheduler  notificationRescheduler // возвращает неотправленные уведомления в отложенную очередь
+	attachments  attachmentLoader
+	templates    templateRenderer
+	acks         ackTracker
+	mutes        chatMuteChecker // чаты, в которых уведомления выключены командой /mute
+	snoozes      snoozeLinker
+	recipients   recipientGetter // реестр, из которого берутся адреса получателей при отправке
+	preferences  preferenceChecker
+	limits       rateLimiter    // nil, если лимиты отправки не заданы
+	breakers     channelBreaker // nil, если автоматы отключения выключены
+	digests      digestStore
 
 	sendRetryAttemps int
 	sendRetryDelay   time.Duration
 	sendRetryBackoff float64
 }
 
+// NotificationSenderConfig зависимости и настройки NotificationSender.
+type NotificationSenderConfig struct {
+	// отправщики каналов, nil - канал выключен
+	EmailSender    emailSender
+	TelegramSender telegramSender
+	WebhookSender  webhookSender
+
+	Storage     storageAdder
+	Rescheduler notificationRescheduler // возвращает неотправленные уведомления в отложенную очередь
+	Attachments attachmentLoader
+	Templates   templateRenderer
+	Acks        ackTracker
+	Mutes       chatMuteChecker // чаты, в которых уведомления выключены командой /mute
+	Snoozes     snoozeLinker
+	Recipients  recipientGetter // реестр, из которого берутся адреса получателей при отправке
+	Preferences preferenceChecker
+	Limits      rateLimiter    // nil, если лимиты отправки не заданы
+	Breakers    channelBreaker // nil, если автоматы отключения выключены
+	Digests     digestStore
+
+	RetryAttempts int           // предельное число попыток отправки
+	RetryDelay    time.Duration // задержка перед второй попыткой
+	RetryBackoff  float64       // во сколько раз растет задержка с каждой попыткой
+}
+
 // NewNotificationSender создает новый NotificationSender.
-func NewNotificationSender(
-	emailSender emailSender, tgSender telegramSender, storageAdder storageAdder,
-	sendRetryAttemps int, sendRetryDelay time.Duration, sendRetryBackoff float64,
-) *NotificationSender {
+func NewNotificationSender(cfg NotificationSenderConfig) *NotificationSender {
 	return &NotificationSender{
-		emailSender:      emailSender,
-		tgSender:         tgSender,
-		storageAdder:     storageAdder,
-		sendRetryAttemps: sendRetryAttemps,
-		sendRetryDelay:   sendRetryDelay,
-		sendRetryBackoff: sendRetryBackoff,
+		emailSender:      cfg.EmailSender,
+		tgSender:         cfg.TelegramSender,
+		whSender:         cfg.WebhookSender,
+		storageAdder:     cfg.Storage,
+		rescheduler:      cfg.Rescheduler,
+		attachments:      cfg.Attachments,
+		templates:        cfg.Templates,
+		acks:             cfg.Acks,
+		mutes:            cfg.Mutes,
+		snoozes:          cfg.Snoozes,
+		recipients:       cfg.Recipients,
+		preferences:      cfg.Preferences,
+		limits:           cfg.Limits,
+		breakers:         cfg.Breakers,
+		digests:          cfg.Digests,
+		sendRetryAttemps: cfg.RetryAttempts,
+		sendRetryDelay:   cfg.RetryDelay,
+		sendRetryBackoff: cfg.RetryBackoff,
 	}
 }
 
 // Send отправляет уведомления по указанным каналам и сохраняет статус.
+// Каналы, по которым отправить не удалось, переносятся в отложенную очередь
+// для следующей попытки, пока не исчерпан лимит попыток.
+// Если контекст отменен до или во время отправки, уведомление возвращается
+// в очередь со статусом interrupted, а ошибка оборачивает ErrSendInterrupted.
+// Уведомление, требующее подтверждения, повторяется или эскалируется, пока получатель его не подтвердит.
+// Каналы, от которых получатель из реестра отказался, не отправляются и получают статус suppressed.
+// Каналы, превысившие лимит отправки получателю, откладываются до пополнения лимита без расхода попытки
+// или, если так требует политика лимитов, не отправляются и получают статус rate_limited.
+// Итоговый статус сводки переносится на собранные в нее уведомления.
 func (ns *NotificationSender) Send(ctx context.Context, notification models.DelayedNotification) error {
-	errs := ns.sendNotifications(ctx, notification)
+	if ctx.Err() != nil {
+		return ns.handBack(ctx, notification, notification.Channels, nil)
+	}
+
+	if notification.Ack != nil {
+		// при ошибке хранилища лучше напомнить лишний раз, чем не напомнить
+		if acked, err := ns.acks.IsAcknowledged(ctx, notification.ID); err == nil && acked {
+			return ns.saveStatus(ctx, notification.ID, models.StatusSent, "")
+		}
+	}
+
+	var (
+		failed    models.Channels
+		delivered []models.ChannelName
+		errs      []error
+	)
+	content, suppressed, err := ns.prepare(ctx, &notification)
+	if errors.Is(err, ErrEmptyDigest) {
+		return ns.saveStatus(ctx, notification.ID, models.StatusDropped, err.Error())
+	}
+	if err != nil {
+		failed, errs = notification.Channels, []error{err}
+	} else {
+		failed, delivered, errs = ns.sendNotifications(ctx, notification, content)
+	}
+	notification.Delivered = append(slices.Clone(notification.Delivered), delivered...)
+	if len(errs) > 0 && ctx.Err() != nil {
+		return ns.handBack(ctx, notification, failed, errs)
+	}
+
+	rejected, errs := rejectRateLimited(&failed, errs)
+	disabled, errs := ns.dropDisabled(&failed, errs)
+	permanent, errs := dropPermanent(&failed, errs)
+
+	var note string
+	status := models.StatusSent
+	if len(errs) > 0 {
+		wait, limitedOnly := retryAfter(errs)
+		rescheduled, err := ns.scheduleRetry(ctx, notification, failed, wait, limitedOnly)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		status = ns.determineStatus(rescheduled)
+		if rescheduled && limitedOnly {
+			status = models.StatusDeferred
+			note = "until " + time.Now().Add(wait).UTC().Format(time.RFC3339) + ": " + errs[0].Error()
+		}
+	} else if len(disabled) > 0 || len(permanent) > 0 {
+		status = models.StatusFailed // повторная попытка не доставит по выключенному каналу или отвергнувшему получателю
+	} else if len(notification.Delivered) == 0 && len(rejected) > 0 {
+		status = models.StatusRejected // все оставшиеся каналы превысили лимит
+	} else if len(notification.Delivered) == 0 && len(suppressed) > 0 {
+		status = models.StatusSuppressed // получатель отказался от всех каналов уведомления
+	}
 
-	status := ns.determineStatus(errs)
-	if err := ns.saveStatus(ctx, notification.ID, status); err != nil {
+	if status == models.StatusSent || status == models.StatusFailed {
+		scheduled, err := ns.scheduleFollowUp(ctx, notification, len(errs) == 0 && len(disabled) == 0 && len(permanent) == 0)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if scheduled {
+			status = models.StatusAwaitingAck
+		}
+	}
+	errs = append(append(disabled, permanent...), errs...)
+
+	if err := ns.saveStatus(ctx, notification.ID, status, note); err != nil {
+		errs = append(errs, err)
+	}
+
+	if notification.Digest != nil {
+		if err := ns.digests.CompleteDigest(ctx, notification.ID, status); err != nil {
+			errs = append(errs, fmt.Errorf("failed to complete digest: %w", err))
+		}
+	}
+
+	if err := ns.saveDelivered(ctx, notification.ID, notification.Delivered); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := ns.saveChannels(ctx, "notification.suppressed:"+notification.ID, suppressed); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := ns.saveChannels(ctx, "notification.rate_limited:"+notification.ID, rejected); err != nil {
 		errs = append(errs, err)
 	}
 
 	return errors.Join(errs...)
 }
 
-// sendNotifications отправляет уведомления по email и Telegram (если указаны) с retry-логикой.
-func (ns *NotificationSender) sendNotifications(ctx context.Context, notification models.DelayedNotification) []error {
-	var wg sync.WaitGroup
-	errCh := make(chan error, 2)
+// handBack возвращает прерванные каналы уведомления в отложенную очередь к немедленной отправке.
+// Счетчик попыток не увеличивается: отправка не завершилась по вине канала.
+func (ns *NotificationSender) handBack(ctx context.Context, notification models.DelayedNotification, interrupted models.Channels, errs []error) error {
+	// хранилище должно получить статус, даже если контекст воркера уже отменен
+	ctx = context.WithoutCancel(ctx)
+	errs = append([]error{ErrSendInterrupted}, errs...)
 
-	if email := notification.Channels.EmailChannel.Email; email != "" {
-		wg.Add(1)
-		go ns.sendWithRetry(ctx, &wg, errCh, func() error {
-			return ns.emailSender.Send(email, string(notification.Notification))
-		})
+	status := models.StatusInterrupted
+	retry := notification
+	retry.Channels = interrupted
+	if err := ns.rescheduler.RescheduleNotification(ctx, retry, time.Now()); err != nil {
+		errs = append(errs, fmt.Errorf("failed to reschedule notification: %w", err))
+		status = models.StatusFailed
 	}
 
-	if tg := notification.Channels.TelegramChannel.ChatID; tg != "" {
-		wg.Add(1)
-		go ns.sendWithRetry(ctx, &wg, errCh, func() error {
-			return ns.tgSender.Send(tg, string(notification.Notification))
-		})
+	if err := ns.saveStatus(ctx, notification.ID, status, ""); err != nil {
+		errs = append(errs, err)
 	}
 
-	go func() {
-		wg.Wait()
-		close(errCh)
-	}()
-
-	var errs []error
-	for err := range errCh {
+	if err := ns.saveDelivered(ctx, notification.ID, notification.Delivered); err != nil {
 		errs = append(errs, err)
 	}
-	return errs
+
+	return errors.Join(errs...)
+}
+
+// prepare подставляет в каналы адреса получателя из реестра с учетом его настроек, убирает чат,
+// выключенный командой /mute, и готовит тексты для каналов.
+// Возвращает каналы, от которых получатель отказался, в том числе выключенный чат.
+func (ns *NotificationSender) prepare(ctx context.Context, notification *models.DelayedNotification) (notificationContent, []models.ChannelName, error) {
+	var suppressed []models.ChannelName
+	if notification.RecipientID != "" {
+		channels, optedOut, err := ns.recipientChannels(ctx, *notification)
+		if err != nil {
+			return notificationContent{}, nil, err
+		}
+		notification.Channels, suppressed = channels, optedOut
+	}
+
+	channels, muted := ns.withoutMutedChat(ctx, notification.Channels)
+	notification.Channels = channels
+	if muted {
+		suppressed = append(suppressed, models.ChannelTelegram)
+	}
+
+	content, err := ns.prepareContent(ctx, *notification)
+	return content, suppressed, err
 }
 
-// sendWithRetry оборачивает отправку с механизмом повторных попыток.
-func (ns *NotificationSender) sendWithRetry(ctx context.Context, wg *sync.WaitGroup, errCh chan<- error, sendFunc func() error) {
-	defer wg.Done()
-	err := retry.Do(sendFunc,
-		retry.Strategy{
-			Attempts: ns.sendRetryAttemps,
-			Delay:    ns.sendRetryDelay,
-			Backoff:  ns.sendRetryBackoff,
-		},
+// recipientChannels возвращает каналы с текущими адресами получателя уведомления из реестра
+// и каналы, от которых получатель отказался в настройках.
+// Выключенные каналы и каналы, доставленные в предыдущих попытках, пропускаются.
+func (ns *NotificationSender) recipientChannels(ctx context.Context, notification models.DelayedNotification) (models.Channels, []models.ChannelName, error) {
+	recipient, err := ns.recipients.GetRecipient(ctx, notification.RecipientID)
+	if err != nil {
+		return models.Channels{}, nil, fmt.Errorf("failed to resolve recipient: %w", err)
+	}
+
+	// без настроек нельзя отличить отписавшегося получателя, поэтому отправка откладывается до повторной попытки
+	prefs, err := ns.preferences.GetPreferences(ctx, recipient.UserID)
+	if err != nil {
+		return models.Channels{}, nil, fmt.Errorf("failed to load recipient preferences: %w", err)
+	}
+
+	var (
+		channels   models.Channels
+		suppressed []models.ChannelName
+		addressed  bool
 	)
+	for _, address := range []struct {
+		name    models.ChannelName
+		value   string
+		enabled bool
+	}{
+		{models.ChannelEmail, recipient.Email, ns.emailSender != nil},
+		{models.ChannelTelegram, recipient.TelegramChatID, ns.tgSender != nil},
+	} {
+		if address.value == "" || !address.enabled {
+			continue
+		}
+		addressed = true
+
+		switch {
+		case slices.Contains(notification.Delivered, address.name):
+		case prefs.OptedOut(address.name, notification.Category):
+			suppressed = append(suppressed, address.name)
+		case address.name == models.ChannelEmail:
+			channels.EmailChannel.Email = address.value
+		default:
+			channels.TelegramChannel.ChatID = address.value
+		}
+	}
+
+	if !addressed {
+		return models.Channels{}, nil, fmt.Errorf("%w: %s", ErrNoRecipientAddress, recipient.UserID)
+	}
+	return channels, suppressed, nil
+}
+
+// prepareContent готовит тексты для каналов, заполняя шаблон уведомления на языке получателя, если он задан.
+func (ns *NotificationSender) prepareContent(ctx context.Context, notification models.DelayedNotification) (notificationContent, error) {
+	if notification.Digest != nil {
+		return ns.digestContent(ctx, notification)
+	}
+
+	ref := notification.Template
+	if ref == nil {
+		return newNotificationContent(notification, nil), nil
+	}
+
+	rendered, err := ns.templates.RenderTemplate(
+		ctx, *ref, notification.Locale, notification.Channels.TelegramChannel.ParseMode)
+	if err != nil {
+		return notificationContent{}, fmt.Errorf("failed to render template: %w", err)
+	}
+
+	return newNotificationContent(notification, &rendered), nil
+}
+
+// digestContent собирает тексты собранных в сводку уведомлений в одно сообщение.
+// Если все уведомления сводки отменены, возвращает ErrEmptyDigest.
+func (ns *NotificationSender) digestContent(ctx context.Context, digest models.DelayedNotification) (notificationContent, error) {
+	members, err := ns.digests.DigestMembers(ctx, digest.ID)
 	if err != nil {
-		select {
-		case errCh <- err:
-		case <-ctx.Done():
+		return notificationContent{}, fmt.Errorf("failed to load digest: %w", err)
+	}
+	if len(members) == 0 {
+		return notificationContent{}, ErrEmptyDigest
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s (%d):", digestSubject, len(members))
+	for _, member := range members {
+		// в сводку попадает текст уведомления без разметки каналов
+		member.Channels, member.Digest = models.Channels{}, nil
+		content, err := ns.prepareContent(ctx, member)
+		if err != nil {
+			return notificationContent{}, err
 		}
+		b.WriteString("\n\n• " + content.webhook)
 	}
+
+	text := b.String()
+	return notificationContent{
+		emailSubject: digestSubject,
+		emailText:    text,
+		telegram:     text,
+		webhook:      text,
+	}, nil
 }
 
-// determineStatus определяет статус уведомления на основе наличия ошибок.
-func (ns *NotificationSender) determineStatus(errs []error) models.NotificationStatus {
-	if len(errs) > 0 {
-		return models.StatusFailed
+// withoutMutedChat убирает канал телеграм, если в чате уведомления выключены командой /mute,
+// и сообщает, был ли он убран.
+func (ns *NotificationSender) withoutMutedChat(ctx context.Context, channels models.Channels) (models.Channels, bool) {
+	chatID := channels.TelegramChannel.ChatID
+	if chatID == "" {
+		return channels, false
+	}
+
+	// при ошибке хранилища лучше отправить уведомление
+	if muted, err := ns.mutes.IsChatMuted(ctx, chatID); err == nil && muted {
+		channels.TelegramChannel = models.TelegramChannel{}
+		return channels, true
+	}
+	return channels, false
+}
+
+// sendNotifications отправляет уведомления по email, Telegram и вебхуку (если указаны):
+// по всем каналам сразу или по очереди, если так требует режим доставки.
+// Возвращает каналы, по которым отправка не удалась, доставленные каналы и ошибки.
+func (ns *NotificationSender) sendNotifications(ctx context.Context, notification models.DelayedNotification, content notificationContent) (models.Channels, []models.ChannelName, []error) {
+	if d := notification.Delivery; d != nil && d.Mode != models.DeliveryAll {
+		return ns.sendInOrder(ctx, notification, content)
+	}
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		failed    models.Channels
+		delivered []models.ChannelName
+		errs      []error
+	)
+
+	for _, name := range configuredChannels(notification.Channels) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := ns.sendChannel(ctx, notification, name, content)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				copyChannel(&failed, notification.Channels, name)
+				errs = append(errs, &channelError{channel: name, err: err})
+				return
+			}
+			delivered = append(delivered, name)
+		}()
+	}
+
+	wg.Wait()
+	slices.SortFunc(delivered, func(a, b models.ChannelName) int {
+		return slices.Index(channelNames, a) - slices.Index(channelNames, b)
+	})
+	return failed, delivered, errs
+}
+
+// sendInOrder отправляет уведомление по каналам в порядке режима доставки,
+// пока не будет доставлено нужное число каналов с учетом предыдущих попыток.
+// Если нужное число доставлено, ошибки отброшенных каналов не возвращаются.
+func (ns *NotificationSender) sendInOrder(ctx context.Context, notification models.DelayedNotification, content notificationContent) (models.Channels, []models.ChannelName, []error) {
+	var (
+		need      = max(notification.Delivery.N, 1) - len(notification.Delivered)
+		failed    models.Channels
+		delivered []models.ChannelName
+		errs      []error
+	)
+
+	for _, name := range notification.Delivery.Order {
+		if !hasChannel(notification.Channels, name) {
+			continue // доставлен в предыдущей попытке
+		}
+		if len(delivered) >= need {
+			break
+		}
+		if ctx.Err() != nil {
+			// оставшиеся каналы вернутся в очередь вместе с прерванным
+			copyChannel(&failed, notification.Channels, name)
+			continue
+		}
+
+		if err := ns.sendChannel(ctx, notification, name, content); err != nil {
+			copyChannel(&failed, notification.Channels, name)
+			errs = append(errs, &channelError{channel: name, err: err})
+			continue
+		}
+		delivered = append(delivered, name)
+	}
+
+	if len(delivered) >= need {
+		return models.Channels{}, delivered, nil
+	}
+	if len(errs) == 0 && ctx.Err() != nil {
+		errs = append(errs, ctx.Err())
+	}
+	return failed, delivered, errs
+}
+
+// sendChannel отправляет уведомление по одному каналу, если автомат отключения канала замкнут
+// и не превышен лимит отправки получателю. Лимит расходуется, только когда отправка действительно выполняется,
+// чтобы отложенные разомкнутым автоматом уведомления не исчерпали его. Результат отправки учитывается автоматом.
+func (ns *NotificationSender) sendChannel(ctx context.Context, notification models.DelayedNotification, name models.ChannelName, content notificationContent) error {
+	if ns.breakers != nil {
+		if err := ns.breakers.Allow(name); err != nil {
+			return err
+		}
+	}
+
+	err := ns.takeLimit(ctx, notification, name)
+	if err == nil {
+		err = ns.sendVia(ctx, notification, name, content)
+	}
+
+	if ns.breakers != nil {
+		ns.breakers.Record(name, err)
+	}
+	return err
+}
+
+// takeLimit расходует лимит отправки по каналу получателю, если лимиты заданы.
+func (ns *NotificationSender) takeLimit(ctx context.Context, notification models.DelayedNotification, name models.ChannelName) error {
+	if ns.limits == nil {
+		return nil
+	}
+	return ns.limits.Take(ctx, name, channelAddress(notification.Channels, name))
+}
+
+// sendVia отправляет уведомление отправщиком канала.
+// Уведомления с выключенным каналом могли быть созданы до изменения конфига или через политику эскалации.
+func (ns *NotificationSender) sendVia(ctx context.Context, notification models.DelayedNotification, name models.ChannelName, content notificationContent) error {
+	switch name {
+	case models.ChannelEmail:
+		if ns.emailSender == nil {
+			return ErrChannelDisabled
+		}
+		return ns.sendEmail(ctx, notification, notification.Channels.EmailChannel, content)
+	case models.ChannelTelegram:
+		if ns.tgSender == nil {
+			return ErrChannelDisabled
+		}
+		return ns.sendTelegram(ctx, notification, notification.Channels.TelegramChannel, content)
+	case models.ChannelWebhook:
+		if ns.whSender == nil {
+			return ErrChannelDisabled
+		}
+		return ns.sendWebhook(ctx, notification, notification.Channels.WebhookChannel, content)
+	default:
+		return fmt.Errorf("unknown channel %q", name)
+	}
+}
+
+// sendTelegram отправляет сообщение телеграм, добавляя кнопку подтверждения, если оно требуется.
+func (ns *NotificationSender) sendTelegram(ctx context.Context, notification models.DelayedNotification, tg models.TelegramChannel, content notificationContent) error {
+	buttons := slices.Clone(tg.Buttons)
+	if len(notification.SnoozeOptions) > 0 {
+		row := make([]models.TelegramButton, len(notification.SnoozeOptions))
+		for i, d := range notification.SnoozeOptions {
+			row[i] = models.TelegramButton{Text: snoozeLabel(d), CallbackData: ns.snoozes.SnoozeCallbackData(notification.ID, d)}
+		}
+		buttons = append(buttons, row)
+	}
+	if notification.Ack != nil {
+		buttons = append(buttons, []models.TelegramButton{{
+			Text:         ackButtonText,
+			CallbackData: ns.acks.AckCallbackData(notification.ID),
+		}})
+	}
+
+	return ns.tgSender.Send(ctx, models.TelegramMessage{
+		ChatID:          tg.ChatID,
+		Text:            content.telegram,
+		ParseMode:       content.telegramParseMode,
+		Buttons:         buttons,
+		Silent:          tg.Silent,
+		PhotoURL:        tg.PhotoURL,
+		DocumentURL:     tg.DocumentURL,
+		MessageThreadID: tg.MessageThreadID,
+	})
+}
+
+// sendWebhook отправляет уведомление на вебхук со ссылкой подтверждения, если оно требуется.
+func (ns *NotificationSender) sendWebhook(ctx context.Context, notification models.DelayedNotification, wh models.WebhookChannel, content notificationContent) error {
+	message := models.WebhookMessage{URL: wh.URL, NotificationID: notification.ID, Text: content.webhook}
+	if notification.Ack != nil {
+		message.AckURL = ns.acks.AckLink(notification.ID, webhookRecipient(wh.URL))
+	}
+
+	return ns.whSender.Send(ctx, message)
+}
+
+// sendEmail подгружает вложения и отправляет письмо.
+// В письмо получателю из реестра добавляются ссылки на страницу настроек и отписку.
+func (ns *NotificationSender) sendEmail(ctx context.Context, notification models.DelayedNotification, email models.EmailChannel, content notificationContent) error {
+	attachments, err := ns.attachments.Load(ctx, email.Attachments)
+	if err != nil {
+		return &models.RecipientError{Err: fmt.Errorf("%w: %w", errLoadAttachments, err)}
+	}
+
+	text, htmlBody := content.emailText, content.emailHTML
+	for _, d := range notification.SnoozeOptions {
+		text, htmlBody = withLink(text, htmlBody, snoozeLabel(d), ns.snoozes.SnoozeLink(notification.ID, d))
+	}
+	if notification.Ack != nil {
+		text, htmlBody = withLink(text, htmlBody, ackButtonText, ns.acks.AckLink(notification.ID, email.Email))
+	}
+
+	var unsubscribe string
+	if notification.RecipientID != "" {
+		text, htmlBody = withLink(text, htmlBody, preferencesLinkText, ns.preferences.PreferencesLink(notification.RecipientID))
+		unsubscribe = ns.preferences.UnsubscribeLink(notification.RecipientID, notification.Category)
+	}
+
+	return ns.emailSender.Send(ctx, models.EmailMessage{
+		To:          email.Email,
+		Subject:     content.emailSubject,
+		Text:        text,
+		HTML:        htmlBody,
+		Attachments: attachments,
+		Event:       notification.Event,
+		Unsubscribe: unsubscribe,
+	})
+}
+
+// webhookRecipient называет вебхук в подтверждении получения только по хосту:
+// адреса вебхуков часто содержат секретный токен.
+func webhookRecipient(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "webhook"
+	}
+	return "webhook:" + u.Host
+}
+
+// withLink добавляет в текст и HTML версию письма ссылку с подписью label.
+func withLink(text, htmlBody, label, link string) (string, string) {
+	text += "\n\n" + label + ": " + link
+	if htmlBody == "" {
+		return text, htmlBody
+	}
+
+	anchor := `<p><a href="` + html.EscapeString(link) + `">` + label + `</a></p>`
+	if i := strings.LastIndex(strings.ToLower(htmlBody), "</body>"); i >= 0 {
+		return text, htmlBody[:i] + anchor + htmlBody[i:]
+	}
+	return text, htmlBody + anchor
+}
+
+// snoozeLabel возвращает подпись кнопки и ссылки, откладывающей уведомление на d.
+func snoozeLabel(d time.Duration) string {
+	switch {
+	case d%(24*time.Hour) == 0:
+		return fmt.Sprintf("Отложить на %d дн.", d/(24*time.Hour))
+	case d%time.Hour == 0:
+		return fmt.Sprintf("Отложить на %d ч", d/time.Hour)
+	default:
+		return fmt.Sprintf("Отложить на %d мин", d/time.Minute)
+	}
+}
+
+// scheduleFollowUp планирует следующий шаг эскалации, а после последнего шага -
+// повторное напоминание, если все каналы доставлены. Возвращает false, если ничего не запланировано.
+func (ns *NotificationSender) scheduleFollowUp(ctx context.Context, notification models.DelayedNotification, delivered bool) (bool, error) {
+	if esc := notification.Escalation; esc != nil && esc.Step+1 < len(esc.Steps) {
+		return ns.escalate(ctx, notification)
+	}
+	if delivered && notification.Ack != nil {
+		return ns.scheduleRepeat(ctx, notification)
+	}
+	return false, nil
+}
+
+// escalate планирует следующий шаг эскалации, если получение еще не подтверждено.
+// Шаг отправляется в свое время относительно первого шага, даже если предыдущий доставлен с опозданием.
+func (ns *NotificationSender) escalate(ctx context.Context, notification models.DelayedNotification) (bool, error) {
+	acked, err := ns.acks.IsAcknowledged(ctx, notification.ID)
+	if err != nil {
+		return false, fmt.Errorf("failed to check acknowledgement: %w", err)
+	}
+	if acked {
+		return false, nil
+	}
+
+	esc := *notification.Escalation
+	esc.Step++
+
+	next := notification
+	next.Escalation = &esc
+	next.Channels = esc.Steps[esc.Step].Channels
+	next.Attempt = 0
+	next.Delivered = nil
+	if next.Ack != nil {
+		ack := *next.Ack
+		ack.Repeat = 0
+		ack.Channels = next.Channels
+		next.Ack = &ack
+	}
+
+	if err := ns.rescheduler.RescheduleNotification(ctx, next, escalationStepAt(&esc, esc.Step)); err != nil {
+		return false, fmt.Errorf("failed to schedule escalation step: %w", err)
+	}
+
+	return true, nil
+}
+
+// scheduleRepeat планирует повторное напоминание по всем каналам уведомления,
+// если получатель его еще не подтвердил. Возвращает false, если повторы исчерпаны.
+func (ns *NotificationSender) scheduleRepeat(ctx context.Context, notification models.DelayedNotification) (bool, error) {
+	ack := *notification.Ack
+	if ack.Repeat >= ack.MaxRepeats {
+		return false, nil
+	}
+
+	acked, err := ns.acks.IsAcknowledged(ctx, notification.ID)
+	if err != nil {
+		return false, fmt.Errorf("failed to check acknowledgement: %w", err)
+	}
+	if acked {
+		return false, nil
+	}
+
+	ack.Repeat++
+	repeat := notification
+	repeat.Channels = ack.Channels
+	repeat.Attempt = 0
+	repeat.Delivered = nil
+	repeat.Ack = &ack
+
+	if err := ns.rescheduler.RescheduleNotification(ctx, repeat, time.Now().Add(ack.RepeatInterval)); err != nil {
+		return false, fmt.Errorf("failed to schedule repeat: %w", err)
+	}
+
+	return true, nil
+}
+
+// scheduleRetry кладет уведомление с неудавшимися каналами обратно в отложенную очередь не раньше, чем через wait.
+// Если все каналы только превысили лимит отправки получателю или провайдера (limitedOnly), попытка не расходуется.
+// Возвращает false, если попытки исчерпаны или раньше наступит следующий шаг эскалации.
+func (ns *NotificationSender) scheduleRetry(ctx context.Context, notification models.DelayedNotification, failed models.Channels, wait time.Duration, limitedOnly bool) (bool, error) {
+	retry := notification
+	retry.Channels = failed
+
+	sendAt := time.Now().Add(wait)
+	if !limitedOnly {
+		if notification.Attempt+1 >= ns.sendRetryAttemps {
+			return false, nil
+		}
+		retry.Attempt++
+		sendAt = time.Now().Add(max(ns.retryDelay(notification.Attempt), wait))
+	}
+	if esc := notification.Escalation; esc != nil && esc.Step+1 < len(esc.Steps) && !sendAt.Before(escalationStepAt(esc, esc.Step+1)) {
+		return false, nil // следующий шаг эскалации наступит раньше повторной попытки
+	}
+
+	if err := ns.rescheduler.RescheduleNotification(ctx, retry, sendAt); err != nil {
+		return false, fmt.Errorf("failed to reschedule notification: %w", err)
+	}
+
+	return true, nil
+}
+
+// retryDelay вычисляет экспоненциальную задержку перед следующей попыткой.
+func (ns *NotificationSender) retryDelay(attempt int) time.Duration {
+	return time.Duration(float64(ns.sendRetryDelay) * math.Pow(ns.sendRetryBackoff, float64(attempt)))
+}
+
+// determineStatus определяет статус неудавшейся отправки.
+func (ns *NotificationSender) determineStatus(rescheduled bool) models.NotificationStatus {
+	if rescheduled {
+		return models.StatusRetrying
+	}
+	return models.StatusFailed
+}
+
+// saveDelivered сохраняет каналы, по которым уведомление доставлено.
+func (ns *NotificationSender) saveDelivered(ctx context.Context, notificationID string, delivered []models.ChannelName) error {
+	if len(delivered) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(delivered)
+	if err != nil {
+		return err
+	}
+
+	return ns.storageAdder.Add(ctx, "notification.delivery:"+notificationID, payload, 168*time.Hour)
+}
+
+// saveChannels сохраняет по ключу каналы, не отправленные по решению получателя или лимита.
+func (ns *NotificationSender) saveChannels(ctx context.Context, key string, channels []models.ChannelName) error {
+	if len(channels) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(channels)
+	if err != nil {
+		return err
+	}
+
+	return ns.storageAdder.Add(ctx, key, payload, 168*time.Hour)
+}
+
+// saveStatus сохраняет статус уведомления в хранилище с пояснением note.
+func (ns *NotificationSender) saveStatus(ctx context.Context, notificationID string, status models.NotificationStatus, note string) error {
+	return setStatus(ctx, ns.storageAdder, notificationID, status, note, 168*time.Hour)
+}
+
+// rejectRateLimited убирает из неудавшихся каналы, превысившие лимит с политикой reject:
+// они не отправляются повторно. Возвращает такие каналы и остальные ошибки.
+func rejectRateLimited(failed *models.Channels, errs []error) ([]models.ChannelName, []error) {
+	var (
+		rejected []models.ChannelName
+		rest     []error
+	)
+	for _, err := range errs {
+		var limitErr *RateLimitError
+		if errors.As(err, &limitErr) && limitErr.Action == models.RateLimitReject {
+			copyChannel(failed, models.Channels{}, limitErr.Channel)
+			rejected = append(rejected, limitErr.Channel)
+			continue
+		}
+		rest = append(rest, err)
 	}
-	return models.StatusSent
+	return rejected, rest
 }
 
-// saveStatus сохраняет статус уведомления в хранилище.
-func (ns *NotificationSender) saveStatus(ctx context.Context, notificationID string, status models.NotificationStatus) error {
-	return ns.storageAdder.Add(ctx, "notification.status:"+notificationID, string(status), 168*time.Hour)
+// dropDisabled убирает из неудавшихся каналы без отправщика и возвращает их ошибки отдельно от остальных:
+// повторная попытка по выключенному каналу не доставит уведомление.
+func (ns *NotificationSender) dropDisabled(failed *models.Channels, errs []error) ([]error, []error) {
+	var disabled, rest []error
+	for _, err := range errs {
+		if errors.Is(err, ErrChannelDisabled) {
+			disabled = append(disabled, err)
+			continue
+		}
+		rest = append(rest, err)
+	}
+
+	for _, name := range configuredChannels(*failed) {
+		if !ns.channelEnabled(name) {
+			copyChannel(failed, models.Channels{}, name)
+		}
+	}
+	return disabled, rest
+}
+
+// dropPermanent убирает из неудавшихся каналы, отправка по которым не удастся и при повторной попытке,
+// и возвращает их ошибки отдельно от остальных: получатель отверг сообщение или шаблон не заполняется.
+// Ошибка шаблона относится ко всем каналам.
+func dropPermanent(failed *models.Channels, errs []error) ([]error, []error) {
+	var permanent, rest []error
+	for _, err := range errs {
+		if !isPermanent(err) {
+			rest = append(rest, err)
+			continue
+		}
+
+		permanent = append(permanent, err)
+		var chErr *channelError
+		if errors.As(err, &chErr) {
+			copyChannel(failed, models.Channels{}, chErr.channel)
+		} else {
+			*failed = models.Channels{}
+		}
+	}
+	return permanent, rest
+}
+
+// isPermanent сообщает, что ошибка отправки не исправится повторной попыткой.
+// Вложение по ссылке может скачаться при следующей попытке, поэтому его ошибка не окончательная.
+func isPermanent(err error) bool {
+	var recipientErr *models.RecipientError
+	if errors.As(err, &recipientErr) {
+		return !errors.Is(err, errLoadAttachments)
+	}
+	return errors.Is(err, ErrTemplateNotFound) || errors.Is(err, ErrInvalidTemplate)
+}
+
+// channelEnabled сообщает, есть ли отправщик канала.
+func (ns *NotificationSender) channelEnabled(name models.ChannelName) bool {
+	switch name {
+	case models.ChannelEmail:
+		return ns.emailSender != nil
+	case models.ChannelTelegram:
+		return ns.tgSender != nil
+	case models.ChannelWebhook:
+		return ns.whSender != nil
+	default:
+		return false
+	}
+}
+
+// retryAfter возвращает наибольшее время до пополнения лимитов получателя и провайдера среди ошибок
+// и сообщает, все ли ошибки - превышение лимита.
+func retryAfter(errs []error) (time.Duration, bool) {
+	var wait time.Duration
+	limitedOnly := true
+	for _, err := range errs {
+		var (
+			limitErr    *RateLimitError
+			providerErr *models.RetryAfterError
+		)
+		switch {
+		case errors.As(err, &limitErr):
+			wait = max(wait, limitErr.RetryAfter)
+		case errors.As(err, &providerErr):
+			wait = max(wait, providerErr.After)
+		default:
+			limitedOnly = false
+		}
+	}
+	return wait, limitedOnly
+}
+
+// channelNames названия всех каналов в порядке отправки в режиме all.
+var channelNames = []models.ChannelName{models.ChannelEmail, models.ChannelTelegram, models.ChannelWebhook}
+
+// configuredChannels возвращает названия указанных каналов.
+func configuredChannels(channels models.Channels) []models.ChannelName {
+	var names []models.ChannelName
+	for _, name := range channelNames {
+		if hasChannel(channels, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// hasChannel сообщает, указан ли канал.
+func hasChannel(channels models.Channels, name models.ChannelName) bool {
+	switch name {
+	case models.ChannelEmail:
+		return channels.EmailChannel.Email != ""
+	case models.ChannelTelegram:
+		return channels.TelegramChannel.ChatID != ""
+	case models.ChannelWebhook:
+		return channels.WebhookChannel.URL != ""
+	default:
+		return false
+	}
+}
+
+// channelAddress возвращает адрес получателя в канале, по которому считается лимит отправки.
+func channelAddress(channels models.Channels, name models.ChannelName) string {
+	switch name {
+	case models.ChannelEmail:
+		return channels.EmailChannel.Email
+	case models.ChannelTelegram:
+		return channels.TelegramChannel.ChatID
+	case models.ChannelWebhook:
+		return webhookRecipient(channels.WebhookChannel.URL)
+	default:
+		return ""
+	}
+}
+
+// copyChannel копирует настройки канала из src в dst.
+func copyChannel(dst *models.Channels, src models.Channels, name models.ChannelName) {
+	switch name {
+	case models.ChannelEmail:
+		dst.EmailChannel = src.EmailChannel
+	case models.ChannelTelegram:
+		dst.TelegramChannel = src.TelegramChannel
+	case models.ChannelWebhook:
+		dst.WebhookChannel = src.WebhookChannel
+	}
 }
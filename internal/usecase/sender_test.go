@@ -2,7 +2,9 @@ package usecase
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
@@ -22,79 +24,114 @@ func TestNotificationSender_Send(t *testing.T) {
 	)
 
 	tests := []struct {
-		name           string
-		emailAddr      string
-		chatID         string
-		emailSendErr   error
-		tgSendErr      error
-		statusSaveErr  error
-		expectStatus   models.NotificationStatus
-		expectErr      bool
-		emailCallCount int
-		tgCallCount    int
+		name            string
+		emailAddr       string
+		chatID          string
+		attempt         int
+		emailSendErr    error
+		tgSendErr       error
+		statusSaveErr   error
+		rescheduleErr   error
+		expectRetry     *models.Channels
+		expectStatus    models.NotificationStatus
+		expectDelivered string
+		expectErr       bool
+		emailCallCount  int
+		tgCallCount     int
 	}{
 		{
-			name:           "both channels succeed",
-			emailAddr:      "user@example.com",
-			chatID:         "123456",
-			expectStatus:   models.StatusSent,
-			emailCallCount: 1,
-			tgCallCount:    1,
+			name:            "both channels succeed",
+			emailAddr:       "user@example.com",
+			chatID:          "123456",
+			expectStatus:    models.StatusSent,
+			expectDelivered: `["email","telegram"]`,
+			emailCallCount:  1,
+			tgCallCount:     1,
 		},
 		{
-			name:           "email fails, telegram succeeds",
-			emailAddr:      "user@example.com",
-			chatID:         "123456",
-			emailSendErr:   errors.New("smtp timeout"),
-			expectStatus:   models.StatusFailed,
+			name:            "email fails, telegram succeeds",
+			emailAddr:       "user@example.com",
+			chatID:          "123456",
+			emailSendErr:    errors.New("smtp timeout"),
+			expectRetry:     &models.Channels{EmailChannel: models.EmailChannel{Email: "user@example.com"}},
+			expectStatus:    models.StatusRetrying,
+			expectDelivered: `["telegram"]`,
+			expectErr:       true,
+			emailCallCount:  1,
+			tgCallCount:     1,
+		},
+		{
+			name:            "telegram fails, email succeeds",
+			emailAddr:       "user@example.com",
+			chatID:          "123456",
+			tgSendErr:       errors.New("tg api down"),
+			expectRetry:     &models.Channels{TelegramChannel: models.TelegramChannel{ChatID: "123456"}},
+			expectStatus:    models.StatusRetrying,
+			expectDelivered: `["email"]`,
+			expectErr:       true,
+			emailCallCount:  1,
+			tgCallCount:     1,
+		},
+		{
+			name:         "both fail",
+			emailAddr:    "user@example.com",
+			chatID:       "123456",
+			emailSendErr: errors.New("email error"),
+			tgSendErr:    errors.New("tg error"),
+			expectRetry: &models.Channels{
+				EmailChannel:    models.EmailChannel{Email: "user@example.com"},
+				TelegramChannel: models.TelegramChannel{ChatID: "123456"},
+			},
+			expectStatus:   models.StatusRetrying,
 			expectErr:      true,
-			emailCallCount: 3, // retry 3 times
+			emailCallCount: 1,
 			tgCallCount:    1,
 		},
 		{
-			name:           "telegram fails, email succeeds",
+			name:           "last attempt fails",
 			emailAddr:      "user@example.com",
-			chatID:         "123456",
-			tgSendErr:      errors.New("tg api down"),
+			attempt:        2,
+			emailSendErr:   errors.New("smtp timeout"),
 			expectStatus:   models.StatusFailed,
 			expectErr:      true,
 			emailCallCount: 1,
-			tgCallCount:    3,
 		},
 		{
-			name:           "both fail",
+			name:           "reschedule fails",
 			emailAddr:      "user@example.com",
-			chatID:         "123456",
-			emailSendErr:   errors.New("email error"),
-			tgSendErr:      errors.New("tg error"),
+			emailSendErr:   errors.New("smtp timeout"),
+			rescheduleErr:  errors.New("redis down"),
+			expectRetry:    &models.Channels{EmailChannel: models.EmailChannel{Email: "user@example.com"}},
 			expectStatus:   models.StatusFailed,
 			expectErr:      true,
-			emailCallCount: 3,
-			tgCallCount:    3,
+			emailCallCount: 1,
 		},
 		{
-			name:           "email only, success",
-			emailAddr:      "user@example.com",
-			expectStatus:   models.StatusSent,
-			emailCallCount: 1,
+			name:            "email only, success",
+			emailAddr:       "user@example.com",
+			expectStatus:    models.StatusSent,
+			expectDelivered: `["email"]`,
+			emailCallCount:  1,
 		},
 		{
-			name:         "telegram only, success",
-			chatID:       "123456",
-			expectStatus: models.StatusSent,
-			tgCallCount:  1,
+			name:            "telegram only, success",
+			chatID:          "123456",
+			expectStatus:    models.StatusSent,
+			expectDelivered: `["telegram"]`,
+			tgCallCount:     1,
 		},
 		{
 			name:         "no channels",
 			expectStatus: models.StatusSent,
 		},
 		{
-			name:           "status save fails",
-			emailAddr:      "user@example.com",
-			statusSaveErr:  errors.New("redis down"),
-			expectStatus:   models.StatusSent,
-			expectErr:      true,
-			emailCallCount: 1,
+			name:            "status save fails",
+			emailAddr:       "user@example.com",
+			statusSaveErr:   errors.New("redis down"),
+			expectStatus:    models.StatusSent,
+			expectDelivered: `["email"]`,
+			expectErr:       true,
+			emailCallCount:  1,
 		},
 	}
 
@@ -108,34 +145,55 @@ func TestNotificationSender_Send(t *testing.T) {
 
 			mockEmail := mock_usecase.NewMockemailSender(ctrl)
 			mockTg := mock_usecase.NewMocktelegramSender(ctrl)
-			mockStorage := mock_usecase.NewMockstorageAdder(ctrl)
+			mockStorage := allowHistory(mock_usecase.NewMockstorageAdder(ctrl))
+			mockRescheduler := mock_usecase.NewMocknotificationRescheduler(ctrl)
 
 			if tt.emailAddr != "" {
 				mockEmail.EXPECT().
-					Send(tt.emailAddr, testMessage).
+					Send(gomock.Any(), models.EmailMessage{To: tt.emailAddr, Text: testMessage}).
 					Return(tt.emailSendErr).
 					Times(tt.emailCallCount)
 			}
 
 			if tt.chatID != "" {
 				mockTg.EXPECT().
-					Send(tt.chatID, testMessage).
+					Send(gomock.Any(), models.TelegramMessage{ChatID: tt.chatID, Text: testMessage}).
 					Return(tt.tgSendErr).
 					Times(tt.tgCallCount)
 			}
 
+			if tt.expectRetry != nil {
+				mockRescheduler.EXPECT().
+					RescheduleNotification(gomock.Any(), models.DelayedNotification{
+						ID:           testID,
+						Notification: models.Notification(testMessage),
+						Channels:     *tt.expectRetry,
+						Attempt:      tt.attempt + 1,
+						Delivered:    deliveredOf(tt.expectDelivered),
+					}, gomock.Any()).
+					Return(tt.rescheduleErr)
+			}
+
 			mockStorage.EXPECT().
 				Add(gomock.Any(), "notification.status:"+testID, string(tt.expectStatus), 168*time.Hour).
 				Return(tt.statusSaveErr)
+			if tt.expectDelivered != "" {
+				mockStorage.EXPECT().
+					Add(gomock.Any(), "notification.delivery:"+testID, []byte(tt.expectDelivered), 168*time.Hour).
+					Return(nil)
+			}
 
-			sender := NewNotificationSender(
-				mockEmail,
-				mockTg,
-				mockStorage,
-				3, // retry attempts
-				10*time.Millisecond,
-				1.0, // no backoff for test speed
-			)
+			sender := NewNotificationSender(NotificationSenderConfig{
+				EmailSender:    mockEmail,
+				TelegramSender: mockTg,
+				Storage:        mockStorage,
+				Rescheduler:    mockRescheduler,
+				Attachments:    NewAttachmentLoader(nil, nil, 0),
+				Mutes:          unmuted(ctrl),
+				RetryAttempts:  3,
+				RetryDelay:     10 * time.Millisecond,
+				RetryBackoff:   1.0, // no backoff for test speed
+			})
 
 			notification := models.DelayedNotification{
 				ID:           testID,
@@ -144,6 +202,7 @@ func TestNotificationSender_Send(t *testing.T) {
 					EmailChannel:    models.EmailChannel{Email: tt.emailAddr},
 					TelegramChannel: models.TelegramChannel{ChatID: tt.chatID},
 				},
+				Attempt: tt.attempt,
 			}
 
 			err := sender.Send(context.Background(), notification)
@@ -165,14 +224,8 @@ func TestNotificationSender_Send_StatusSavedEvenWithContextCancel(t *testing.T)
 
 	mockEmail := mock_usecase.NewMockemailSender(ctrl)
 	mockTg := mock_usecase.NewMocktelegramSender(ctrl)
-	mockStorage := mock_usecase.NewMockstorageAdder(ctrl)
-
-	mockEmail.EXPECT().Send("user@example.com", "msg").Return(nil)
-	mockStorage.EXPECT().
-		Add(gomock.Any(), "notification.status:test", string(models.StatusSent), 168*time.Hour).
-		Return(nil)
-
-	sender := NewNotificationSender(mockEmail, mockTg, mockStorage, 1, 0, 1.0)
+	mockStorage := allowHistory(mock_usecase.NewMockstorageAdder(ctrl))
+	mockRescheduler := mock_usecase.NewMocknotificationRescheduler(ctrl)
 
 	notification := models.DelayedNotification{
 		ID:           "test",
@@ -180,16 +233,42 @@ func TestNotificationSender_Send_StatusSavedEvenWithContextCancel(t *testing.T)
 		Channels: models.Channels{
 			EmailChannel: models.EmailChannel{Email: "user@example.com"},
 		},
+		Attempt: 1,
 	}
 
+	// отправка не начинается, уведомление целиком возвращается в очередь без расхода попытки
+	mockRescheduler.EXPECT().
+		RescheduleNotification(gomock.Any(), notification, gomock.Any()).
+		DoAndReturn(func(ctx context.Context, _ models.DelayedNotification, _ time.Time) error {
+			assert.NoError(t, ctx.Err())
+			return nil
+		})
+	mockStorage.EXPECT().
+		Add(gomock.Any(), "notification.status:test", string(models.StatusInterrupted), 168*time.Hour).
+		DoAndReturn(func(ctx context.Context, _ string, _ interface{}, _ time.Duration) error {
+			assert.NoError(t, ctx.Err())
+			return nil
+		})
+
+	sender := NewNotificationSender(NotificationSenderConfig{
+		EmailSender:    mockEmail,
+		TelegramSender: mockTg,
+		Storage:        mockStorage,
+		Rescheduler:    mockRescheduler,
+		Attachments:    NewAttachmentLoader(nil, nil, 0),
+		Mutes:          unmuted(ctrl),
+		RetryAttempts:  1,
+		RetryBackoff:   1.0,
+	})
+
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel()
 
 	err := sender.Send(ctx, notification)
-	require.NoError(t, err)
+	require.ErrorIs(t, err, ErrSendInterrupted)
 }
 
-func TestNotificationSender_Send_RetryBehavior(t *testing.T) {
+func TestNotificationSender_Send_InterruptedWhileSending(t *testing.T) {
 	t.Parallel()
 
 	ctrl := gomock.NewController(t)
@@ -197,27 +276,99 @@ func TestNotificationSender_Send_RetryBehavior(t *testing.T) {
 
 	mockEmail := mock_usecase.NewMockemailSender(ctrl)
 	mockTg := mock_usecase.NewMocktelegramSender(ctrl)
-	mockStorage := mock_usecase.NewMockstorageAdder(ctrl)
+	mockStorage := allowHistory(mock_usecase.NewMockstorageAdder(ctrl))
+	mockRescheduler := mock_usecase.NewMocknotificationRescheduler(ctrl)
 
-	// Fail twice, succeed on third attempt
-	gomock.InOrder(
-		mockEmail.EXPECT().Send("test@example.com", "retry me").Return(errors.New("temp fail")),
-		mockEmail.EXPECT().Send("test@example.com", "retry me").Return(errors.New("temp fail")),
-		mockEmail.EXPECT().Send("test@example.com", "retry me").Return(nil),
-	)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
+	mockTg.EXPECT().Send(gomock.Any(), models.TelegramMessage{ChatID: "123456", Text: "msg"}).Return(nil)
+	mockEmail.EXPECT().Send(gomock.Any(), models.EmailMessage{To: "user@example.com", Text: "msg"}).
+		DoAndReturn(func(ctx context.Context, _ models.EmailMessage) error {
+			cancel()
+			return ctx.Err()
+		})
+
+	// вернуться в очередь должен только прерванный канал
+	mockRescheduler.EXPECT().
+		RescheduleNotification(gomock.Any(), models.DelayedNotification{
+			ID:           "test",
+			Notification: "msg",
+			Channels: models.Channels{
+				EmailChannel: models.EmailChannel{Email: "user@example.com"},
+			},
+			Delivered: []models.ChannelName{models.ChannelTelegram},
+		}, gomock.Any()).
+		Return(nil)
 	mockStorage.EXPECT().
-		Add(gomock.Any(), "notification.status:test", string(models.StatusSent), 168*time.Hour).
+		Add(gomock.Any(), "notification.status:test", string(models.StatusInterrupted), 168*time.Hour).
+		Return(nil)
+	mockStorage.EXPECT().
+		Add(gomock.Any(), "notification.delivery:test", []byte(`["telegram"]`), 168*time.Hour).
 		Return(nil)
 
-	sender := NewNotificationSender(
-		mockEmail,
-		mockTg,
-		mockStorage,
-		3,
-		1*time.Millisecond,
-		1.0,
-	)
+	sender := NewNotificationSender(NotificationSenderConfig{
+		EmailSender:    mockEmail,
+		TelegramSender: mockTg,
+		Storage:        mockStorage,
+		Rescheduler:    mockRescheduler,
+		Attachments:    NewAttachmentLoader(nil, nil, 0),
+		Mutes:          unmuted(ctrl),
+		RetryAttempts:  3,
+		RetryDelay:     time.Second,
+		RetryBackoff:   1.0,
+	})
+
+	notification := models.DelayedNotification{
+		ID:           "test",
+		Notification: "msg",
+		Channels: models.Channels{
+			EmailChannel:    models.EmailChannel{Email: "user@example.com"},
+			TelegramChannel: models.TelegramChannel{ChatID: "123456"},
+		},
+	}
+
+	err := sender.Send(ctx, notification)
+	require.ErrorIs(t, err, ErrSendInterrupted)
+}
+
+func TestNotificationSender_Send_RetryBackoff(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockEmail := mock_usecase.NewMockemailSender(ctrl)
+	mockTg := mock_usecase.NewMocktelegramSender(ctrl)
+	mockStorage := allowHistory(mock_usecase.NewMockstorageAdder(ctrl))
+	mockRescheduler := mock_usecase.NewMocknotificationRescheduler(ctrl)
+
+	mockEmail.EXPECT().Send(gomock.Any(), models.EmailMessage{To: "test@example.com", Text: "retry me"}).Return(errors.New("temp fail"))
+
+	var sendAt time.Time
+	mockRescheduler.EXPECT().
+		RescheduleNotification(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, n models.DelayedNotification, at time.Time) error {
+			assert.Equal(t, 3, n.Attempt)
+			sendAt = at
+			return nil
+		})
+
+	mockStorage.EXPECT().
+		Add(gomock.Any(), "notification.status:test", string(models.StatusRetrying), 168*time.Hour).
+		Return(nil)
+
+	sender := NewNotificationSender(NotificationSenderConfig{
+		EmailSender:    mockEmail,
+		TelegramSender: mockTg,
+		Storage:        mockStorage,
+		Rescheduler:    mockRescheduler,
+		Attachments:    NewAttachmentLoader(nil, nil, 0),
+		Mutes:          unmuted(ctrl),
+		RetryAttempts:  5,
+		RetryDelay:     time.Second,
+		RetryBackoff:   2.0,
+	})
 
 	notification := models.DelayedNotification{
 		ID:           "test",
@@ -225,10 +376,15 @@ func TestNotificationSender_Send_RetryBehavior(t *testing.T) {
 		Channels: models.Channels{
 			EmailChannel: models.EmailChannel{Email: "test@example.com"},
 		},
+		Attempt: 2,
 	}
 
+	start := time.Now()
 	err := sender.Send(context.Background(), notification)
-	require.NoError(t, err)
+	require.Error(t, err)
+
+	// третья попытка ждет delay * backoff^2
+	assert.WithinDuration(t, start.Add(4*time.Second), sendAt, time.Second)
 }
 
 func TestNotificationSender_Send_NoChannels(t *testing.T) {
@@ -237,17 +393,21 @@ func TestNotificationSender_Send_NoChannels(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	mockStorage := mock_usecase.NewMockstorageAdder(ctrl)
+	mockStorage := allowHistory(mock_usecase.NewMockstorageAdder(ctrl))
 	mockStorage.EXPECT().
 		Add(gomock.Any(), "notification.status:empty", string(models.StatusSent), 168*time.Hour).
 		Return(nil)
 
-	sender := NewNotificationSender(
-		mock_usecase.NewMockemailSender(ctrl),
-		mock_usecase.NewMocktelegramSender(ctrl),
-		mockStorage,
-		1, 0, 1.0,
-	)
+	sender := NewNotificationSender(NotificationSenderConfig{
+		EmailSender:    mock_usecase.NewMockemailSender(ctrl),
+		TelegramSender: mock_usecase.NewMocktelegramSender(ctrl),
+		Storage:        mockStorage,
+		Rescheduler:    mock_usecase.NewMocknotificationRescheduler(ctrl),
+		Attachments:    NewAttachmentLoader(nil, nil, 0),
+		Mutes:          unmuted(ctrl),
+		RetryAttempts:  1,
+		RetryBackoff:   1.0,
+	})
 
 	notification := models.DelayedNotification{
 		ID:           "empty",
@@ -258,3 +418,1328 @@ func TestNotificationSender_Send_NoChannels(t *testing.T) {
 	err := sender.Send(context.Background(), notification)
 	require.NoError(t, err)
 }
+
+func TestNotificationSender_Send_AttachmentLoadFails(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := allowHistory(mock_usecase.NewMockstorageAdder(ctrl))
+	mockRescheduler := mock_usecase.NewMocknotificationRescheduler(ctrl)
+	mockLoader := mock_usecase.NewMockattachmentLoader(ctrl)
+
+	attachments := []models.Attachment{{Filename: "invoice.pdf", URL: "https://example.com/invoice.pdf"}}
+	mockLoader.EXPECT().Load(gomock.Any(), attachments).Return(nil, errors.New("404 not found"))
+
+	// письмо без вложения не отправляется, канал уходит на повторную попытку
+	mockRescheduler.EXPECT().RescheduleNotification(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	mockStorage.EXPECT().
+		Add(gomock.Any(), "notification.status:test", string(models.StatusRetrying), 168*time.Hour).
+		Return(nil)
+
+	sender := NewNotificationSender(NotificationSenderConfig{
+		EmailSender:    mock_usecase.NewMockemailSender(ctrl),
+		TelegramSender: mock_usecase.NewMocktelegramSender(ctrl),
+		Storage:        mockStorage,
+		Rescheduler:    mockRescheduler,
+		Attachments:    mockLoader,
+		Mutes:          unmuted(ctrl),
+		RetryAttempts:  3,
+		RetryDelay:     time.Second,
+		RetryBackoff:   1.0,
+	})
+
+	notification := models.DelayedNotification{
+		ID:           "test",
+		Notification: "invoice",
+		Channels: models.Channels{
+			EmailChannel: models.EmailChannel{Email: "user@example.com", Attachments: attachments},
+		},
+	}
+
+	err := sender.Send(context.Background(), notification)
+	require.Error(t, err)
+}
+
+func TestNotificationSender_Send_PermanentFailure(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockEmail := mock_usecase.NewMockemailSender(ctrl)
+	mockTg := mock_usecase.NewMocktelegramSender(ctrl)
+	mockStorage := allowHistory(mock_usecase.NewMockstorageAdder(ctrl))
+	mockTemplates := mock_usecase.NewMocktemplateRenderer(ctrl)
+
+	// повторная попытка не планируется: ожиданий у планировщика нет
+	sender := NewNotificationSender(NotificationSenderConfig{
+		EmailSender:    mockEmail,
+		TelegramSender: mockTg,
+		Storage:        mockStorage,
+		Rescheduler:    mock_usecase.NewMocknotificationRescheduler(ctrl),
+		Attachments:    NewAttachmentLoader(nil, nil, 0),
+		Templates:      mockTemplates,
+		Mutes:          unmuted(ctrl),
+		RetryAttempts:  30,
+		RetryDelay:     time.Second,
+		RetryBackoff:   2.0,
+	})
+
+	channels := models.Channels{
+		EmailChannel:    models.EmailChannel{Email: "user@example.com"},
+		TelegramChannel: models.TelegramChannel{ChatID: "123456"},
+	}
+
+	t.Run("recipient_rejected", func(t *testing.T) {
+		mockEmail.EXPECT().Send(gomock.Any(), gomock.Any()).Return(nil)
+		mockTg.EXPECT().Send(gomock.Any(), gomock.Any()).Return(&models.RecipientError{Err: errors.New("Forbidden: bot was blocked by the user")})
+		mockStorage.EXPECT().Add(gomock.Any(), "notification.status:rejected", string(models.StatusFailed), 168*time.Hour).Return(nil)
+		mockStorage.EXPECT().Add(gomock.Any(), "notification.delivery:rejected", []byte(`["email"]`), 168*time.Hour).Return(nil)
+
+		err := sender.Send(context.Background(), models.DelayedNotification{ID: "rejected", Notification: "test", Channels: channels})
+		var recipientErr *models.RecipientError
+		assert.ErrorAs(t, err, &recipientErr)
+	})
+
+	t.Run("invalid_template", func(t *testing.T) {
+		ref := models.TemplateRef{ID: "tmpl-1", Version: 1}
+		mockTemplates.EXPECT().RenderTemplate(gomock.Any(), ref, "", models.TelegramParseMode("")).
+			Return(models.RenderedTemplate{}, fmt.Errorf("%w: missing variable order", ErrInvalidTemplate))
+		mockStorage.EXPECT().Add(gomock.Any(), "notification.status:template", string(models.StatusFailed), 168*time.Hour).Return(nil)
+
+		err := sender.Send(context.Background(), models.DelayedNotification{ID: "template", Channels: channels, Template: &ref})
+		assert.ErrorIs(t, err, ErrInvalidTemplate)
+	})
+}
+
+func TestNotificationSender_Send_Template(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockEmail := mock_usecase.NewMockemailSender(ctrl)
+	mockTg := mock_usecase.NewMocktelegramSender(ctrl)
+	mockStorage := allowHistory(mock_usecase.NewMockstorageAdder(ctrl))
+	mockTemplates := mock_usecase.NewMocktemplateRenderer(ctrl)
+
+	ref := models.TemplateRef{ID: "tmpl-1", Version: 2, Variables: map[string]any{"order": "A_1"}}
+	mockTemplates.EXPECT().RenderTemplate(gomock.Any(), ref, "kk", models.TelegramParseMode("")).Return(models.RenderedTemplate{
+		Version:      2,
+		Locale:       "ru",
+		EmailSubject: "Заказ A_1",
+		EmailText:    "Заказ A_1 готов",
+		Telegram:     `Заказ *A\_1* готов`,
+	}, nil)
+	mockEmail.EXPECT().Send(gomock.Any(), models.EmailMessage{
+		To:      "user@example.com",
+		Subject: "Заказ A_1",
+		Text:    "Заказ A_1 готов",
+	}).Return(nil)
+	mockTg.EXPECT().Send(gomock.Any(), models.TelegramMessage{
+		ChatID:    "123456",
+		Text:      `Заказ *A\_1* готов`,
+		ParseMode: models.TelegramMarkdown,
+	}).Return(nil)
+	mockStorage.EXPECT().
+		Add(gomock.Any(), "notification.status:test", string(models.StatusSent), 168*time.Hour).
+		Return(nil)
+	mockStorage.EXPECT().
+		Add(gomock.Any(), "notification.delivery:test", []byte(`["email","telegram"]`), 168*time.Hour).
+		Return(nil)
+
+	sender := NewNotificationSender(NotificationSenderConfig{
+		EmailSender:    mockEmail,
+		TelegramSender: mockTg,
+		Storage:        mockStorage,
+		Rescheduler:    mock_usecase.NewMocknotificationRescheduler(ctrl),
+		Attachments:    NewAttachmentLoader(nil, nil, 0),
+		Templates:      mockTemplates,
+		Mutes:          unmuted(ctrl),
+		RetryAttempts:  3,
+		RetryDelay:     time.Second,
+		RetryBackoff:   1.0,
+	})
+
+	notification := models.DelayedNotification{
+		ID: "test",
+		Channels: models.Channels{
+			EmailChannel:    models.EmailChannel{Email: "user@example.com"},
+			TelegramChannel: models.TelegramChannel{ChatID: "123456"},
+		},
+		Template: &ref,
+		Locale:   "kk",
+	}
+
+	require.NoError(t, sender.Send(context.Background(), notification))
+}
+
+func TestNotificationSender_Send_TelegramOptions(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTg := mock_usecase.NewMocktelegramSender(ctrl)
+	mockStorage := allowHistory(mock_usecase.NewMockstorageAdder(ctrl))
+
+	buttons := [][]models.TelegramButton{
+		{{Text: "Открыть", URL: "https://example.com/orders/1"}},
+		{{Text: "Готово", CallbackData: "done:1"}},
+	}
+	mockTg.EXPECT().Send(gomock.Any(), models.TelegramMessage{
+		ChatID:          "123456",
+		Text:            "<b>Заказ</b> готов",
+		ParseMode:       models.TelegramHTML,
+		Buttons:         buttons,
+		Silent:          true,
+		PhotoURL:        "https://example.com/order.png",
+		MessageThreadID: 42,
+	}).Return(nil)
+	mockStorage.EXPECT().
+		Add(gomock.Any(), "notification.status:test", string(models.StatusSent), 168*time.Hour).
+		Return(nil)
+	mockStorage.EXPECT().
+		Add(gomock.Any(), "notification.delivery:test", []byte(`["telegram"]`), 168*time.Hour).
+		Return(nil)
+
+	sender := NewNotificationSender(NotificationSenderConfig{
+		EmailSender:    mock_usecase.NewMockemailSender(ctrl),
+		TelegramSender: mockTg,
+		Storage:        mockStorage,
+		Rescheduler:    mock_usecase.NewMocknotificationRescheduler(ctrl),
+		Attachments:    NewAttachmentLoader(nil, nil, 0),
+		Mutes:          unmuted(ctrl),
+		RetryAttempts:  3,
+		RetryDelay:     time.Second,
+		RetryBackoff:   1.0,
+	})
+
+	notification := models.DelayedNotification{
+		ID:           "test",
+		Notification: "<b>Заказ</b> готов",
+		Channels: models.Channels{
+			TelegramChannel: models.TelegramChannel{
+				ChatID:          "123456",
+				ParseMode:       models.TelegramHTML,
+				Buttons:         buttons,
+				Silent:          true,
+				PhotoURL:        "https://example.com/order.png",
+				MessageThreadID: 42,
+			},
+		},
+	}
+
+	require.NoError(t, sender.Send(context.Background(), notification))
+}
+
+func TestNotificationSender_Send_Ack(t *testing.T) {
+	t.Parallel()
+
+	channels := models.Channels{
+		EmailChannel:    models.EmailChannel{Email: "user@example.com", HTML: "<html><body><p>Сервер недоступен</p></body></html>"},
+		TelegramChannel: models.TelegramChannel{ChatID: "123456"},
+	}
+	notification := models.DelayedNotification{
+		ID:           "test",
+		Notification: "Сервер недоступен",
+		Channels:     channels,
+		Ack:          &models.AckPolicy{RepeatInterval: 5 * time.Minute, MaxRepeats: 2, Channels: channels},
+	}
+
+	t.Run("repeats_until_acknowledged", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockEmail := mock_usecase.NewMockemailSender(ctrl)
+		mockTg := mock_usecase.NewMocktelegramSender(ctrl)
+		mockStorage := allowHistory(mock_usecase.NewMockstorageAdder(ctrl))
+		mockRescheduler := mock_usecase.NewMocknotificationRescheduler(ctrl)
+		mockAcks := mock_usecase.NewMockackTracker(ctrl)
+
+		mockAcks.EXPECT().IsAcknowledged(gomock.Any(), "test").Return(false, nil).Times(2)
+		mockAcks.EXPECT().AckLink("test", "user@example.com").Return("https://notify.example.com/ack/t?to=user")
+		mockAcks.EXPECT().AckCallbackData("test").Return("ack:t")
+
+		mockEmail.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, email models.EmailMessage) error {
+			assert.Equal(t, "Сервер недоступен\n\nПодтвердить получение: https://notify.example.com/ack/t?to=user", email.Text)
+			assert.Equal(t, `<html><body><p>Сервер недоступен</p><p><a href="https://notify.example.com/ack/t?to=user">Подтвердить получение</a></p></body></html>`, email.HTML)
+			return nil
+		})
+		mockTg.EXPECT().Send(gomock.Any(), models.TelegramMessage{
+			ChatID:  "123456",
+			Text:    "Сервер недоступен",
+			Buttons: [][]models.TelegramButton{{{Text: "Подтвердить получение", CallbackData: "ack:t"}}},
+		}).Return(nil)
+
+		mockRescheduler.EXPECT().
+			RescheduleNotification(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, repeat models.DelayedNotification, sendAt time.Time) error {
+				assert.Equal(t, 1, repeat.Ack.Repeat)
+				assert.Equal(t, channels, repeat.Channels)
+				assert.Nil(t, repeat.Delivered)
+				assert.WithinDuration(t, time.Now().Add(5*time.Minute), sendAt, time.Second)
+				return nil
+			})
+		mockStorage.EXPECT().
+			Add(gomock.Any(), "notification.status:test", string(models.StatusAwaitingAck), 168*time.Hour).
+			Return(nil)
+		mockStorage.EXPECT().
+			Add(gomock.Any(), "notification.delivery:test", []byte(`["email","telegram"]`), 168*time.Hour).
+			Return(nil)
+
+		sender := NewNotificationSender(NotificationSenderConfig{
+			EmailSender:    mockEmail,
+			TelegramSender: mockTg,
+			Storage:        mockStorage,
+			Rescheduler:    mockRescheduler,
+			Attachments:    NewAttachmentLoader(nil, nil, 0),
+			Acks:           mockAcks,
+			Mutes:          unmuted(ctrl),
+			RetryAttempts:  3,
+			RetryDelay:     time.Second,
+			RetryBackoff:   1.0,
+		})
+		require.NoError(t, sender.Send(context.Background(), notification))
+	})
+
+	t.Run("already_acknowledged", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStorage := allowHistory(mock_usecase.NewMockstorageAdder(ctrl))
+		mockAcks := mock_usecase.NewMockackTracker(ctrl)
+
+		mockAcks.EXPECT().IsAcknowledged(gomock.Any(), "test").Return(true, nil)
+		mockStorage.EXPECT().
+			Add(gomock.Any(), "notification.status:test", string(models.StatusSent), 168*time.Hour).
+			Return(nil)
+
+		sender := NewNotificationSender(NotificationSenderConfig{
+			EmailSender:    mock_usecase.NewMockemailSender(ctrl),
+			TelegramSender: mock_usecase.NewMocktelegramSender(ctrl),
+			Storage:        mockStorage,
+			Rescheduler:    mock_usecase.NewMocknotificationRescheduler(ctrl),
+			Attachments:    NewAttachmentLoader(nil, nil, 0),
+			Acks:           mockAcks,
+			Mutes:          unmuted(ctrl),
+			RetryAttempts:  3,
+			RetryDelay:     time.Second,
+			RetryBackoff:   1.0,
+		})
+		require.NoError(t, sender.Send(context.Background(), notification))
+	})
+}
+
+func TestNotificationSender_Send_SnoozeOptions(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockEmail := mock_usecase.NewMockemailSender(ctrl)
+	mockTg := mock_usecase.NewMocktelegramSender(ctrl)
+	mockStorage := allowHistory(mock_usecase.NewMockstorageAdder(ctrl))
+	mockSnoozes := mock_usecase.NewMocksnoozeLinker(ctrl)
+
+	mockSnoozes.EXPECT().SnoozeLink("test", time.Hour).Return("https://notify.example.com/snooze/t?for=3600")
+	mockSnoozes.EXPECT().SnoozeLink("test", 24*time.Hour).Return("https://notify.example.com/snooze/t?for=86400")
+	mockSnoozes.EXPECT().SnoozeCallbackData("test", time.Hour).Return("sz:3600:t")
+	mockSnoozes.EXPECT().SnoozeCallbackData("test", 24*time.Hour).Return("sz:86400:t")
+
+	mockEmail.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, email models.EmailMessage) error {
+		assert.Equal(t, "Оплатите счет\n\nОтложить на 1 ч: https://notify.example.com/snooze/t?for=3600"+
+			"\n\nОтложить на 1 дн.: https://notify.example.com/snooze/t?for=86400", email.Text)
+		return nil
+	})
+	mockTg.EXPECT().Send(gomock.Any(), models.TelegramMessage{
+		ChatID: "123456",
+		Text:   "Оплатите счет",
+		Buttons: [][]models.TelegramButton{{
+			{Text: "Отложить на 1 ч", CallbackData: "sz:3600:t"},
+			{Text: "Отложить на 1 дн.", CallbackData: "sz:86400:t"},
+		}},
+	}).Return(nil)
+	mockStorage.EXPECT().
+		Add(gomock.Any(), "notification.status:test", string(models.StatusSent), 168*time.Hour).
+		Return(nil)
+	mockStorage.EXPECT().
+		Add(gomock.Any(), "notification.delivery:test", []byte(`["email","telegram"]`), 168*time.Hour).
+		Return(nil)
+
+	sender := NewNotificationSender(NotificationSenderConfig{
+		EmailSender:    mockEmail,
+		TelegramSender: mockTg,
+		Storage:        mockStorage,
+		Rescheduler:    mock_usecase.NewMocknotificationRescheduler(ctrl),
+		Attachments:    NewAttachmentLoader(nil, nil, 0),
+		Mutes:          unmuted(ctrl),
+		Snoozes:        mockSnoozes,
+		RetryAttempts:  3,
+		RetryDelay:     time.Second,
+		RetryBackoff:   1.0,
+	})
+
+	notification := models.DelayedNotification{
+		ID:           "test",
+		Notification: "Оплатите счет",
+		Channels: models.Channels{
+			EmailChannel:    models.EmailChannel{Email: "user@example.com"},
+			TelegramChannel: models.TelegramChannel{ChatID: "123456"},
+		},
+		SnoozeOptions: []time.Duration{time.Hour, 24 * time.Hour},
+	}
+
+	require.NoError(t, sender.Send(context.Background(), notification))
+}
+
+func TestNotificationSender_Send_DisabledChannel(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockEmail := mock_usecase.NewMockemailSender(ctrl)
+	mockStorage := allowHistory(mock_usecase.NewMockstorageAdder(ctrl))
+
+	mockEmail.EXPECT().Send(gomock.Any(), gomock.Any()).Return(nil)
+	mockStorage.EXPECT().
+		Add(gomock.Any(), "notification.status:test", string(models.StatusFailed), 168*time.Hour).
+		Return(nil)
+	mockStorage.EXPECT().
+		Add(gomock.Any(), "notification.delivery:test", []byte(`["email"]`), 168*time.Hour).
+		Return(nil)
+
+	// телеграм выключен, поэтому его отправщик не передается, а повторная попытка не планируется
+	sender := NewNotificationSender(NotificationSenderConfig{
+		EmailSender:   mockEmail,
+		Storage:       mockStorage,
+		Rescheduler:   mock_usecase.NewMocknotificationRescheduler(ctrl),
+		Attachments:   NewAttachmentLoader(nil, nil, 0),
+		Mutes:         unmuted(ctrl),
+		RetryAttempts: 3,
+		RetryDelay:    time.Second,
+		RetryBackoff:  1.0,
+	})
+
+	notification := models.DelayedNotification{
+		ID:           "test",
+		Notification: "test",
+		Channels: models.Channels{
+			EmailChannel:    models.EmailChannel{Email: "user@example.com"},
+			TelegramChannel: models.TelegramChannel{ChatID: "123456"},
+		},
+	}
+
+	err := sender.Send(context.Background(), notification)
+	assert.ErrorIs(t, err, ErrChannelDisabled)
+}
+
+func TestNotificationSender_Send_Escalation(t *testing.T) {
+	t.Parallel()
+
+	tg := models.Channels{TelegramChannel: models.TelegramChannel{ChatID: "123456"}}
+	webhook := models.Channels{WebhookChannel: models.WebhookChannel{URL: "https://chat.example.com/hook?token=secret"}}
+	startedAt := time.Now()
+	notification := models.DelayedNotification{
+		ID:           "test",
+		Notification: "Сервер недоступен",
+		Channels:     tg,
+		Ack:          &models.AckPolicy{Channels: tg},
+		Escalation: &models.Escalation{
+			PolicyID:  "oncall",
+			Steps:     []models.EscalationStep{{Channels: tg}, {DelaySeconds: 600, Channels: webhook}},
+			StartedAt: startedAt,
+		},
+	}
+
+	t.Run("next_step_after_delivery", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockTg := mock_usecase.NewMocktelegramSender(ctrl)
+		mockStorage := allowHistory(mock_usecase.NewMockstorageAdder(ctrl))
+		mockRescheduler := mock_usecase.NewMocknotificationRescheduler(ctrl)
+		mockAcks := mock_usecase.NewMockackTracker(ctrl)
+
+		mockAcks.EXPECT().IsAcknowledged(gomock.Any(), "test").Return(false, nil).Times(2)
+		mockAcks.EXPECT().AckCallbackData("test").Return("ack:t")
+		mockTg.EXPECT().Send(gomock.Any(), gomock.Any()).Return(nil)
+		mockRescheduler.EXPECT().
+			RescheduleNotification(gomock.Any(), gomock.Any(), startedAt.Add(10*time.Minute)).
+			DoAndReturn(func(_ context.Context, next models.DelayedNotification, _ time.Time) error {
+				assert.Equal(t, 1, next.Escalation.Step)
+				assert.Equal(t, webhook, next.Channels)
+				assert.Equal(t, webhook, next.Ack.Channels)
+				return nil
+			})
+		mockStorage.EXPECT().
+			Add(gomock.Any(), "notification.status:test", string(models.StatusAwaitingAck), 168*time.Hour).
+			Return(nil)
+		mockStorage.EXPECT().
+			Add(gomock.Any(), "notification.delivery:test", []byte(`["telegram"]`), 168*time.Hour).
+			Return(nil)
+
+		sender := NewNotificationSender(NotificationSenderConfig{
+			TelegramSender: mockTg,
+			Storage:        mockStorage,
+			Rescheduler:    mockRescheduler,
+			Attachments:    NewAttachmentLoader(nil, nil, 0),
+			Acks:           mockAcks,
+			Mutes:          unmuted(ctrl),
+			RetryAttempts:  3,
+			RetryDelay:     time.Second,
+			RetryBackoff:   1.0,
+		})
+		require.NoError(t, sender.Send(context.Background(), notification))
+	})
+
+	t.Run("next_step_outruns_retry", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockTg := mock_usecase.NewMocktelegramSender(ctrl)
+		mockStorage := allowHistory(mock_usecase.NewMockstorageAdder(ctrl))
+		mockRescheduler := mock_usecase.NewMocknotificationRescheduler(ctrl)
+		mockAcks := mock_usecase.NewMockackTracker(ctrl)
+
+		mockAcks.EXPECT().IsAcknowledged(gomock.Any(), "test").Return(false, nil).Times(2)
+		mockAcks.EXPECT().AckCallbackData("test").Return("ack:t")
+		mockTg.EXPECT().Send(gomock.Any(), gomock.Any()).Return(errors.New("telegram is down"))
+		// повторная попытка через час позже второго шага, поэтому сразу планируется шаг
+		mockRescheduler.EXPECT().
+			RescheduleNotification(gomock.Any(), gomock.Any(), startedAt.Add(10*time.Minute)).
+			DoAndReturn(func(_ context.Context, next models.DelayedNotification, _ time.Time) error {
+				assert.Equal(t, 1, next.Escalation.Step)
+				assert.Zero(t, next.Attempt)
+				return nil
+			})
+		mockStorage.EXPECT().
+			Add(gomock.Any(), "notification.status:test", string(models.StatusAwaitingAck), 168*time.Hour).
+			Return(nil)
+
+		sender := NewNotificationSender(NotificationSenderConfig{
+			TelegramSender: mockTg,
+			Storage:        mockStorage,
+			Rescheduler:    mockRescheduler,
+			Attachments:    NewAttachmentLoader(nil, nil, 0),
+			Acks:           mockAcks,
+			Mutes:          unmuted(ctrl),
+			RetryAttempts:  3,
+			RetryDelay:     time.Hour,
+			RetryBackoff:   1.0,
+		})
+		assert.Error(t, sender.Send(context.Background(), notification))
+	})
+
+	t.Run("last_step_webhook", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockWh := mock_usecase.NewMockwebhookSender(ctrl)
+		mockStorage := allowHistory(mock_usecase.NewMockstorageAdder(ctrl))
+		mockAcks := mock_usecase.NewMockackTracker(ctrl)
+
+		last := notification
+		last.Channels = webhook
+		last.Ack = &models.AckPolicy{Channels: webhook}
+		last.Escalation = &models.Escalation{Steps: notification.Escalation.Steps, Step: 1, StartedAt: startedAt}
+
+		mockAcks.EXPECT().IsAcknowledged(gomock.Any(), "test").Return(false, nil)
+		mockAcks.EXPECT().AckLink("test", "webhook:chat.example.com").Return("https://notify.example.com/ack/t")
+		mockWh.EXPECT().Send(gomock.Any(), models.WebhookMessage{
+			URL:            "https://chat.example.com/hook?token=secret",
+			NotificationID: "test",
+			Text:           "Сервер недоступен",
+			AckURL:         "https://notify.example.com/ack/t",
+		}).Return(nil)
+		mockStorage.EXPECT().
+			Add(gomock.Any(), "notification.status:test", string(models.StatusSent), 168*time.Hour).
+			Return(nil)
+		mockStorage.EXPECT().
+			Add(gomock.Any(), "notification.delivery:test", []byte(`["webhook"]`), 168*time.Hour).
+			Return(nil)
+
+		sender := NewNotificationSender(NotificationSenderConfig{
+			WebhookSender: mockWh,
+			Storage:       mockStorage,
+			Rescheduler:   mock_usecase.NewMocknotificationRescheduler(ctrl),
+			Attachments:   NewAttachmentLoader(nil, nil, 0),
+			Acks:          mockAcks,
+			Mutes:         unmuted(ctrl),
+			RetryAttempts: 3,
+			RetryDelay:    time.Second,
+			RetryBackoff:  1.0,
+		})
+		require.NoError(t, sender.Send(context.Background(), last))
+	})
+}
+
+func TestNotificationSender_Send_DeliveryMode(t *testing.T) {
+	t.Parallel()
+
+	channels := models.Channels{
+		EmailChannel:    models.EmailChannel{Email: "user@example.com"},
+		TelegramChannel: models.TelegramChannel{ChatID: "123456"},
+		WebhookChannel:  models.WebhookChannel{URL: "https://chat.example.com/hook"},
+	}
+	order := []models.ChannelName{models.ChannelTelegram, models.ChannelEmail, models.ChannelWebhook}
+	email := models.EmailMessage{To: "user@example.com", Text: "msg"}
+	tg := models.TelegramMessage{ChatID: "123456", Text: "msg"}
+	webhook := models.WebhookMessage{URL: "https://chat.example.com/hook", NotificationID: "test", Text: "msg"}
+
+	t.Run("first_success_falls_back", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockEmail := mock_usecase.NewMockemailSender(ctrl)
+		mockTg := mock_usecase.NewMocktelegramSender(ctrl)
+		mockStorage := allowHistory(mock_usecase.NewMockstorageAdder(ctrl))
+
+		gomock.InOrder(
+			mockTg.EXPECT().Send(gomock.Any(), tg).Return(errors.New("telegram is down")),
+			mockEmail.EXPECT().Send(gomock.Any(), email).Return(nil),
+		)
+		mockStorage.EXPECT().
+			Add(gomock.Any(), "notification.status:test", string(models.StatusSent), 168*time.Hour).
+			Return(nil)
+		mockStorage.EXPECT().
+			Add(gomock.Any(), "notification.delivery:test", []byte(`["email"]`), 168*time.Hour).
+			Return(nil)
+
+		sender := NewNotificationSender(NotificationSenderConfig{
+			EmailSender:    mockEmail,
+			TelegramSender: mockTg,
+			WebhookSender:  mock_usecase.NewMockwebhookSender(ctrl),
+			Storage:        mockStorage,
+			Rescheduler:    mock_usecase.NewMocknotificationRescheduler(ctrl),
+			Attachments:    NewAttachmentLoader(nil, nil, 0),
+			Mutes:          unmuted(ctrl),
+			RetryAttempts:  3,
+			RetryDelay:     time.Second,
+			RetryBackoff:   1.0,
+		})
+		require.NoError(t, sender.Send(context.Background(), models.DelayedNotification{
+			ID:           "test",
+			Notification: "msg",
+			Channels:     channels,
+			Delivery:     &models.Delivery{Mode: models.DeliveryFirstSuccess, Order: order},
+		}))
+	})
+
+	t.Run("first_success_all_fail", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockEmail := mock_usecase.NewMockemailSender(ctrl)
+		mockTg := mock_usecase.NewMocktelegramSender(ctrl)
+		mockWh := mock_usecase.NewMockwebhookSender(ctrl)
+		mockStorage := allowHistory(mock_usecase.NewMockstorageAdder(ctrl))
+		mockRescheduler := mock_usecase.NewMocknotificationRescheduler(ctrl)
+
+		mockTg.EXPECT().Send(gomock.Any(), tg).Return(errors.New("telegram is down"))
+		mockEmail.EXPECT().Send(gomock.Any(), email).Return(errors.New("smtp timeout"))
+		mockWh.EXPECT().Send(gomock.Any(), webhook).Return(errors.New("webhook responded with status 502"))
+		mockRescheduler.EXPECT().
+			RescheduleNotification(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, retry models.DelayedNotification, _ time.Time) error {
+				assert.Equal(t, channels, retry.Channels)
+				assert.Empty(t, retry.Delivered)
+				return nil
+			})
+		mockStorage.EXPECT().
+			Add(gomock.Any(), "notification.status:test", string(models.StatusRetrying), 168*time.Hour).
+			Return(nil)
+
+		sender := NewNotificationSender(NotificationSenderConfig{
+			EmailSender:    mockEmail,
+			TelegramSender: mockTg,
+			WebhookSender:  mockWh,
+			Storage:        mockStorage,
+			Rescheduler:    mockRescheduler,
+			Attachments:    NewAttachmentLoader(nil, nil, 0),
+			Mutes:          unmuted(ctrl),
+			RetryAttempts:  3,
+			RetryDelay:     time.Second,
+			RetryBackoff:   1.0,
+		})
+		assert.Error(t, sender.Send(context.Background(), models.DelayedNotification{
+			ID:           "test",
+			Notification: "msg",
+			Channels:     channels,
+			Delivery:     &models.Delivery{Mode: models.DeliveryFirstSuccess, Order: order},
+		}))
+	})
+
+	t.Run("any_n_retries_missing", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockEmail := mock_usecase.NewMockemailSender(ctrl)
+		mockTg := mock_usecase.NewMocktelegramSender(ctrl)
+		mockWh := mock_usecase.NewMockwebhookSender(ctrl)
+		mockStorage := allowHistory(mock_usecase.NewMockstorageAdder(ctrl))
+		mockRescheduler := mock_usecase.NewMocknotificationRescheduler(ctrl)
+
+		mockTg.EXPECT().Send(gomock.Any(), tg).Return(nil)
+		mockEmail.EXPECT().Send(gomock.Any(), email).Return(errors.New("smtp timeout"))
+		mockWh.EXPECT().Send(gomock.Any(), webhook).Return(errors.New("webhook responded with status 502"))
+		mockRescheduler.EXPECT().
+			RescheduleNotification(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, retry models.DelayedNotification, _ time.Time) error {
+				assert.Equal(t, models.Channels{EmailChannel: channels.EmailChannel, WebhookChannel: channels.WebhookChannel}, retry.Channels)
+				assert.Equal(t, []models.ChannelName{models.ChannelTelegram}, retry.Delivered)
+				return nil
+			})
+		mockStorage.EXPECT().
+			Add(gomock.Any(), "notification.status:test", string(models.StatusRetrying), 168*time.Hour).
+			Return(nil)
+		mockStorage.EXPECT().
+			Add(gomock.Any(), "notification.delivery:test", []byte(`["telegram"]`), 168*time.Hour).
+			Return(nil)
+
+		sender := NewNotificationSender(NotificationSenderConfig{
+			EmailSender:    mockEmail,
+			TelegramSender: mockTg,
+			WebhookSender:  mockWh,
+			Storage:        mockStorage,
+			Rescheduler:    mockRescheduler,
+			Attachments:    NewAttachmentLoader(nil, nil, 0),
+			Mutes:          unmuted(ctrl),
+			RetryAttempts:  3,
+			RetryDelay:     time.Second,
+			RetryBackoff:   1.0,
+		})
+		assert.Error(t, sender.Send(context.Background(), models.DelayedNotification{
+			ID:           "test",
+			Notification: "msg",
+			Channels:     channels,
+			Delivery:     &models.Delivery{Mode: models.DeliveryAnyN, Order: order, N: 2},
+		}))
+	})
+
+	t.Run("any_n_counts_previous_attempts", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockEmail := mock_usecase.NewMockemailSender(ctrl)
+		mockStorage := allowHistory(mock_usecase.NewMockstorageAdder(ctrl))
+
+		mockEmail.EXPECT().Send(gomock.Any(), email).Return(nil)
+		mockStorage.EXPECT().
+			Add(gomock.Any(), "notification.status:test", string(models.StatusSent), 168*time.Hour).
+			Return(nil)
+		mockStorage.EXPECT().
+			Add(gomock.Any(), "notification.delivery:test", []byte(`["telegram","email"]`), 168*time.Hour).
+			Return(nil)
+
+		sender := NewNotificationSender(NotificationSenderConfig{
+			EmailSender:    mockEmail,
+			TelegramSender: mock_usecase.NewMocktelegramSender(ctrl),
+			WebhookSender:  mock_usecase.NewMockwebhookSender(ctrl),
+			Storage:        mockStorage,
+			Rescheduler:    mock_usecase.NewMocknotificationRescheduler(ctrl),
+			Attachments:    NewAttachmentLoader(nil, nil, 0),
+			Mutes:          unmuted(ctrl),
+			RetryAttempts:  3,
+			RetryDelay:     time.Second,
+			RetryBackoff:   1.0,
+		})
+		require.NoError(t, sender.Send(context.Background(), models.DelayedNotification{
+			ID:           "test",
+			Notification: "msg",
+			Channels:     models.Channels{EmailChannel: channels.EmailChannel, WebhookChannel: channels.WebhookChannel},
+			Attempt:      1,
+			Delivery:     &models.Delivery{Mode: models.DeliveryAnyN, Order: order, N: 2},
+			Delivered:    []models.ChannelName{models.ChannelTelegram},
+		}))
+	})
+}
+
+func TestNotificationSender_Send_MutedChat(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	newSender := func(mockEmail *mock_usecase.MockemailSender, mockStorage *mock_usecase.MockstorageAdder) *NotificationSender {
+		mockMutes := mock_usecase.NewMockchatMuteChecker(ctrl)
+		mockMutes.EXPECT().IsChatMuted(gomock.Any(), "123456").Return(true, nil)
+
+		return NewNotificationSender(NotificationSenderConfig{
+			EmailSender:    mockEmail,
+			TelegramSender: mock_usecase.NewMocktelegramSender(ctrl),
+			Storage:        mockStorage,
+			Rescheduler:    mock_usecase.NewMocknotificationRescheduler(ctrl),
+			Attachments:    NewAttachmentLoader(nil, nil, 0),
+			Mutes:          mockMutes,
+			RetryAttempts:  3,
+			RetryDelay:     time.Second,
+			RetryBackoff:   1.0,
+		})
+	}
+
+	t.Run("other_channels_sent", func(t *testing.T) {
+		mockEmail := mock_usecase.NewMockemailSender(ctrl)
+		mockStorage := allowHistory(mock_usecase.NewMockstorageAdder(ctrl))
+
+		mockEmail.EXPECT().Send(gomock.Any(), models.EmailMessage{To: "user@example.com", Text: "msg"}).Return(nil)
+		mockStorage.EXPECT().
+			Add(gomock.Any(), "notification.status:test", string(models.StatusSent), 168*time.Hour).
+			Return(nil)
+		mockStorage.EXPECT().
+			Add(gomock.Any(), "notification.delivery:test", []byte(`["email"]`), 168*time.Hour).
+			Return(nil)
+		mockStorage.EXPECT().
+			Add(gomock.Any(), "notification.suppressed:test", []byte(`["telegram"]`), 168*time.Hour).
+			Return(nil)
+
+		require.NoError(t, newSender(mockEmail, mockStorage).Send(context.Background(), models.DelayedNotification{
+			ID:           "test",
+			Notification: "msg",
+			Channels: models.Channels{
+				EmailChannel:    models.EmailChannel{Email: "user@example.com"},
+				TelegramChannel: models.TelegramChannel{ChatID: "123456"},
+			},
+		}))
+	})
+
+	t.Run("telegram_only", func(t *testing.T) {
+		mockStorage := allowHistory(mock_usecase.NewMockstorageAdder(ctrl))
+
+		// уведомление только в выключенный чат не доставлено, поэтому не отмечается отправленным
+		mockStorage.EXPECT().
+			Add(gomock.Any(), "notification.status:test", string(models.StatusSuppressed), 168*time.Hour).
+			Return(nil)
+		mockStorage.EXPECT().
+			Add(gomock.Any(), "notification.suppressed:test", []byte(`["telegram"]`), 168*time.Hour).
+			Return(nil)
+
+		require.NoError(t, newSender(mock_usecase.NewMockemailSender(ctrl), mockStorage).Send(context.Background(), models.DelayedNotification{
+			ID:           "test",
+			Notification: "msg",
+			Channels:     models.Channels{TelegramChannel: models.TelegramChannel{ChatID: "123456"}},
+		}))
+	})
+}
+
+func TestNotificationSender_Send_Recipient(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	recipient := models.Recipient{UserID: "user-1", Email: "new@example.com", TelegramChatID: "123456"}
+
+	t.Run("addresses_from_registry", func(t *testing.T) {
+		mockEmail := mock_usecase.NewMockemailSender(ctrl)
+		mockStorage := allowHistory(mock_usecase.NewMockstorageAdder(ctrl))
+		mockRecipients := mock_usecase.NewMockrecipientGetter(ctrl)
+		mockPrefs := mock_usecase.NewMockpreferenceChecker(ctrl)
+
+		// телеграм уже доставлен в предыдущей попытке, повторно отправляется только email по новому адресу
+		mockRecipients.EXPECT().GetRecipient(gomock.Any(), "user-1").Return(recipient, nil)
+		mockPrefs.EXPECT().GetPreferences(gomock.Any(), "user-1").Return(models.Preferences{}, nil)
+		mockPrefs.EXPECT().PreferencesLink("user-1").Return("https://notify.example.com/preferences/t")
+		mockPrefs.EXPECT().UnsubscribeLink("user-1", "").Return("https://notify.example.com/preferences/t/unsubscribe")
+		mockEmail.EXPECT().Send(gomock.Any(), models.EmailMessage{
+			To:          "new@example.com",
+			Text:        "msg\n\nНастроить уведомления: https://notify.example.com/preferences/t",
+			Unsubscribe: "https://notify.example.com/preferences/t/unsubscribe",
+		}).Return(nil)
+		mockStorage.EXPECT().
+			Add(gomock.Any(), "notification.status:test", string(models.StatusSent), 168*time.Hour).
+			Return(nil)
+		mockStorage.EXPECT().
+			Add(gomock.Any(), "notification.delivery:test", gomock.Any(), 168*time.Hour).
+			Return(nil)
+
+		sender := NewNotificationSender(NotificationSenderConfig{
+			EmailSender:    mockEmail,
+			TelegramSender: mock_usecase.NewMocktelegramSender(ctrl),
+			Storage:        mockStorage,
+			Rescheduler:    mock_usecase.NewMocknotificationRescheduler(ctrl),
+			Attachments:    NewAttachmentLoader(nil, nil, 0),
+			Mutes:          unmuted(ctrl),
+			Recipients:     mockRecipients,
+			Preferences:    mockPrefs,
+			RetryAttempts:  3,
+			RetryDelay:     time.Second,
+			RetryBackoff:   1.0,
+		})
+		require.NoError(t, sender.Send(context.Background(), models.DelayedNotification{
+			ID:           "test",
+			Notification: "msg",
+			RecipientID:  "user-1",
+			Delivered:    []models.ChannelName{models.ChannelTelegram},
+			Channels: models.Channels{
+				EmailChannel: models.EmailChannel{Email: "old@example.com"},
+			},
+		}))
+	})
+
+	t.Run("opted_out_channel", func(t *testing.T) {
+		mockTg := mock_usecase.NewMocktelegramSender(ctrl)
+		mockStorage := allowHistory(mock_usecase.NewMockstorageAdder(ctrl))
+		mockRecipients := mock_usecase.NewMockrecipientGetter(ctrl)
+		mockPrefs := mock_usecase.NewMockpreferenceChecker(ctrl)
+
+		mockRecipients.EXPECT().GetRecipient(gomock.Any(), "user-1").Return(recipient, nil)
+		mockPrefs.EXPECT().GetPreferences(gomock.Any(), "user-1").
+			Return(models.Preferences{OptedOutChannels: []models.ChannelName{models.ChannelEmail}}, nil)
+		mockTg.EXPECT().Send(gomock.Any(), models.TelegramMessage{ChatID: "123456", Text: "msg"}).Return(nil)
+		mockStorage.EXPECT().
+			Add(gomock.Any(), "notification.status:test", string(models.StatusSent), 168*time.Hour).
+			Return(nil)
+		mockStorage.EXPECT().
+			Add(gomock.Any(), "notification.delivery:test", []byte(`["telegram"]`), 168*time.Hour).
+			Return(nil)
+		mockStorage.EXPECT().
+			Add(gomock.Any(), "notification.suppressed:test", []byte(`["email"]`), 168*time.Hour).
+			Return(nil)
+
+		sender := NewNotificationSender(NotificationSenderConfig{
+			EmailSender:    mock_usecase.NewMockemailSender(ctrl),
+			TelegramSender: mockTg,
+			Storage:        mockStorage,
+			Rescheduler:    mock_usecase.NewMocknotificationRescheduler(ctrl),
+			Attachments:    NewAttachmentLoader(nil, nil, 0),
+			Mutes:          unmuted(ctrl),
+			Recipients:     mockRecipients,
+			Preferences:    mockPrefs,
+			RetryAttempts:  3,
+			RetryDelay:     time.Second,
+			RetryBackoff:   1.0,
+		})
+		require.NoError(t, sender.Send(context.Background(), models.DelayedNotification{
+			ID:           "test",
+			Notification: "msg",
+			RecipientID:  "user-1",
+		}))
+	})
+
+	t.Run("opted_out_category", func(t *testing.T) {
+		mockStorage := allowHistory(mock_usecase.NewMockstorageAdder(ctrl))
+		mockRecipients := mock_usecase.NewMockrecipientGetter(ctrl)
+		mockPrefs := mock_usecase.NewMockpreferenceChecker(ctrl)
+
+		mockAcks := mock_usecase.NewMockackTracker(ctrl)
+
+		// от категории отказались целиком: ничего не отправляется, повторы подтверждения не планируются
+		mockAcks.EXPECT().IsAcknowledged(gomock.Any(), "test").Return(false, nil)
+		mockRecipients.EXPECT().GetRecipient(gomock.Any(), "user-1").Return(recipient, nil)
+		mockPrefs.EXPECT().GetPreferences(gomock.Any(), "user-1").
+			Return(models.Preferences{OptedOutCategories: []string{"marketing"}}, nil)
+		mockStorage.EXPECT().
+			Add(gomock.Any(), "notification.status:test", string(models.StatusSuppressed), 168*time.Hour).
+			Return(nil)
+		mockStorage.EXPECT().
+			Add(gomock.Any(), "notification.suppressed:test", []byte(`["email","telegram"]`), 168*time.Hour).
+			Return(nil)
+
+		sender := NewNotificationSender(NotificationSenderConfig{
+			EmailSender:    mock_usecase.NewMockemailSender(ctrl),
+			TelegramSender: mock_usecase.NewMocktelegramSender(ctrl),
+			Storage:        mockStorage,
+			Rescheduler:    mock_usecase.NewMocknotificationRescheduler(ctrl),
+			Attachments:    NewAttachmentLoader(nil, nil, 0),
+			Acks:           mockAcks,
+			Mutes:          unmuted(ctrl),
+			Recipients:     mockRecipients,
+			Preferences:    mockPrefs,
+			RetryAttempts:  3,
+			RetryDelay:     time.Second,
+			RetryBackoff:   1.0,
+		})
+		require.NoError(t, sender.Send(context.Background(), models.DelayedNotification{
+			ID:           "test",
+			Notification: "msg",
+			RecipientID:  "user-1",
+			Category:     "marketing",
+			Ack:          &models.AckPolicy{RepeatInterval: time.Hour, MaxRepeats: 3},
+		}))
+	})
+
+	t.Run("no_address", func(t *testing.T) {
+		mockStorage := allowHistory(mock_usecase.NewMockstorageAdder(ctrl))
+		mockRecipients := mock_usecase.NewMockrecipientGetter(ctrl)
+		mockPrefs := mock_usecase.NewMockpreferenceChecker(ctrl)
+		mockRescheduler := mock_usecase.NewMocknotificationRescheduler(ctrl)
+
+		// адрес может появиться в реестре позже, поэтому уведомление уходит на повторную попытку
+		mockRecipients.EXPECT().GetRecipient(gomock.Any(), "user-2").Return(models.Recipient{UserID: "user-2"}, nil)
+		mockPrefs.EXPECT().GetPreferences(gomock.Any(), "user-2").Return(models.Preferences{}, nil)
+		mockRescheduler.EXPECT().RescheduleNotification(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+		mockStorage.EXPECT().
+			Add(gomock.Any(), "notification.status:test", string(models.StatusRetrying), 168*time.Hour).
+			Return(nil)
+
+		sender := NewNotificationSender(NotificationSenderConfig{
+			EmailSender:    mock_usecase.NewMockemailSender(ctrl),
+			TelegramSender: mock_usecase.NewMocktelegramSender(ctrl),
+			Storage:        mockStorage,
+			Rescheduler:    mockRescheduler,
+			Attachments:    NewAttachmentLoader(nil, nil, 0),
+			Mutes:          unmuted(ctrl),
+			Recipients:     mockRecipients,
+			Preferences:    mockPrefs,
+			RetryAttempts:  3,
+			RetryDelay:     time.Second,
+			RetryBackoff:   1.0,
+		})
+		err := sender.Send(context.Background(), models.DelayedNotification{
+			ID:           "test",
+			Notification: "msg",
+			RecipientID:  "user-2",
+		})
+		assert.ErrorIs(t, err, ErrNoRecipientAddress)
+	})
+}
+
+// deliveredOf разбирает сохраняемый список доставленных каналов.
+func deliveredOf(payload string) []models.ChannelName {
+	if payload == "" {
+		return nil
+	}
+	var delivered []models.ChannelName
+	if err := json.Unmarshal([]byte(payload), &delivered); err != nil {
+		panic(err)
+	}
+	return delivered
+}
+
+// allowHistory разрешает запись истории статусов, которую проверяют тесты NotificationCreator.
+func allowHistory(storage *mock_usecase.MockstorageAdder) *mock_usecase.MockstorageAdder {
+	storage.EXPECT().ListPushCapped(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), 168*time.Hour).Return(nil).AnyTimes()
+	return storage
+}
+
+// unmuted возвращает проверку чатов, в которых уведомления не выключены.
+func unmuted(ctrl *gomock.Controller) chatMuteChecker {
+	mutes := mock_usecase.NewMockchatMuteChecker(ctrl)
+	mutes.EXPECT().IsChatMuted(gomock.Any(), gomock.Any()).Return(false, nil).AnyTimes()
+	return mutes
+}
+
+func TestNotificationSender_Send_RateLimit(t *testing.T) {
+	t.Parallel()
+
+	channels := models.Channels{
+		EmailChannel:    models.EmailChannel{Email: "user@example.com"},
+		TelegramChannel: models.TelegramChannel{ChatID: "123456"},
+	}
+
+	t.Run("deferred_without_spending_attempt", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockEmail := mock_usecase.NewMockemailSender(ctrl)
+		mockStorage := allowHistory(mock_usecase.NewMockstorageAdder(ctrl))
+		mockRescheduler := mock_usecase.NewMocknotificationRescheduler(ctrl)
+		mockLimits := mock_usecase.NewMockrateLimiter(ctrl)
+
+		mockLimits.EXPECT().Take(gomock.Any(), models.ChannelEmail, "user@example.com").Return(nil)
+		mockLimits.EXPECT().Take(gomock.Any(), models.ChannelTelegram, "123456").
+			Return(&RateLimitError{Channel: models.ChannelTelegram, Action: models.RateLimitDefer, RetryAfter: 30 * time.Minute})
+		mockEmail.EXPECT().Send(gomock.Any(), gomock.Any()).Return(nil)
+
+		mockRescheduler.EXPECT().
+			RescheduleNotification(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, retry models.DelayedNotification, sendAt time.Time) error {
+				assert.Equal(t, models.Channels{TelegramChannel: channels.TelegramChannel}, retry.Channels)
+				assert.Equal(t, 1, retry.Attempt)
+				assert.WithinDuration(t, time.Now().Add(30*time.Minute), sendAt, time.Second)
+				return nil
+			})
+		mockStorage.EXPECT().
+			Add(gomock.Any(), "notification.status:test", string(models.StatusDeferred), 168*time.Hour).
+			Return(nil)
+		mockStorage.EXPECT().
+			Add(gomock.Any(), "notification.delivery:test", []byte(`["email"]`), 168*time.Hour).
+			Return(nil)
+
+		sender := NewNotificationSender(NotificationSenderConfig{
+			EmailSender:    mockEmail,
+			TelegramSender: mock_usecase.NewMocktelegramSender(ctrl),
+			Storage:        mockStorage,
+			Rescheduler:    mockRescheduler,
+			Attachments:    NewAttachmentLoader(nil, nil, 0),
+			Mutes:          unmuted(ctrl),
+			Limits:         mockLimits,
+			RetryAttempts:  3,
+			RetryDelay:     time.Second,
+			RetryBackoff:   1.0,
+		})
+		err := sender.Send(context.Background(), models.DelayedNotification{
+			ID: "test", Notification: "Hello", Channels: channels, Attempt: 1,
+		})
+
+		var limitErr *RateLimitError
+		require.ErrorAs(t, err, &limitErr)
+		assert.Equal(t, models.ChannelTelegram, limitErr.Channel)
+	})
+
+	t.Run("rejected_channel", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockEmail := mock_usecase.NewMockemailSender(ctrl)
+		mockStorage := allowHistory(mock_usecase.NewMockstorageAdder(ctrl))
+		mockLimits := mock_usecase.NewMockrateLimiter(ctrl)
+
+		mockLimits.EXPECT().Take(gomock.Any(), models.ChannelEmail, "user@example.com").Return(nil)
+		mockLimits.EXPECT().Take(gomock.Any(), models.ChannelTelegram, "123456").
+			Return(&RateLimitError{Channel: models.ChannelTelegram, Action: models.RateLimitReject, RetryAfter: time.Minute})
+		mockEmail.EXPECT().Send(gomock.Any(), gomock.Any()).Return(nil)
+
+		mockStorage.EXPECT().
+			Add(gomock.Any(), "notification.status:test", string(models.StatusSent), 168*time.Hour).
+			Return(nil)
+		mockStorage.EXPECT().
+			Add(gomock.Any(), "notification.delivery:test", []byte(`["email"]`), 168*time.Hour).
+			Return(nil)
+		mockStorage.EXPECT().
+			Add(gomock.Any(), "notification.rate_limited:test", []byte(`["telegram"]`), 168*time.Hour).
+			Return(nil)
+
+		sender := NewNotificationSender(NotificationSenderConfig{
+			EmailSender:    mockEmail,
+			TelegramSender: mock_usecase.NewMocktelegramSender(ctrl),
+			Storage:        mockStorage,
+			Rescheduler:    mock_usecase.NewMocknotificationRescheduler(ctrl),
+			Attachments:    NewAttachmentLoader(nil, nil, 0),
+			Mutes:          unmuted(ctrl),
+			Limits:         mockLimits,
+			RetryAttempts:  3,
+			RetryDelay:     time.Second,
+			RetryBackoff:   1.0,
+		})
+		require.NoError(t, sender.Send(context.Background(), models.DelayedNotification{
+			ID: "test", Notification: "Hello", Channels: channels,
+		}))
+	})
+
+	t.Run("all_channels_rejected", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStorage := allowHistory(mock_usecase.NewMockstorageAdder(ctrl))
+		mockLimits := mock_usecase.NewMockrateLimiter(ctrl)
+
+		mockLimits.EXPECT().Take(gomock.Any(), models.ChannelTelegram, "123456").
+			Return(&RateLimitError{Channel: models.ChannelTelegram, Action: models.RateLimitReject, RetryAfter: time.Minute})
+
+		mockStorage.EXPECT().
+			Add(gomock.Any(), "notification.status:test", string(models.StatusRejected), 168*time.Hour).
+			Return(nil)
+		mockStorage.EXPECT().
+			Add(gomock.Any(), "notification.rate_limited:test", []byte(`["telegram"]`), 168*time.Hour).
+			Return(nil)
+
+		sender := NewNotificationSender(NotificationSenderConfig{
+			EmailSender:    mock_usecase.NewMockemailSender(ctrl),
+			TelegramSender: mock_usecase.NewMocktelegramSender(ctrl),
+			Storage:        mockStorage,
+			Rescheduler:    mock_usecase.NewMocknotificationRescheduler(ctrl),
+			Attachments:    NewAttachmentLoader(nil, nil, 0),
+			Mutes:          unmuted(ctrl),
+			Limits:         mockLimits,
+			RetryAttempts:  3,
+			RetryDelay:     time.Second,
+			RetryBackoff:   1.0,
+		})
+		require.NoError(t, sender.Send(context.Background(), models.DelayedNotification{
+			ID: "test", Notification: "Hello", Channels: models.Channels{TelegramChannel: channels.TelegramChannel},
+		}))
+	})
+}
+
+func TestNotificationSender_Send_ProviderRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTg := mock_usecase.NewMocktelegramSender(ctrl)
+	mockStorage := allowHistory(mock_usecase.NewMockstorageAdder(ctrl))
+	mockRescheduler := mock_usecase.NewMocknotificationRescheduler(ctrl)
+
+	mockTg.EXPECT().Send(gomock.Any(), gomock.Any()).
+		Return(&models.RetryAfterError{After: 15 * time.Second, Err: errors.New("too many requests")})
+	mockRescheduler.EXPECT().
+		RescheduleNotification(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, retry models.DelayedNotification, sendAt time.Time) error {
+			assert.Equal(t, 2, retry.Attempt, "throttling by provider should not spend an attempt")
+			assert.WithinDuration(t, time.Now().Add(15*time.Second), sendAt, time.Second)
+			return nil
+		})
+	mockStorage.EXPECT().
+		Add(gomock.Any(), "notification.status:test", string(models.StatusDeferred), 168*time.Hour).
+		Return(nil)
+
+	sender := NewNotificationSender(NotificationSenderConfig{
+		EmailSender:    mock_usecase.NewMockemailSender(ctrl),
+		TelegramSender: mockTg,
+		Storage:        mockStorage,
+		Rescheduler:    mockRescheduler,
+		Attachments:    NewAttachmentLoader(nil, nil, 0),
+		Mutes:          unmuted(ctrl),
+		RetryAttempts:  3,
+		RetryDelay:     time.Hour,
+		RetryBackoff:   2.0,
+	})
+	err := sender.Send(context.Background(), models.DelayedNotification{
+		ID:           "test",
+		Notification: "Hello",
+		Channels:     models.Channels{TelegramChannel: models.TelegramChannel{ChatID: "123456"}},
+		Attempt:      2,
+	})
+	assert.Error(t, err)
+}
+
+func TestNotificationSender_Send_CircuitOpen(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTg := mock_usecase.NewMocktelegramSender(ctrl)
+	mockStorage := allowHistory(mock_usecase.NewMockstorageAdder(ctrl))
+	mockRescheduler := mock_usecase.NewMocknotificationRescheduler(ctrl)
+	mockBreakers := mock_usecase.NewMockchannelBreaker(ctrl)
+	mockLimits := mock_usecase.NewMockrateLimiter(ctrl)
+
+	// лимит получателя email не расходуется, пока отправка отложена разомкнутым автоматом
+	mockBreakers.EXPECT().Allow(models.ChannelEmail).
+		Return(&models.RetryAfterError{After: 20 * time.Second, Err: ErrCircuitOpen})
+	mockBreakers.EXPECT().Allow(models.ChannelTelegram).Return(nil)
+	mockLimits.EXPECT().Take(gomock.Any(), models.ChannelTelegram, "123456").Return(nil)
+	mockBreakers.EXPECT().Record(models.ChannelTelegram, nil)
+	mockTg.EXPECT().Send(gomock.Any(), gomock.Any()).Return(nil)
+
+	mockRescheduler.EXPECT().
+		RescheduleNotification(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, retry models.DelayedNotification, sendAt time.Time) error {
+			assert.Equal(t, models.Channels{EmailChannel: models.EmailChannel{Email: "user@example.com"}}, retry.Channels)
+			assert.Equal(t, 0, retry.Attempt)
+			assert.WithinDuration(t, time.Now().Add(20*time.Second), sendAt, time.Second)
+			return nil
+		})
+	mockStorage.EXPECT().
+		Add(gomock.Any(), "notification.status:test", string(models.StatusDeferred), 168*time.Hour).
+		Return(nil)
+	mockStorage.EXPECT().
+		Add(gomock.Any(), "notification.delivery:test", []byte(`["telegram"]`), 168*time.Hour).
+		Return(nil)
+
+	sender := NewNotificationSender(NotificationSenderConfig{
+		EmailSender:    mock_usecase.NewMockemailSender(ctrl),
+		TelegramSender: mockTg,
+		Storage:        mockStorage,
+		Rescheduler:    mockRescheduler,
+		Attachments:    NewAttachmentLoader(nil, nil, 0),
+		Mutes:          unmuted(ctrl),
+		Limits:         mockLimits,
+		Breakers:       mockBreakers,
+		RetryAttempts:  3,
+		RetryDelay:     time.Second,
+		RetryBackoff:   1.0,
+	})
+	err := sender.Send(context.Background(), models.DelayedNotification{
+		ID:           "test",
+		Notification: "Hello",
+		Channels: models.Channels{
+			EmailChannel:    models.EmailChannel{Email: "user@example.com"},
+			TelegramChannel: models.TelegramChannel{ChatID: "123456"},
+		},
+	})
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+}
+
+func TestNotificationSender_Send_Digest(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	digest := models.DelayedNotification{
+		ID:          "digest",
+		RecipientID: "user-1",
+		Digest:      &models.DigestPolicy{Key: "reminders", Window: time.Hour},
+	}
+	recipient := models.Recipient{UserID: "user-1", TelegramChatID: "123456"}
+
+	t.Run("sent", func(t *testing.T) {
+		mockTg := mock_usecase.NewMocktelegramSender(ctrl)
+		mockStorage := allowHistory(mock_usecase.NewMockstorageAdder(ctrl))
+		mockRecipients := mock_usecase.NewMockrecipientGetter(ctrl)
+		mockPrefs := mock_usecase.NewMockpreferenceChecker(ctrl)
+		mockDigests := mock_usecase.NewMockdigestStore(ctrl)
+
+		mockRecipients.EXPECT().GetRecipient(gomock.Any(), "user-1").Return(recipient, nil)
+		mockPrefs.EXPECT().GetPreferences(gomock.Any(), "user-1").Return(models.Preferences{}, nil)
+		mockDigests.EXPECT().DigestMembers(gomock.Any(), "digest").Return([]models.DelayedNotification{
+			{ID: "member-1", Notification: "first", Digest: digest.Digest},
+			{ID: "member-2", Notification: "second", Digest: digest.Digest},
+		}, nil)
+		mockTg.EXPECT().Send(gomock.Any(), models.TelegramMessage{
+			ChatID: "123456",
+			Text:   "Сводка уведомлений (2):\n\n• first\n\n• second",
+		}).Return(nil)
+		mockStorage.EXPECT().
+			Add(gomock.Any(), "notification.status:digest", string(models.StatusSent), 168*time.Hour).
+			Return(nil)
+		mockStorage.EXPECT().
+			Add(gomock.Any(), "notification.delivery:digest", []byte(`["telegram"]`), 168*time.Hour).
+			Return(nil)
+		mockDigests.EXPECT().CompleteDigest(gomock.Any(), "digest", models.StatusSent).Return(nil)
+
+		sender := NewNotificationSender(NotificationSenderConfig{
+			EmailSender:    mock_usecase.NewMockemailSender(ctrl),
+			TelegramSender: mockTg,
+			Storage:        mockStorage,
+			Rescheduler:    mock_usecase.NewMocknotificationRescheduler(ctrl),
+			Attachments:    NewAttachmentLoader(nil, nil, 0),
+			Mutes:          unmuted(ctrl),
+			Recipients:     mockRecipients,
+			Preferences:    mockPrefs,
+			Digests:        mockDigests,
+			RetryAttempts:  3,
+			RetryDelay:     time.Second,
+			RetryBackoff:   1.0,
+		})
+		require.NoError(t, sender.Send(context.Background(), digest))
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		mockStorage := allowHistory(mock_usecase.NewMockstorageAdder(ctrl))
+		mockRecipients := mock_usecase.NewMockrecipientGetter(ctrl)
+		mockPrefs := mock_usecase.NewMockpreferenceChecker(ctrl)
+		mockDigests := mock_usecase.NewMockdigestStore(ctrl)
+
+		mockRecipients.EXPECT().GetRecipient(gomock.Any(), "user-1").Return(recipient, nil)
+		mockPrefs.EXPECT().GetPreferences(gomock.Any(), "user-1").Return(models.Preferences{}, nil)
+		mockDigests.EXPECT().DigestMembers(gomock.Any(), "digest").Return(nil, nil)
+		mockStorage.EXPECT().
+			Add(gomock.Any(), "notification.status:digest", string(models.StatusDropped), 168*time.Hour).
+			Return(nil)
+
+		sender := NewNotificationSender(NotificationSenderConfig{
+			EmailSender:    mock_usecase.NewMockemailSender(ctrl),
+			TelegramSender: mock_usecase.NewMocktelegramSender(ctrl),
+			Storage:        mockStorage,
+			Rescheduler:    mock_usecase.NewMocknotificationRescheduler(ctrl),
+			Attachments:    NewAttachmentLoader(nil, nil, 0),
+			Mutes:          unmuted(ctrl),
+			Recipients:     mockRecipients,
+			Preferences:    mockPrefs,
+			Digests:        mockDigests,
+			RetryAttempts:  3,
+			RetryDelay:     time.Second,
+			RetryBackoff:   1.0,
+		})
+		require.NoError(t, sender.Send(context.Background(), digest))
+	})
+}
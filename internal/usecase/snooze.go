@@ -0,0 +1,82 @@
+package usecase
+
+import (
+	"context"
+	"crypto/hmac"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
+)
+
+// snoozeSignatureBytes длина подписи токена: callback_data кнопки телеграм
+// вместе с префиксом и длительностью должна уместиться в 64 байта.
+const snoozeSignatureBytes = 12
+
+const (
+	minSnooze = time.Minute
+	maxSnooze = 7 * 24 * time.Hour
+)
+
+// ErrInvalidSnoozeToken возвращается, если токен ссылки "отложить" подделан или поврежден.
+var ErrInvalidSnoozeToken = errors.New("invalid snooze token")
+
+type notificationSnoozer interface {
+	SnoozeNotification(ctx context.Context, uid string, sendAt time.Time) (string, error)
+}
+
+// SnoozeManager выдает подписанные ссылки и кнопки "отложить" для получателей уведомлений
+// и откладывает уведомления по ним.
+type SnoozeManager struct {
+	notifications notificationSnoozer
+	secret        []byte // ключ HMAC подписи токенов
+	baseURL       string // публичный адрес сервиса для ссылок
+}
+
+// NewSnoozeManager создает новый SnoozeManager.
+func NewSnoozeManager(notifications notificationSnoozer, secret []byte, baseURL string) *SnoozeManager {
+	return &SnoozeManager{notifications: notifications, secret: secret, baseURL: strings.TrimRight(baseURL, "/")}
+}
+
+// SnoozeLink возвращает ссылку, откладывающую уведомление на d.
+func (sm *SnoozeManager) SnoozeLink(uid string, d time.Duration) string {
+	return sm.baseURL + "/snooze/" + sm.token(uid) + "?for=" + strconv.FormatInt(int64(d/time.Second), 10)
+}
+
+// SnoozeCallbackData возвращает callback_data кнопки телеграм, откладывающей уведомление на d.
+func (sm *SnoozeManager) SnoozeCallbackData(uid string, d time.Duration) string {
+	return models.SnoozeCallbackPrefix + strconv.FormatInt(int64(d/time.Second), 10) + ":" + sm.token(uid)
+}
+
+// token подписывает айди уведомления. Длительность не подписывается: получатель
+// и так может отложить уведомление, а ее пределы проверяются в Snooze.
+func (sm *SnoozeManager) token(uid string) string {
+	return uid + "." + sm.signature(uid)
+}
+
+func (sm *SnoozeManager) signature(uid string) string {
+	return hmacSignature(sm.secret, "snooze\n"+uid, snoozeSignatureBytes)
+}
+
+// Snooze проверяет токен и откладывает уведомление на d.
+// Возвращает айди отложенного уведомления и время его отправки.
+func (sm *SnoozeManager) Snooze(ctx context.Context, token string, d time.Duration) (string, time.Time, error) {
+	uid, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(sm.signature(uid))) {
+		return "", time.Time{}, ErrInvalidSnoozeToken
+	}
+	if d < minSnooze || d > maxSnooze {
+		return "", time.Time{}, fmt.Errorf("%w: must be between %s and %s", ErrInvalidSnoozeTime, minSnooze, maxSnooze)
+	}
+
+	sendAt := time.Now().Add(d)
+	snoozedID, err := sm.notifications.SnoozeNotification(ctx, uid, sendAt)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return snoozedID, sendAt, nil
+}
@@ -0,0 +1,75 @@
+package usecase
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	mock_usecase "github.com/child6yo/wbtech-l3-delayed-notifyer/internal/usecase/mock"
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnoozeManager_Tokens(t *testing.T) {
+	t.Parallel()
+
+	manager := NewSnoozeManager(nil, []byte("secret"), "https://notify.example.com/")
+
+	t.Run("callback_data_fits_telegram_limit", func(t *testing.T) {
+		data := manager.SnoozeCallbackData(testNotificationID, maxSnooze)
+		assert.True(t, strings.HasPrefix(data, models.SnoozeCallbackPrefix))
+		assert.LessOrEqual(t, len(data), 64)
+	})
+
+	t.Run("link", func(t *testing.T) {
+		link, err := url.Parse(manager.SnoozeLink(testNotificationID, time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, "notify.example.com", link.Host)
+		assert.True(t, strings.HasPrefix(link.Path, "/snooze/"+testNotificationID+"."))
+		assert.Equal(t, "3600", link.Query().Get("for"))
+	})
+}
+
+func TestSnoozeManager_Snooze(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockNotifications := mock_usecase.NewMocknotificationSnoozer(ctrl)
+	manager := NewSnoozeManager(mockNotifications, []byte("secret"), "https://notify.example.com")
+
+	token := manager.token(testNotificationID)
+
+	t.Run("success", func(t *testing.T) {
+		mockNotifications.EXPECT().SnoozeNotification(gomock.Any(), testNotificationID, gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ string, sendAt time.Time) (string, error) {
+				assert.WithinDuration(t, time.Now().Add(time.Hour), sendAt, time.Second)
+				return "snoozed-id", nil
+			})
+
+		id, sendAt, err := manager.Snooze(context.Background(), token, time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, "snoozed-id", id)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), sendAt, time.Second)
+	})
+
+	t.Run("forged_token", func(t *testing.T) {
+		_, _, err := manager.Snooze(context.Background(), "other-id."+strings.SplitN(token, ".", 2)[1], time.Hour)
+		assert.ErrorIs(t, err, ErrInvalidSnoozeToken)
+	})
+
+	t.Run("malformed_token", func(t *testing.T) {
+		_, _, err := manager.Snooze(context.Background(), testNotificationID, time.Hour)
+		assert.ErrorIs(t, err, ErrInvalidSnoozeToken)
+	})
+
+	t.Run("duration_out_of_bounds", func(t *testing.T) {
+		_, _, err := manager.Snooze(context.Background(), token, 8*24*time.Hour)
+		assert.ErrorIs(t, err, ErrInvalidSnoozeTime)
+	})
+}
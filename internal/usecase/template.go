@@ -0,0 +1,577 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	htmltemplate "html/template"
+	"slices"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+	"text/template/parse"
+	"time"
+
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
+	"github.com/google/uuid"
+)
+
+const (
+	// templatesSetName множество айди шаблонов, упорядоченное по времени создания.
+	templatesSetName = "templates"
+
+	// deletedTemplateTTL сколько хранятся версии удаленного шаблона:
+	// на них могут ссылаться уже запланированные уведомления.
+	deletedTemplateTTL = 30*24*time.Hour + 168*time.Hour
+)
+
+var (
+	// ErrTemplateNotFound возвращается, если шаблона или его версии нет.
+	ErrTemplateNotFound = errors.New("template not found")
+
+	// ErrInvalidTemplate возвращается, если шаблон не разбирается или не заполняется переменными.
+	ErrInvalidTemplate = errors.New("invalid template")
+)
+
+type templateStorage interface {
+	Add(ctx context.Context, key string, value interface{}, exp time.Duration) error
+	Get(ctx context.Context, key string) (string, error)
+	Remove(ctx context.Context, key string) error
+	Increment(ctx context.Context, key string) (int64, error)
+	SortedSetAdd(ctx context.Context, set string, value interface{}, score float64) error
+	SortedSetRangeByScore(ctx context.Context, key, min, max string, offset, count int64) ([]string, error)
+	SortedSetRemove(ctx context.Context, set string, value interface{}) error
+}
+
+// TemplateManager хранит версионируемые шаблоны уведомлений и заполняет их на языке получателя.
+type TemplateManager struct {
+	storage templateStorage
+
+	defaultLocale   string   // язык основного варианта, если он не указан
+	localeFallbacks []string // цепочка языков, например kk -> ru -> en
+}
+
+// NewTemplateManager создает новый TemplateManager.
+func NewTemplateManager(storage templateStorage, defaultLocale string, localeFallbacks []string) *TemplateManager {
+	return &TemplateManager{
+		storage:         storage,
+		defaultLocale:   normalizeLocale(defaultLocale),
+		localeFallbacks: localeFallbacks,
+	}
+}
+
+// CreateTemplate сохраняет новый шаблон с версией 1.
+func (tm *TemplateManager) CreateTemplate(ctx context.Context, tmpl models.Template) (models.Template, error) {
+	if err := tm.prepare(&tmpl); err != nil {
+		return models.Template{}, err
+	}
+
+	tmpl.ID = uuid.NewString()
+	tmpl, err := tm.saveVersion(ctx, tmpl)
+	if err != nil {
+		return models.Template{}, err
+	}
+
+	err = tm.storage.SortedSetAdd(ctx, templatesSetName, tmpl.ID, float64(tmpl.CreatedAt.UnixMilli()))
+	if err != nil {
+		return models.Template{}, err
+	}
+
+	return tmpl, nil
+}
+
+// UpdateTemplate сохраняет новую версию существующего шаблона.
+func (tm *TemplateManager) UpdateTemplate(ctx context.Context, id string, tmpl models.Template) (models.Template, error) {
+	if _, err := tm.currentVersion(ctx, id); err != nil {
+		return models.Template{}, err
+	}
+
+	if err := tm.prepare(&tmpl); err != nil {
+		return models.Template{}, err
+	}
+
+	tmpl.ID = id
+	return tm.saveVersion(ctx, tmpl)
+}
+
+// GetTemplate возвращает версию шаблона, при version == 0 - последнюю.
+// Версии удаленного шаблона доступны, пока не истек их срок хранения.
+func (tm *TemplateManager) GetTemplate(ctx context.Context, id string, version int) (models.Template, error) {
+	if version == 0 {
+		current, err := tm.currentVersion(ctx, id)
+		if err != nil {
+			return models.Template{}, err
+		}
+		version = current
+	}
+
+	payload, err := tm.storage.Get(ctx, templateVersionKey(id, version))
+	if errors.Is(err, models.ErrNotFound) {
+		return models.Template{}, fmt.Errorf("%w: %s version %d", ErrTemplateNotFound, id, version)
+	}
+	if err != nil {
+		return models.Template{}, err
+	}
+
+	var tmpl models.Template
+	if err := json.Unmarshal([]byte(payload), &tmpl); err != nil {
+		return models.Template{}, err
+	}
+
+	return tmpl, nil
+}
+
+// RenderTemplate заполняет шаблон переменными на языке получателя.
+// Вариант выбирается по цепочке языков, при отсутствии подходящего используется основной.
+// Даты, числа и множественное число форматируются по правилам языка выбранного варианта.
+// Переменные варианта telegram экранируются по правилам разметки tgParseMode, по умолчанию Markdown.
+func (tm *TemplateManager) RenderTemplate(ctx context.Context, ref models.TemplateRef, locale string, tgParseMode models.TelegramParseMode) (models.RenderedTemplate, error) {
+	tmpl, err := tm.GetTemplate(ctx, ref.ID, ref.Version)
+	if err != nil {
+		return models.RenderedTemplate{}, err
+	}
+
+	variantLocale, variants := selectVariant(tmpl, localeChain(locale, tm.defaultLocale, tm.localeFallbacks))
+
+	rendered, err := renderVariants(variants, variantLocale, telegramTemplateParseMode(tgParseMode), ref.Variables, true)
+	if err != nil {
+		return models.RenderedTemplate{}, err
+	}
+	rendered.Version = tmpl.Version
+	rendered.Locale = variantLocale
+
+	return rendered, nil
+}
+
+// ListTemplates возвращает последние версии всех шаблонов.
+func (tm *TemplateManager) ListTemplates(ctx context.Context) ([]models.Template, error) {
+	ids, err := tm.storage.SortedSetRangeByScore(ctx, templatesSetName, "-inf", "+inf", 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	templates := make([]models.Template, 0, len(ids))
+	for _, id := range ids {
+		tmpl, err := tm.GetTemplate(ctx, id, 0)
+		if errors.Is(err, ErrTemplateNotFound) {
+			continue // удален параллельно
+		}
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, tmpl)
+	}
+
+	return templates, nil
+}
+
+// DeleteTemplate удаляет шаблон. Его версии хранятся еще deletedTemplateTTL,
+// чтобы уже запланированные уведомления могли быть отправлены.
+func (tm *TemplateManager) DeleteTemplate(ctx context.Context, id string) error {
+	current, err := tm.currentVersion(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := tm.storage.SortedSetRemove(ctx, templatesSetName, id); err != nil {
+		return err
+	}
+
+	if err := tm.storage.Remove(ctx, templateCounterKey(id)); err != nil {
+		return err
+	}
+
+	for version := 1; version <= current; version++ {
+		key := templateVersionKey(id, version)
+		payload, err := tm.storage.Get(ctx, key)
+		if errors.Is(err, models.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := tm.storage.Add(ctx, key, payload, deletedTemplateTTL); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// saveVersion сохраняет шаблон следующей версией.
+func (tm *TemplateManager) saveVersion(ctx context.Context, tmpl models.Template) (models.Template, error) {
+	version, err := tm.storage.Increment(ctx, templateCounterKey(tmpl.ID))
+	if err != nil {
+		return models.Template{}, err
+	}
+
+	tmpl.Version = int(version)
+	tmpl.CreatedAt = time.Now().UTC()
+
+	payload, err := json.Marshal(tmpl)
+	if err != nil {
+		return models.Template{}, err
+	}
+
+	if err := tm.storage.Add(ctx, templateVersionKey(tmpl.ID, tmpl.Version), payload, 0); err != nil {
+		return models.Template{}, err
+	}
+
+	return tmpl, nil
+}
+
+// currentVersion возвращает номер последней версии шаблона.
+func (tm *TemplateManager) currentVersion(ctx context.Context, id string) (int, error) {
+	value, err := tm.storage.Get(ctx, templateCounterKey(id))
+	if errors.Is(err, models.ErrNotFound) {
+		return 0, fmt.Errorf("%w: %s", ErrTemplateNotFound, id)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.Atoi(value)
+}
+
+func templateCounterKey(id string) string {
+	return "template:" + id + ":version"
+}
+
+func templateVersionKey(id string, version int) string {
+	return "template:" + id + ":v" + strconv.Itoa(version)
+}
+
+// prepare приводит языки шаблона к единому виду и проверяет, что все варианты разбираются
+// и используют одинаковый набор переменных.
+func (tm *TemplateManager) prepare(tmpl *models.Template) error {
+	tmpl.Locale = normalizeLocale(tmpl.Locale)
+	if tmpl.Locale == "" {
+		tmpl.Locale = tm.defaultLocale
+	}
+
+	baseVars, err := templateVariables(tmpl.Variants)
+	if err != nil {
+		return err
+	}
+
+	locales := make(map[string]models.TemplateVariants, len(tmpl.Locales))
+	for locale, variants := range tmpl.Locales {
+		locale = normalizeLocale(locale)
+		if locale == "" || locale == tmpl.Locale {
+			return fmt.Errorf("%w: locale %q duplicates base variant", ErrInvalidTemplate, locale)
+		}
+		if _, ok := locales[locale]; ok {
+			return fmt.Errorf("%w: duplicate locale %q", ErrInvalidTemplate, locale)
+		}
+
+		vars, err := templateVariables(variants)
+		if err != nil {
+			return fmt.Errorf("locale %s: %w", locale, err)
+		}
+		if !slices.Equal(vars, baseVars) {
+			return fmt.Errorf("%w: locale %s uses variables %v, base variant (%s) uses %v",
+				ErrInvalidTemplate, locale, vars, tmpl.Locale, baseVars)
+		}
+
+		locales[locale] = variants
+	}
+	if len(locales) > 0 {
+		tmpl.Locales = locales
+	} else {
+		tmpl.Locales = nil
+	}
+
+	return nil
+}
+
+// selectVariant выбирает первый вариант шаблона из цепочки языков, иначе основной.
+func selectVariant(tmpl models.Template, chain []string) (string, models.TemplateVariants) {
+	for _, locale := range chain {
+		if locale == tmpl.Locale {
+			break
+		}
+		if variants, ok := tmpl.Locales[locale]; ok {
+			return locale, variants
+		}
+	}
+	return tmpl.Locale, tmpl.Variants
+}
+
+// renderVariants заполняет варианты шаблона переменными.
+// Отсутствующая переменная считается ошибкой. Если execute == false, шаблоны только разбираются.
+// В вариантах email_html и telegram значения переменных экранируются.
+func renderVariants(
+	variants models.TemplateVariants, locale string, tgParseMode models.TelegramParseMode, vars map[string]any, execute bool,
+) (models.RenderedTemplate, error) {
+	var (
+		rendered models.RenderedTemplate
+		errs     []error
+		funcs    = templateFuncs(locale)
+	)
+
+	render := func(dst *string, name, text string, data any) {
+		out, err := renderText(name, text, funcs, data, execute)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%w: %s: %w", ErrInvalidTemplate, name, err))
+		}
+		*dst = out
+	}
+
+	render(&rendered.EmailSubject, "email_subject", variants.EmailSubject, vars)
+	render(&rendered.EmailText, "email_text", variants.EmailText, vars)
+	render(&rendered.Telegram, "telegram", variants.Telegram, escapeTelegramValues(vars, tgParseMode))
+	render(&rendered.SMS, "sms", variants.SMS, vars)
+
+	out, err := renderHTML("email_html", variants.EmailHTML, funcs, vars, execute)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("%w: email_html: %w", ErrInvalidTemplate, err))
+	}
+	rendered.EmailHTML = out
+
+	return rendered, errors.Join(errs...)
+}
+
+func renderText(name, text string, funcs texttemplate.FuncMap, data any, execute bool) (string, error) {
+	if text == "" {
+		return "", nil
+	}
+
+	t, err := texttemplate.New(name).Option("missingkey=error").Funcs(funcs).Parse(text)
+	if err != nil || !execute {
+		return "", err
+	}
+
+	var buf strings.Builder
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func renderHTML(name, text string, funcs texttemplate.FuncMap, data any, execute bool) (string, error) {
+	if text == "" {
+		return "", nil
+	}
+
+	t, err := htmltemplate.New(name).Option("missingkey=error").Funcs(htmltemplate.FuncMap(funcs)).Parse(text)
+	if err != nil || !execute {
+		return "", err
+	}
+
+	var buf strings.Builder
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// templateVariables разбирает варианты шаблона и возвращает отсортированные имена
+// переменных верхнего уровня, которые они используют.
+func templateVariables(variants models.TemplateVariants) ([]string, error) {
+	if _, err := renderVariants(variants, "", models.TelegramMarkdown, nil, false); err != nil {
+		return nil, err
+	}
+
+	set := make(map[string]struct{})
+	funcs := templateFuncs("")
+	for _, text := range []string{variants.EmailSubject, variants.EmailText, variants.EmailHTML, variants.Telegram, variants.SMS} {
+		if text == "" {
+			continue
+		}
+		t, err := texttemplate.New("").Funcs(funcs).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidTemplate, err)
+		}
+		collectVariables(t.Root, true, set)
+	}
+
+	vars := make([]string, 0, len(set))
+	for name := range set {
+		vars = append(vars, name)
+	}
+	slices.Sort(vars)
+	return vars, nil
+}
+
+// collectVariables обходит дерево шаблона. Внутри range и with точка указывает
+// не на переменные, поэтому там учитываются только обращения через $.
+func collectVariables(node parse.Node, rootDot bool, vars map[string]struct{}) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			collectVariables(child, rootDot, vars)
+		}
+	case *parse.ActionNode:
+		collectVariables(n.Pipe, rootDot, vars)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			collectVariables(cmd, rootDot, vars)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			collectVariables(arg, rootDot, vars)
+		}
+	case *parse.FieldNode:
+		if rootDot {
+			vars[n.Ident[0]] = struct{}{}
+		}
+	case *parse.ChainNode:
+		collectVariables(n.Node, rootDot, vars)
+	case *parse.VariableNode:
+		if n.Ident[0] == "$" && len(n.Ident) > 1 {
+			vars[n.Ident[1]] = struct{}{}
+		}
+	case *parse.IfNode:
+		collectVariables(n.Pipe, rootDot, vars)
+		collectVariables(n.List, rootDot, vars)
+		collectVariables(n.ElseList, rootDot, vars)
+	case *parse.RangeNode:
+		collectVariables(n.Pipe, rootDot, vars)
+		collectVariables(n.List, false, vars)
+		collectVariables(n.ElseList, rootDot, vars)
+	case *parse.WithNode:
+		collectVariables(n.Pipe, rootDot, vars)
+		collectVariables(n.List, false, vars)
+		collectVariables(n.ElseList, rootDot, vars)
+	case *parse.TemplateNode:
+		collectVariables(n.Pipe, rootDot, vars)
+	}
+}
+
+// telegramTemplateParseMode разметка варианта telegram шаблона: заданная в канале, по умолчанию Markdown.
+func telegramTemplateParseMode(mode models.TelegramParseMode) models.TelegramParseMode {
+	if mode == "" {
+		return models.TelegramMarkdown
+	}
+	return mode
+}
+
+// escapeTelegramValues экранирует служебные символы разметки во всех строковых значениях.
+func escapeTelegramValues(value any, mode models.TelegramParseMode) any {
+	switch v := value.(type) {
+	case string:
+		return escapeTelegramText(v, mode)
+	case map[string]any:
+		escaped := make(map[string]any, len(v))
+		for k, item := range v {
+			escaped[k] = escapeTelegramValues(item, mode)
+		}
+		return escaped
+	case []any:
+		escaped := make([]any, len(v))
+		for i, item := range v {
+			escaped[i] = escapeTelegramValues(item, mode)
+		}
+		return escaped
+	default:
+		return v
+	}
+}
+
+// escapeTelegramText экранирует текст, чтобы он выводился как есть в указанной разметке.
+func escapeTelegramText(text string, mode models.TelegramParseMode) string {
+	switch mode {
+	case models.TelegramMarkdown:
+		return telegramMarkdownEscaper.Replace(text)
+	case models.TelegramMarkdownV2:
+		return telegramMarkdownV2Escaper.Replace(text)
+	case models.TelegramHTML:
+		return html.EscapeString(text)
+	default:
+		return text
+	}
+}
+
+var telegramMarkdownEscaper = strings.NewReplacer(
+	"_", `\_`,
+	"*", `\*`,
+	"`", "\\`",
+	"[", `\[`,
+)
+
+var telegramMarkdownV2Escaper = func() *strings.Replacer {
+	const special = "\\_*[]()~`>#+-=|{}.!"
+	pairs := make([]string, 0, 2*len(special))
+	for _, r := range special {
+		pairs = append(pairs, string(r), `\`+string(r))
+	}
+	return strings.NewReplacer(pairs...)
+}()
+
+// notificationContent тексты уведомления для каждого канала.
+type notificationContent struct {
+	emailSubject string
+	emailText    string
+	emailHTML    string
+
+	telegram          string
+	telegramParseMode models.TelegramParseMode
+
+	webhook string
+}
+
+// newNotificationContent выбирает тексты каналов: из заполненного шаблона, если он есть,
+// иначе текст уведомления. Каналы без своего варианта шаблона получают вариант sms.
+// Явно заданные в канале тема и HTML письма важнее шаблона.
+func newNotificationContent(notification models.DelayedNotification, rendered *models.RenderedTemplate) notificationContent {
+	email := notification.Channels.EmailChannel
+
+	content := notificationContent{
+		emailText:         string(notification.Notification),
+		telegram:          string(notification.Notification),
+		telegramParseMode: notification.Channels.TelegramChannel.ParseMode,
+		webhook:           string(notification.Notification),
+	}
+	if rendered != nil {
+		content.emailSubject = rendered.EmailSubject
+		content.emailText = firstNonEmpty(rendered.EmailText, rendered.SMS)
+		content.emailHTML = rendered.EmailHTML
+		content.telegram = rendered.SMS
+		content.telegramParseMode = ""
+		if rendered.Telegram != "" {
+			content.telegram = rendered.Telegram
+			content.telegramParseMode = telegramTemplateParseMode(notification.Channels.TelegramChannel.ParseMode)
+		}
+		content.webhook = firstNonEmpty(rendered.SMS, rendered.EmailText)
+	}
+
+	if email.Subject != "" {
+		content.emailSubject = email.Subject
+	}
+	if email.HTML != "" {
+		content.emailHTML = email.HTML
+	}
+
+	return content
+}
+
+// validate проверяет, что для каждого канала уведомления есть текст.
+func (c notificationContent) validate(channels models.Channels) error {
+	if channels.EmailChannel.Email != "" && c.emailText == "" && c.emailHTML == "" {
+		return fmt.Errorf("%w: no text for email channel", ErrInvalidTemplate)
+	}
+	if channels.TelegramChannel.ChatID != "" && c.telegram == "" {
+		return fmt.Errorf("%w: no text for telegram channel", ErrInvalidTemplate)
+	}
+	if channels.WebhookChannel.URL != "" && c.webhook == "" {
+		return fmt.Errorf("%w: no text for webhook channel", ErrInvalidTemplate)
+	}
+	return nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,275 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	mock_usecase "github.com/child6yo/wbtech-l3-delayed-notifyer/internal/usecase/mock"
+	"github.com/child6yo/wbtech-l3-delayed-notifyer/pkg/models"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplateManager_CreateTemplate(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := mock_usecase.NewMocktemplateStorage(ctrl)
+	manager := NewTemplateManager(mockStorage, "ru", []string{"kk", "ru", "en"})
+
+	variants := models.TemplateVariants{EmailText: "Привет, {{.name}}!"}
+
+	t.Run("success", func(t *testing.T) {
+		mockStorage.EXPECT().Increment(gomock.Any(), gomock.Any()).Return(int64(1), nil)
+		mockStorage.EXPECT().Add(gomock.Any(), gomock.Any(), gomock.Any(), time.Duration(0)).Return(nil)
+		mockStorage.EXPECT().SortedSetAdd(gomock.Any(), "templates", gomock.Any(), gomock.Any()).Return(nil)
+
+		tmpl, err := manager.CreateTemplate(context.Background(), models.Template{Name: "greeting", Variants: variants})
+		require.NoError(t, err)
+		assert.NotEmpty(t, tmpl.ID)
+		assert.Equal(t, 1, tmpl.Version)
+		assert.Equal(t, "ru", tmpl.Locale)
+	})
+
+	t.Run("invalid_syntax", func(t *testing.T) {
+		_, err := manager.CreateTemplate(context.Background(), models.Template{
+			Name:     "broken",
+			Variants: models.TemplateVariants{Telegram: "{{.name"},
+		})
+		assert.ErrorIs(t, err, ErrInvalidTemplate)
+	})
+
+	t.Run("locale_variables_differ", func(t *testing.T) {
+		_, err := manager.CreateTemplate(context.Background(), models.Template{
+			Name:     "greeting",
+			Variants: variants,
+			Locales: map[string]models.TemplateVariants{
+				"en": {EmailText: "Hello, {{.first_name}}!"},
+			},
+		})
+		assert.ErrorIs(t, err, ErrInvalidTemplate)
+	})
+}
+
+func TestTemplateManager_UpdateTemplate(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := mock_usecase.NewMocktemplateStorage(ctrl)
+	manager := NewTemplateManager(mockStorage, "ru", []string{"kk", "ru", "en"})
+
+	t.Run("new_version", func(t *testing.T) {
+		mockStorage.EXPECT().Get(gomock.Any(), "template:tmpl-1:version").Return("2", nil)
+		mockStorage.EXPECT().Increment(gomock.Any(), "template:tmpl-1:version").Return(int64(3), nil)
+		mockStorage.EXPECT().Add(gomock.Any(), "template:tmpl-1:v3", gomock.Any(), time.Duration(0)).Return(nil)
+
+		tmpl, err := manager.UpdateTemplate(context.Background(), "tmpl-1", models.Template{Name: "greeting", Variants: models.TemplateVariants{SMS: "hi"}})
+		require.NoError(t, err)
+		assert.Equal(t, 3, tmpl.Version)
+	})
+
+	t.Run("not_found", func(t *testing.T) {
+		mockStorage.EXPECT().Get(gomock.Any(), "template:missing:version").Return("", models.ErrNotFound)
+
+		_, err := manager.UpdateTemplate(context.Background(), "missing", models.Template{Name: "greeting", Variants: models.TemplateVariants{SMS: "hi"}})
+		assert.ErrorIs(t, err, ErrTemplateNotFound)
+	})
+}
+
+func TestTemplateManager_GetTemplate(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := mock_usecase.NewMocktemplateStorage(ctrl)
+	manager := NewTemplateManager(mockStorage, "ru", []string{"kk", "ru", "en"})
+
+	payload, err := json.Marshal(models.Template{ID: "tmpl-1", Version: 2, Name: "greeting"})
+	require.NoError(t, err)
+
+	t.Run("latest", func(t *testing.T) {
+		mockStorage.EXPECT().Get(gomock.Any(), "template:tmpl-1:version").Return("2", nil)
+		mockStorage.EXPECT().Get(gomock.Any(), "template:tmpl-1:v2").Return(string(payload), nil)
+
+		tmpl, err := manager.GetTemplate(context.Background(), "tmpl-1", 0)
+		require.NoError(t, err)
+		assert.Equal(t, 2, tmpl.Version)
+	})
+
+	t.Run("pinned_version_missing", func(t *testing.T) {
+		mockStorage.EXPECT().Get(gomock.Any(), "template:tmpl-1:v7").Return("", models.ErrNotFound)
+
+		_, err := manager.GetTemplate(context.Background(), "tmpl-1", 7)
+		assert.ErrorIs(t, err, ErrTemplateNotFound)
+	})
+}
+
+func TestTemplateManager_DeleteTemplate(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := mock_usecase.NewMocktemplateStorage(ctrl)
+	manager := NewTemplateManager(mockStorage, "ru", []string{"kk", "ru", "en"})
+
+	mockStorage.EXPECT().Get(gomock.Any(), "template:tmpl-1:version").Return("2", nil)
+	mockStorage.EXPECT().SortedSetRemove(gomock.Any(), "templates", "tmpl-1").Return(nil)
+	mockStorage.EXPECT().Remove(gomock.Any(), "template:tmpl-1:version").Return(nil)
+
+	// версии остаются для запланированных уведомлений, но получают срок хранения
+	mockStorage.EXPECT().Get(gomock.Any(), "template:tmpl-1:v1").Return("v1", nil)
+	mockStorage.EXPECT().Add(gomock.Any(), "template:tmpl-1:v1", "v1", deletedTemplateTTL).Return(nil)
+	mockStorage.EXPECT().Get(gomock.Any(), "template:tmpl-1:v2").Return("v2", nil)
+	mockStorage.EXPECT().Add(gomock.Any(), "template:tmpl-1:v2", "v2", deletedTemplateTTL).Return(nil)
+
+	require.NoError(t, manager.DeleteTemplate(context.Background(), "tmpl-1"))
+}
+
+func TestTemplateManager_RenderTemplate(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := mock_usecase.NewMocktemplateStorage(ctrl)
+	manager := NewTemplateManager(mockStorage, "ru", []string{"kk", "ru", "en"})
+
+	payload, err := json.Marshal(models.Template{
+		ID:       "tmpl-1",
+		Version:  1,
+		Locale:   "ru",
+		Variants: models.TemplateVariants{SMS: "У вас {{.count}} {{plural .count \"заказ\" \"заказа\" \"заказов\"}} на {{number .total}} ₸"},
+		Locales: map[string]models.TemplateVariants{
+			"en": {SMS: "You have {{.count}} {{plural .count \"order\" \"orders\"}} for {{number .total}} ₸"},
+		},
+	})
+	require.NoError(t, err)
+	mockStorage.EXPECT().Get(gomock.Any(), "template:tmpl-1:v1").Return(string(payload), nil).AnyTimes()
+
+	ref := models.TemplateRef{ID: "tmpl-1", Version: 1, Variables: map[string]any{"count": float64(3), "total": 12500.5}}
+
+	tests := []struct {
+		locale     string
+		wantLocale string
+		wantSMS    string
+	}{
+		{locale: "kk-KZ", wantLocale: "ru", wantSMS: "У вас 3 заказа на 12\u00a0500,5 ₸"},
+		{locale: "en-US", wantLocale: "en", wantSMS: "You have 3 orders for 12,500.5 ₸"},
+		{locale: "", wantLocale: "ru", wantSMS: "У вас 3 заказа на 12\u00a0500,5 ₸"},
+	}
+
+	for _, tt := range tests {
+		rendered, err := manager.RenderTemplate(context.Background(), ref, tt.locale, "")
+		require.NoError(t, err)
+		assert.Equal(t, tt.wantLocale, rendered.Locale, tt.locale)
+		assert.Equal(t, tt.wantSMS, rendered.SMS, tt.locale)
+		assert.Equal(t, 1, rendered.Version)
+	}
+}
+
+func TestRenderVariants(t *testing.T) {
+	t.Parallel()
+
+	variants := models.TemplateVariants{
+		EmailSubject: "Заказ {{.order}}",
+		EmailHTML:    "<p>{{.name}}</p>",
+		Telegram:     "*Заказ* {{.order}} для {{.name}}",
+		SMS:          "Заказ {{.order}} готов",
+	}
+	vars := map[string]any{"order": "A_1", "name": "<Иван>"}
+
+	t.Run("escapes_per_channel", func(t *testing.T) {
+		rendered, err := renderVariants(variants, "ru", models.TelegramMarkdown, vars, true)
+		require.NoError(t, err)
+		assert.Equal(t, "Заказ A_1", rendered.EmailSubject)
+		assert.Equal(t, "<p>&lt;Иван&gt;</p>", rendered.EmailHTML)
+		assert.Equal(t, `*Заказ* A\_1 для <Иван>`, rendered.Telegram)
+		assert.Equal(t, "Заказ A_1 готов", rendered.SMS)
+	})
+
+	t.Run("missing_variable", func(t *testing.T) {
+		_, err := renderVariants(variants, "ru", models.TelegramMarkdown, map[string]any{"order": "A_1"}, true)
+		assert.ErrorIs(t, err, ErrInvalidTemplate)
+	})
+}
+
+func TestTemplateVariables(t *testing.T) {
+	t.Parallel()
+
+	vars, err := templateVariables(models.TemplateVariants{
+		EmailSubject: "{{.title}}",
+		EmailText:    "{{range .items}}{{.name}} {{$.currency}}{{end}}{{if .note}}{{.note}}{{end}}",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"currency", "items", "note", "title"}, vars)
+}
+
+func TestNewNotificationContent(t *testing.T) {
+	t.Parallel()
+
+	notification := models.DelayedNotification{
+		Channels: models.Channels{
+			EmailChannel:    models.EmailChannel{Email: "user@example.com", Subject: "Своя тема"},
+			TelegramChannel: models.TelegramChannel{ChatID: "1"},
+		},
+	}
+
+	t.Run("sms_fallback", func(t *testing.T) {
+		content := newNotificationContent(notification, &models.RenderedTemplate{EmailSubject: "Тема", SMS: "текст"})
+		require.NoError(t, content.validate(notification.Channels))
+		assert.Equal(t, "Своя тема", content.emailSubject)
+		assert.Equal(t, "текст", content.emailText)
+		assert.Equal(t, "текст", content.telegram)
+		assert.Empty(t, content.telegramParseMode)
+	})
+
+	t.Run("telegram_markdown", func(t *testing.T) {
+		content := newNotificationContent(notification, &models.RenderedTemplate{EmailText: "текст", Telegram: "*текст*"})
+		assert.Equal(t, "*текст*", content.telegram)
+		assert.Equal(t, models.TelegramMarkdown, content.telegramParseMode)
+	})
+
+	t.Run("no_text_for_channel", func(t *testing.T) {
+		content := newNotificationContent(notification, &models.RenderedTemplate{EmailText: "текст"})
+		assert.ErrorIs(t, content.validate(notification.Channels), ErrInvalidTemplate)
+	})
+}
+
+func TestEscapeTelegramText(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		mode models.TelegramParseMode
+		text string
+		want string
+	}{
+		{mode: models.TelegramMarkdown, text: "A_1 *x* [y]", want: `A\_1 \*x\* \[y]`},
+		{mode: models.TelegramMarkdownV2, text: "A_1 (v1.2)!", want: `A\_1 \(v1\.2\)\!`},
+		{mode: models.TelegramHTML, text: "<Иван> & Co", want: "&lt;Иван&gt; &amp; Co"},
+		{mode: "", text: "A_1 <b>", want: "A_1 <b>"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, escapeTelegramText(tt.text, tt.mode), tt.mode)
+	}
+}
+
+func TestRenderVariants_TelegramParseMode(t *testing.T) {
+	t.Parallel()
+
+	variants := models.TemplateVariants{Telegram: "<b>Заказ</b> {{.order}} для {{.name}}"}
+	vars := map[string]any{"order": "A_1", "name": "<Иван>"}
+
+	rendered, err := renderVariants(variants, "ru", models.TelegramHTML, vars, true)
+	require.NoError(t, err)
+	assert.Equal(t, "<b>Заказ</b> A_1 для &lt;Иван&gt;", rendered.Telegram)
+}
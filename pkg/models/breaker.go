@@ -0,0 +1,22 @@
+package models
+
+// BreakerState состояние автомата отключения отправщика канала.
+type BreakerState string
+
+const (
+	// BreakerClosed - отправка идет как обычно.
+	BreakerClosed BreakerState = "closed"
+
+	// BreakerOpen - отправщик отказывает подряд, отправка по каналу откладывается.
+	BreakerOpen BreakerState = "open"
+
+	// BreakerHalfOpen - пробная отправка проверяет, восстановился ли отправщик.
+	BreakerHalfOpen BreakerState = "half_open"
+)
+
+// BreakerStats состояние автомата отключения канала для проверок состояния и метрик.
+type BreakerStats struct {
+	State               BreakerState `json:"state"`
+	ConsecutiveFailures int          `json:"consecutive_failures"`
+	Opens               int64        `json:"opens"` // сколько раз автомат размыкался с запуска
+}
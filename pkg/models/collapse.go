@@ -0,0 +1,30 @@
+package models
+
+// CollapsePolicy что делать с новым уведомлением, если уведомление с тем же ключом схлопывания еще запланировано.
+type CollapsePolicy string
+
+const (
+	// CollapseReplace - отменить запланированное уведомление и запланировать новое.
+	CollapseReplace CollapsePolicy = "replace"
+
+	// CollapseIgnore - оставить запланированное уведомление, а новое не создавать.
+	CollapseIgnore CollapsePolicy = "ignore"
+)
+
+// Collapse ключ схлопывания повторно запланированных "одинаковых" уведомлений.
+type Collapse struct {
+	Key    string         `json:"key"`
+	Policy CollapsePolicy `json:"policy"`
+}
+
+// CollapseIndexKey возвращает ключ индекса, по которому находится запланированное уведомление
+// с тем же ключом схлопывания, или пустую строку. У каждого получателя из реестра свой индекс.
+func (n DelayedNotification) CollapseIndexKey() string {
+	if n.Collapse == nil {
+		return ""
+	}
+	if n.RecipientID != "" {
+		return "notification.collapse:" + n.RecipientID + ":" + n.Collapse.Key
+	}
+	return "notification.collapse:" + n.Collapse.Key
+}
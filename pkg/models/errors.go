@@ -0,0 +1,39 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNotFound возвращается хранилищем, если значения по ключу нет.
+var ErrNotFound = errors.New("not found")
+
+// RetryAfterError возвращается отправщиком, если провайдер перегружен и просит повторить отправку
+// не раньше, чем через After.
+type RetryAfterError struct {
+	After time.Duration
+	Err   error
+}
+
+func (e *RetryAfterError) Error() string {
+	return fmt.Sprintf("retry after %s: %v", e.After, e.Err)
+}
+
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
+
+// RecipientError возвращается отправкой, которую не принял получатель или для которой не собралось содержимое:
+// адреса нет, бот заблокирован в чате, вложение не скачалось. Сам отправщик канала при этом исправен.
+type RecipientError struct {
+	Err error
+}
+
+func (e *RecipientError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RecipientError) Unwrap() error {
+	return e.Err
+}
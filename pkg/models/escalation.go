@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// EscalationPolicy именованная цепочка эскалации: шаги с каналами и получателями,
+// которые по очереди оповещаются, пока кто-нибудь не подтвердит получение уведомления.
+type EscalationPolicy struct {
+	ID        string           `json:"id"`
+	Name      string           `json:"name"`
+	Steps     []EscalationStep `json:"steps"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+}
+
+// EscalationStep шаг эскалации.
+type EscalationStep struct {
+	DelaySeconds int64    `json:"delay_seconds" binding:"min=0,max=2592000"` // от отправки первого шага
+	Channels     Channels `json:"channels"`
+}
+
+// Escalation цепочка эскалации, по которой идет уведомление.
+// Шаги копируются из политики при создании уведомления, чтобы ее изменения не влияли на уже запланированные.
+type Escalation struct {
+	PolicyID  string           `json:"policy_id"`
+	Steps     []EscalationStep `json:"steps"`
+	Step      int              `json:"step,omitempty"` // номер текущего шага, начиная с 0
+	StartedAt time.Time        `json:"started_at"`     // время отправки первого шага
+}
@@ -16,33 +16,268 @@ const (
 	// StatusSending - уведомление отправляется.
 	StatusSending NotificationStatus = "sending"
 
+	// StatusRetrying - отправка не удалась, уведомление ждет повторной попытки в отложенной очереди.
+	StatusRetrying NotificationStatus = "retrying"
+
+	// StatusInterrupted - отправка прервана остановкой сервиса, уведомление будет отправлено повторно.
+	StatusInterrupted NotificationStatus = "interrupted"
+
+	// StatusAwaitingAck - уведомление отправлено, но не подтверждено получателем, запланировано повторное напоминание.
+	StatusAwaitingAck NotificationStatus = "awaiting_ack"
+
 	// StatusSent - уведомление отправлено.
 	StatusSent NotificationStatus = "sent"
 
 	// StatusFailed - ошибка отправки уведомления.
 	StatusFailed NotificationStatus = "failed"
+
+	// StatusSuppressed - уведомление не отправлено: получатель отказался от всех его каналов или от его категории.
+	StatusSuppressed NotificationStatus = "suppressed"
+
+	// StatusDeferred - уведомление пришлось на тихие часы или превысило лимит отправки получателю
+	// и отложено до конца тихих часов или пополнения лимита.
+	StatusDeferred NotificationStatus = "deferred"
+
+	// StatusDropped - уведомление пришлось на тихие часы и отброшено или сводка осталась пустой,
+	// потому что все собранные в нее уведомления отменены.
+	StatusDropped NotificationStatus = "dropped"
+
+	// StatusSentInDigest - уведомление отправлено в составе сводки.
+	StatusSentInDigest NotificationStatus = "sent_in_digest"
+
+	// StatusRejected - уведомление не отправлено: превышен лимит отправки получателю по всем его каналам.
+	StatusRejected NotificationStatus = "rejected"
 )
 
+// StatusChange запись истории статусов уведомления.
+type StatusChange struct {
+	Status NotificationStatus `json:"status"`
+	At     time.Time          `json:"at"`
+	Note   string             `json:"note,omitempty"` // пояснение, например до какого времени отложено уведомление
+}
+
 // TelegramChannel канал отправки через телеграм.
 type TelegramChannel struct {
 	ChatID string `json:"chat_id"`
+
+	// ParseMode разметка текста уведомления. Значения переменных шаблона экранируются по ее правилам,
+	// для шаблона по умолчанию используется Markdown.
+	ParseMode       TelegramParseMode  `json:"parse_mode,omitempty" binding:"omitempty,oneof=Markdown MarkdownV2 HTML"`
+	Buttons         [][]TelegramButton `json:"buttons,omitempty" binding:"max=10,dive,min=1,max=8,dive"` // inline клавиатура по рядам
+	Silent          bool               `json:"silent,omitempty"`                                         // без звука уведомления
+	PhotoURL        string             `json:"photo_url,omitempty" binding:"omitempty,url,excluded_with=DocumentURL"`
+	DocumentURL     string             `json:"document_url,omitempty" binding:"omitempty,url"`
+	MessageThreadID int                `json:"message_thread_id,omitempty" binding:"min=0"` // тема форума
+}
+
+// TelegramButton кнопка inline клавиатуры: ссылка или callback.
+type TelegramButton struct {
+	Text         string `json:"text" binding:"required,max=64"`
+	URL          string `json:"url,omitempty" binding:"omitempty,url,excluded_with=CallbackData"`
+	CallbackData string `json:"callback_data,omitempty" binding:"required_without=URL"`
 }
 
 // EmailChannel канал отправки через email.
 type EmailChannel struct {
-	Email string `json:"email"`
+	Email       string       `json:"email"`
+	Subject     string       `json:"subject,omitempty" binding:"max=255"` // тема письма, по умолчанию из конфига
+	HTML        string       `json:"html,omitempty" binding:"max=100000"` // HTML версия, по умолчанию строится из текста
+	Attachments []Attachment `json:"attachments,omitempty" binding:"max=10,dive"`
+}
+
+// Attachment вложение письма.
+// Содержимое передается либо в запросе (content в base64), либо ссылкой url, загружаемой при отправке.
+// Переданное в запросе содержимое хранится отдельно от уведомления, в нем остается только StorageKey.
+type Attachment struct {
+	Filename    string `json:"filename" binding:"required,max=255"`
+	ContentType string `json:"content_type,omitempty" binding:"max=255"`
+	ContentID   string `json:"content_id,omitempty" binding:"omitempty,max=255,printascii,excludesall=<>()0x2C;:\\\"[] "` // для inline изображений: <img src="cid:...">
+	URL         string `json:"url,omitempty" binding:"omitempty,url,excluded_with=Data"`
+	Data        []byte `json:"content,omitempty" binding:"required_without=URL"`
+	StorageKey  string `json:"storage_key,omitempty" binding:"isdefault"` // заполняется сервисом
+}
+
+// EventMethod метод iTIP, с которым приглашение в календарь отправляется участнику.
+type EventMethod string
+
+const (
+	// EventRequest - приглашение на событие или его обновление.
+	EventRequest EventMethod = "REQUEST"
+
+	// EventCancel - отмена события.
+	EventCancel EventMethod = "CANCEL"
+)
+
+// Event событие, напоминание о котором отправляется уведомлением.
+// К письму прикладывается приглашение в календарь в формате iCalendar.
+type Event struct {
+	UID       string    `json:"uid,omitempty" binding:"isdefault"`   // заполняется сервисом
+	Summary   string    `json:"summary,omitempty" binding:"max=255"` // по умолчанию тема письма
+	Start     time.Time `json:"start" binding:"required"`
+	End       time.Time `json:"end" binding:"required,gtfield=Start"`
+	Location  string    `json:"location,omitempty" binding:"max=255"`
+	Organizer string    `json:"organizer,omitempty" binding:"omitempty,email"` // по умолчанию адрес отправителя писем
+
+	Method   EventMethod `json:"method,omitempty" binding:"isdefault"`   // заполняется сервисом
+	Sequence int         `json:"sequence,omitempty" binding:"isdefault"` // номер версии события, растет при отмене
+}
+
+// WebhookChannel канал отправки POST запросом с JSON на адрес, например в чат команды.
+type WebhookChannel struct {
+	URL string `json:"url" binding:"omitempty,url"`
 }
 
+// ChannelName название канала отправки.
+type ChannelName string
+
+const (
+	// ChannelTelegram - канал телеграм.
+	ChannelTelegram ChannelName = "telegram"
+
+	// ChannelEmail - канал email.
+	ChannelEmail ChannelName = "email"
+
+	// ChannelWebhook - канал вебхука.
+	ChannelWebhook ChannelName = "webhook"
+)
+
 // Channels определяет возможные каналы отправки уведомления.
 type Channels struct {
 	TelegramChannel TelegramChannel `json:"tg_channel,omitempty"`
 	EmailChannel    EmailChannel    `json:"email_channel,omitempty"`
+	WebhookChannel  WebhookChannel  `json:"webhook_channel,omitempty"`
+}
+
+// Any сообщает, указан ли хотя бы один канал или его настройки.
+func (c Channels) Any() bool {
+	return c.EmailChannel.Email != "" || len(c.EmailChannel.Attachments) > 0 ||
+		c.TelegramChannel.ChatID != "" || c.WebhookChannel.URL != ""
 }
 
 // DelayedNotification определяет модель отложенного уведомления.
 type DelayedNotification struct {
-	ID           string        `json:"id"`
-	Notification Notification  `json:"notification"`
-	Delay        time.Duration `json:"delay"`
-	Channels     Channels      `json:"channels"`
+	ID               string        `json:"id"`
+	Notification     Notification  `json:"notification"`
+	Delay            time.Duration `json:"delay"`
+	Channels         Channels      `json:"channels"`
+	Attempt          int           `json:"attempt,omitempty"` // номер попытки отправки, начиная с 0
+	Event            *Event        `json:"event,omitempty"`
+	Template         *TemplateRef  `json:"template,omitempty"` // если задан, текст рендерится из шаблона при отправке
+	Locale           string        `json:"locale,omitempty"`   // язык получателя, например "kk" или "ru-RU"
+	Ack              *AckPolicy    `json:"ack,omitempty"`      // если задано, уведомление повторяется до подтверждения получения
+	Escalation       *Escalation   `json:"escalation,omitempty"`
+	Delivery         *Delivery     `json:"delivery,omitempty"`           // по умолчанию отправка по всем каналам сразу
+	Delivered        []ChannelName `json:"delivered,omitempty"`          // каналы, доставленные в предыдущих попытках
+	RecipientID      string        `json:"recipient_id,omitempty"`       // получатель из реестра, адреса которого подставляются в каналы при отправке
+	Category         string        `json:"category,omitempty"`           // категория, от которой получатель может отписаться
+	QuietHours       *QuietHours   `json:"quiet_hours,omitempty"`        // вместо тихих часов получателя из реестра
+	BypassQuietHours bool          `json:"bypass_quiet_hours,omitempty"` // срочное уведомление отправляется и в тихие часы
+	Digest           *DigestPolicy `json:"digest,omitempty"`             // уведомление отправляется в составе сводки
+	Collapse         *Collapse     `json:"collapse,omitempty"`           // повторное уведомление с тем же ключом заменяет это или отбрасывается
+
+	SnoozeOptions []time.Duration `json:"snooze_options,omitempty"` // варианты "отложить" кнопками в телеграм и ссылками в письме
+	SnoozedFrom   string          `json:"snoozed_from,omitempty"`   // айди уведомления, отложенного этим
+}
+
+// DigestPolicy собирает уведомления получателя из реестра с одним ключом, срок которых попал в одно окно,
+// в одну сводку, отправляемую в конце окна. У самой сводки та же политика.
+type DigestPolicy struct {
+	Key    string        `json:"key"`
+	Window time.Duration `json:"window"`
+}
+
+// DeliveryMode режим выбора каналов, по которым доставляется уведомление.
+type DeliveryMode string
+
+const (
+	// DeliveryAll - отправка по всем каналам параллельно.
+	DeliveryAll DeliveryMode = "all"
+
+	// DeliveryFirstSuccess - отправка по каналам по очереди до первой успешной доставки.
+	DeliveryFirstSuccess DeliveryMode = "first_success"
+
+	// DeliveryAnyN - отправка по каналам по очереди, пока не доставлено N каналов.
+	DeliveryAnyN DeliveryMode = "any_n"
+)
+
+// Delivery порядок доставки уведомления по каналам.
+type Delivery struct {
+	Mode  DeliveryMode  `json:"mode"`
+	Order []ChannelName `json:"order,omitempty"` // очередность каналов для first_success и any_n
+	N     int           `json:"n,omitempty"`     // сколько каналов нужно доставить, для first_success - 1
+}
+
+// AckPolicy правила повторной отправки уведомления, требующего подтверждения получения.
+type AckPolicy struct {
+	RepeatInterval time.Duration `json:"repeat_interval"`
+	MaxRepeats     int           `json:"max_repeats"`
+	Repeat         int           `json:"repeat,omitempty"` // номер повтора, начиная с 0
+	Channels       Channels      `json:"channels"`         // все каналы уведомления, по которым повторяется напоминание
+}
+
+// AckCallbackPrefix префикс callback_data кнопки подтверждения получения в телеграм.
+const AckCallbackPrefix = "ack:"
+
+// SnoozeCallbackPrefix префикс callback_data кнопки "отложить" в телеграм.
+const SnoozeCallbackPrefix = "sz:"
+
+// Acknowledgement подтверждение получения уведомления.
+type Acknowledgement struct {
+	At time.Time `json:"acknowledged_at"`
+	By string    `json:"acknowledged_by"` // адрес email или пользователь телеграм, например "telegram:12345 (@user)"
+}
+
+// TelegramParseMode режим разметки сообщения телеграм.
+type TelegramParseMode string
+
+const (
+	// TelegramMarkdown - устаревшая разметка Markdown.
+	TelegramMarkdown TelegramParseMode = "Markdown"
+
+	// TelegramMarkdownV2 - разметка MarkdownV2.
+	TelegramMarkdownV2 TelegramParseMode = "MarkdownV2"
+
+	// TelegramHTML - разметка HTML.
+	TelegramHTML TelegramParseMode = "HTML"
+)
+
+// TelegramMessage сообщение, передаваемое отправщику телеграм.
+// Если задано фото или документ, текст отправляется подписью к нему.
+type TelegramMessage struct {
+	ChatID          string
+	Text            string
+	ParseMode       TelegramParseMode // пусто для простого текста
+	Buttons         [][]TelegramButton
+	Silent          bool
+	PhotoURL        string
+	DocumentURL     string
+	MessageThreadID int
+}
+
+// WebhookMessage сообщение, передаваемое отправщику вебхуков.
+type WebhookMessage struct {
+	URL            string `json:"-"`
+	NotificationID string `json:"id"`
+	Text           string `json:"notification"`
+	AckURL         string `json:"ack_url,omitempty"` // ссылка подтверждения получения, если оно требуется
+}
+
+// EmailMessage письмо, передаваемое отправщику email.
+type EmailMessage struct {
+	To          string
+	Subject     string
+	Text        string
+	HTML        string
+	Attachments []Attachment // с загруженным содержимым
+	Event       *Event       // если задано, к письму прикладывается приглашение в календарь
+	Unsubscribe string       // ссылка отписки для заголовка List-Unsubscribe, если задана
+}
+
+// ChatNotification уведомление, ожидающее отправки в чат телеграм.
+type ChatNotification struct {
+	ID         string
+	Text       string
+	TemplateID string // вместо текста, если уведомление по шаблону
+	SendAt     time.Time
+	Status     NotificationStatus
 }
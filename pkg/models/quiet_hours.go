@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// QuietHoursAction что делать с уведомлением, которое пришлось на тихие часы.
+type QuietHoursAction string
+
+const (
+	// QuietHoursDefer - отложить уведомление до конца тихих часов.
+	QuietHoursDefer QuietHoursAction = "defer"
+
+	// QuietHoursDrop - отбросить уведомление.
+	QuietHoursDrop QuietHoursAction = "drop"
+)
+
+// QuietHours тихие часы: ежедневное окно в часовом поясе получателя, когда уведомления не отправляются.
+// Окно может переходить через полночь, например 22:00–08:00.
+type QuietHours struct {
+	Start    string           `json:"start" binding:"required,datetime=15:04"`
+	End      string           `json:"end" binding:"required,datetime=15:04,nefield=Start"`
+	Timezone string           `json:"timezone,omitempty" binding:"omitempty,timezone"` // IANA, по умолчанию UTC
+	Action   QuietHoursAction `json:"action,omitempty" binding:"omitempty,oneof=defer drop"`
+}
+
+// Until возвращает конец тихих часов, если момент t в них попадает, иначе false.
+func (q QuietHours) Until(t time.Time) (time.Time, bool) {
+	start, errStart := time.Parse("15:04", q.Start)
+	end, errEnd := time.Parse("15:04", q.End)
+	if errStart != nil || errEnd != nil {
+		return time.Time{}, false
+	}
+
+	loc, err := time.LoadLocation(q.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	local := t.In(loc)
+	at := func(day int, clock time.Time) time.Time {
+		return time.Date(local.Year(), local.Month(), local.Day()+day, clock.Hour(), clock.Minute(), 0, 0, loc)
+	}
+
+	startToday, endToday := at(0, start), at(0, end)
+	switch {
+	case startToday.Before(endToday):
+		if !local.Before(startToday) && local.Before(endToday) {
+			return endToday, true
+		}
+	case !local.Before(startToday):
+		return at(1, end), true // окно через полночь, вечерняя часть
+	case local.Before(endToday):
+		return endToday, true // окно через полночь, утренняя часть
+	}
+
+	return time.Time{}, false
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// RateLimitAction что делать с отправкой по каналу, если превышен лимит отправки получателю.
+type RateLimitAction string
+
+const (
+	// RateLimitDefer - отложить отправку по каналу до пополнения лимита.
+	RateLimitDefer RateLimitAction = "defer"
+
+	// RateLimitReject - отказаться от отправки по каналу.
+	RateLimitReject RateLimitAction = "reject"
+)
+
+// RateLimit лимит отправки по каналу одному получателю: не больше Limit сообщений за Window.
+type RateLimit struct {
+	Limit  int
+	Window time.Duration
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// Recipient получатель уведомлений из реестра: адреса в каналах под айди пользователя вызывающей системы.
+// Уведомления для получателя хранят только его айди, а адреса подставляются при отправке.
+type Recipient struct {
+	UserID         string      `json:"user_id"`
+	Email          string      `json:"email,omitempty"`
+	TelegramChatID string      `json:"telegram_chat_id,omitempty"`
+	Phone          string      `json:"phone,omitempty"`       // пока только хранится, канала SMS нет
+	PushTokens     []string    `json:"push_tokens,omitempty"` // пока только хранятся, канала push нет
+	QuietHours     *QuietHours `json:"quiet_hours,omitempty"`
+	CreatedAt      time.Time   `json:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+}
+
+// Preferences настройки получателя: каналы и категории уведомлений, от которых он отказался.
+// Уведомления по таким каналам и категориям не отправляются, а каналы получают статус suppressed.
+type Preferences struct {
+	OptedOutChannels   []ChannelName `json:"opted_out_channels" binding:"max=3,unique,dive,oneof=email telegram webhook"`
+	OptedOutCategories []string      `json:"opted_out_categories" binding:"max=100,unique,dive,required,max=64"`
+}
+
+// OptedOut сообщает, отказался ли получатель от уведомления категории category по каналу name.
+func (p Preferences) OptedOut(name ChannelName, category string) bool {
+	if category != "" && slices.Contains(p.OptedOutCategories, category) {
+		return true
+	}
+	return slices.Contains(p.OptedOutChannels, name)
+}
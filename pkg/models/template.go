@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// Template шаблон уведомления. Каждое изменение создает новую версию,
+// старые версии не меняются, чтобы запланированные уведомления отправлялись так, как были созданы.
+// Кроме основного варианта шаблон может содержать переводы на другие языки.
+type Template struct {
+	ID        string                      `json:"id"`
+	Version   int                         `json:"version"`
+	Name      string                      `json:"name"`
+	Locale    string                      `json:"locale"` // язык основного варианта
+	Variants  TemplateVariants            `json:"variants"`
+	Locales   map[string]TemplateVariants `json:"locales,omitempty"` // варианты на других языках
+	CreatedAt time.Time                   `json:"created_at"`
+}
+
+// TemplateVariants тексты шаблона для разных каналов в синтаксисе Go text/template.
+// SMS - простой текст, используется и каналами, для которых нет своего варианта.
+type TemplateVariants struct {
+	EmailSubject string `json:"email_subject,omitempty" binding:"max=1000"`
+	EmailText    string `json:"email_text,omitempty" binding:"max=100000"`
+	EmailHTML    string `json:"email_html,omitempty" binding:"max=100000"` // html/template, значения экранируются
+	Telegram     string `json:"telegram,omitempty" binding:"max=10000"`    // Markdown, значения экранируются
+	SMS          string `json:"sms,omitempty" binding:"max=10000"`
+}
+
+// TemplateRef ссылка уведомления на шаблон и значения его переменных.
+type TemplateRef struct {
+	ID        string         `json:"id"`
+	Version   int            `json:"version"` // закрепляется при создании уведомления
+	Variables map[string]any `json:"variables,omitempty"`
+}
+
+// RenderedTemplate шаблон, заполненный переменными.
+type RenderedTemplate struct {
+	Version int    // версия шаблона
+	Locale  string // язык выбранного варианта
+
+	EmailSubject string
+	EmailText    string
+	EmailHTML    string
+	Telegram     string
+	SMS          string
+}